@@ -0,0 +1,62 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net"
+)
+
+// CIDR is an IPv4 or IPv6 network written in CIDR notation, such as
+// "10.0.0.0/8" or "2001:db8::/32".
+type CIDR struct {
+	*net.IPNet
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *CIDR) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		return fmt.Errorf("invalid CIDR %q", s)
+	}
+	c.IPNet = n
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (c CIDR) MarshalYAML() (interface{}, error) {
+	if c.IPNet == nil {
+		return nil, nil
+	}
+	return c.String(), nil
+}
+
+// InNetworks returns true if v is an IP address within any of the
+// networks. Values that are no IP addresses are in none of them.
+func InNetworks(v string, nets []CIDR) bool {
+	ip := net.ParseIP(v)
+	if ip == nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+)
+
+func TestMatchCIDR(t *testing.T) {
+	cfg, err := Load(`
+route:
+  receiver: default
+  routes:
+  - match_cidr:
+      instance_ip: ["10.0.0.0/8", "192.168.0.0/16"]
+    receiver: private
+  - match_cidr:
+      instance_ip: ["2001:db8::/32"]
+    receiver: ipv6
+
+receivers:
+- name: default
+  blackhole: true
+- name: private
+  blackhole: true
+- name: ipv6
+  blackhole: true
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	cases := []struct {
+		ip       string
+		receiver string
+	}{
+		{ip: "10.1.2.3", receiver: "private"},
+		{ip: "192.168.255.255", receiver: "private"},
+		{ip: "192.169.0.1", receiver: "default"},
+		{ip: "2001:db8::1", receiver: "ipv6"},
+		{ip: "2001:db9::1", receiver: "default"},
+		// IPv4-mapped IPv6 addresses are in IPv4 networks.
+		{ip: "::ffff:10.0.0.1", receiver: "private"},
+		// Values that are no IP addresses do not match.
+		{ip: "10.0.0.1:9100", receiver: "default"},
+		{ip: "", receiver: "default"},
+	}
+
+	for _, c := range cases {
+		matches := cfg.RoutesForLabels(model.LabelSet{"instance_ip": model.LabelValue(c.ip)})
+		if len(matches) != 1 || matches[0].Receiver != c.receiver {
+			t.Errorf("%q: expected a single match for receiver %q, got %v", c.ip, c.receiver, matches)
+		}
+	}
+
+	out, err := yaml.Marshal(cfg.Route.Routes[0])
+	if err != nil {
+		t.Fatalf("Error marshaling route: %s", err)
+	}
+	var r Route
+	if err := yaml.Unmarshal(out, &r); err != nil {
+		t.Fatalf("Error unmarshaling marshaled route: %s", err)
+	}
+	if nets := r.MatchCIDR["instance_ip"]; len(nets) != 2 || nets[1].String() != "192.168.0.0/16" {
+		t.Errorf("Expected networks after marshaling, got %v", nets)
+	}
+}
+
+func TestMatchCIDRErrors(t *testing.T) {
+	cases := []struct {
+		route string
+		err   string
+	}{
+		{
+			route: `
+  - match_cidr:
+      instance_ip: ["10.0.0.0/33"]
+`,
+			err: `invalid CIDR "10.0.0.0/33"`,
+		},
+		{
+			route: `
+  - match_cidr:
+      instance_ip: ["10.0.0.1"]
+`,
+			err: `invalid CIDR "10.0.0.1"`,
+		},
+		{
+			route: `
+  - match_cidr:
+      instance_ip: []
+`,
+			err: `match_cidr for label "instance_ip" must list at least one network`,
+		},
+	}
+
+	for _, c := range cases {
+		expectLoadError(t, `
+route:
+  receiver: default
+  routes:`+c.route+`
+receivers:
+- name: default
+  blackhole: true
+`, c.err)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"regexp"
+	"time"
+)
+
+// sharedTypes are the types of values that are never modified after they
+// are created. Pointers to them are shared between a config and its
+// clones.
+var sharedTypes = map[reflect.Type]struct{}{
+	reflect.TypeOf(regexp.Regexp{}): {},
+	reflect.TypeOf(time.Location{}): {},
+	reflect.TypeOf(url.Userinfo{}):  {},
+}
+
+// Clone returns a deep copy of the configuration, which can be modified
+// without affecting c. Compiled regular expressions are safe for
+// concurrent use and shared with the copy.
+func (c *Config) Clone() *Config {
+	cc := &Config{}
+	deepCopy(reflect.ValueOf(cc).Elem(), reflect.ValueOf(c).Elem())
+	return cc
+}
+
+// deepCopy copies src into dst, which must be settable values of the same
+// type. Unexported struct fields are copied shallowly.
+func deepCopy(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		if _, ok := sharedTypes[src.Type().Elem()]; ok {
+			dst.Set(src)
+			return
+		}
+		p := reflect.New(src.Type().Elem())
+		deepCopy(p.Elem(), src.Elem())
+		dst.Set(p)
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		v := reflect.New(src.Elem().Type()).Elem()
+		deepCopy(v, src.Elem())
+		dst.Set(v)
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(s.Index(i), src.Index(i))
+		}
+		dst.Set(s)
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		m := reflect.MakeMap(src.Type())
+		for _, k := range src.MapKeys() {
+			v := reflect.New(src.Type().Elem()).Elem()
+			deepCopy(v, src.MapIndex(k))
+			m.SetMapIndex(k, v)
+		}
+		dst.Set(m)
+	case reflect.Struct:
+		dst.Set(src)
+		t := src.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				continue
+			}
+			deepCopy(dst.Field(i), src.Field(i))
+		}
+	default:
+		dst.Set(src)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import "testing"
+
+func TestConfigClone(t *testing.T) {
+	cfg := mustLoad(t, `
+global:
+  slack_api_url: https://hooks.slack.com/services/token
+
+route:
+  receiver: default
+  group_by: [alertname]
+  routes:
+  - match:
+      team: a
+    match_re:
+      service: api|web
+    receiver: team-a
+    routes:
+    - match:
+        severity: critical
+      receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+- name: team-a
+  slack_configs:
+  - channel: '#team-a'
+
+inhibit_rules:
+- source_match:
+    severity: critical
+  target_match:
+    severity: warning
+  equal: [cluster]
+`)
+	before := cfg.String()
+	hash := cfg.Hash()
+
+	cc := cfg.Clone()
+	if cc.String() != before {
+		t.Errorf("Expected the clone to keep the original input")
+	}
+	if cc.Hash() != hash {
+		t.Fatalf("Expected the clone to equal the config")
+	}
+	if !cc.Route.Routes[0].MatchRE["service"].MatchString("api") {
+		t.Errorf("Expected the regexp of the clone to match")
+	}
+
+	cc.Route.GroupBy[0] = "cluster"
+	cc.Route.Routes[0].Match["team"] = "b"
+	re, err := NewRegexp("db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc.Route.Routes[0].MatchRE["service"] = re
+	cc.Route.Routes[0].Routes[0].Match["severity"] = "warning"
+	cc.Route.Routes[0].Routes = append(cc.Route.Routes[0].Routes[:0], &Route{Receiver: "team-a"})
+	cc.Route.Routes = append(cc.Route.Routes, &Route{Receiver: "default"})
+	cc.Receivers[1].SlackConfigs[0].Channel = "#team-b"
+	cc.Receivers[1].SlackConfigs[0].APIURL.Path = "/services/other"
+	cc.Global.SlackAPIURL.Host = "example.com"
+	cc.InhibitRules[0].SourceMatch["severity"] = "info"
+	cc.InhibitRules[0].Equal[0] = "instance"
+
+	if cfg.Hash() != hash {
+		t.Errorf("Modifying the clone changed the config")
+	}
+	if cfg.Route.Routes[0].Match["team"] != "a" || len(cfg.Route.Routes) != 1 {
+		t.Errorf("Modifying the routes of the clone changed the config")
+	}
+	if r := cfg.Route.Routes[0].Routes[0]; r.Receiver != "default" || r.Match["severity"] != "critical" {
+		t.Errorf("Modifying the nested routes of the clone changed the config")
+	}
+}
@@ -14,61 +14,592 @@
 package config
 
 import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"sort"
 	"strings"
+	"text/template"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/prometheus/common/model"
+	"golang.org/x/net/context"
 	"gopkg.in/yaml.v2"
 )
 
-var patAuthLine = regexp.MustCompile(`((?:api_token|api_key|service_key|api_url|auth_token):\s+)(".+"|'.+'|[^\s]+)`)
+// patAuthLine matches the settings of all fields of type Secret in the
+// configuration input, which is either YAML or JSON.
+var patAuthLine = regexp.MustCompile(`(\b(?:` + strings.Join(secretKeys(reflect.TypeOf(Config{})), "|") + `)"?:\s*)("(?:[^"\\]|\\.)*"|'(?:[^']|'')*'|[^\s]+)`)
 
 // Secret is a string that must not be revealed on marshaling.
 type Secret string
 
-// MarshalYAML implements the yaml.Marshaler interface.
+// isSecretType returns true if values of type t are hidden on marshaling.
+func isSecretType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == reflect.TypeOf(Secret("")) || t == reflect.TypeOf(SecretURL{})
+}
+
+// secretKeys returns the sorted YAML keys of all fields of type Secret or
+// SecretURL that can be reached from the given type.
+func secretKeys(t reflect.Type) []string {
+	var (
+		keys    = map[string]struct{}{}
+		visited = map[reflect.Type]struct{}{}
+		walk    func(reflect.Type)
+	)
+	walk = func(t reflect.Type) {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			walk(t.Elem())
+		case reflect.Struct:
+			if _, ok := visited[t]; ok {
+				return
+			}
+			visited[t] = struct{}{}
+
+			for i := 0; i < t.NumField(); i++ {
+				f := t.Field(i)
+				if !isSecretType(f.Type) {
+					walk(f.Type)
+					continue
+				}
+				key := strings.Split(f.Tag.Get("yaml"), ",")[0]
+				if key == "" {
+					key = strings.ToLower(f.Name)
+				}
+				keys[key] = struct{}{}
+			}
+		}
+	}
+	walk(t)
+
+	var res []string
+	for k := range keys {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// MarshalYAML implements the yaml.Marshaler interface. Secrets are always
+// hidden, MarshalWithSecrets reveals them in the marshaled document.
 func (s Secret) MarshalYAML() (interface{}, error) {
 	return "<hidden>", nil
 }
 
+// String hides the secret, so that it is not revealed when formatted by
+// accident. Convert it to a string to get its value.
+func (s Secret) String() string {
+	return "<hidden>"
+}
+
+// GoString hides the secret when formatted with %#v.
+func (s Secret) GoString() string {
+	return "<hidden>"
+}
+
+// Equal returns true if both secrets are equal. The comparison takes
+// constant time, so that it does not reveal the secrets by its timing.
+func (s Secret) Equal(other Secret) bool {
+	return subtle.ConstantTimeCompare([]byte(s), []byte(other)) == 1
+}
+
+// HostPort is the address of an SMTP smarthost, split into host and port.
+type HostPort struct {
+	Host string
+	Port string
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (hp *HostPort) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	if s == "" {
+		*hp = HostPort{}
+		return nil
+	}
+	host, port, err := splitHostPort(s)
+	if err != nil {
+		return fmt.Errorf("invalid smarthost %q: %s", s, err)
+	}
+	hp.Host, hp.Port = host, port
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (hp HostPort) MarshalYAML() (interface{}, error) {
+	// An empty string would not be unmarshaled again, as it is decoded
+	// like null without calling UnmarshalYAML.
+	if hp.IsZero() {
+		return nil, nil
+	}
+	return hp.String(), nil
+}
+
+// IsZero returns true if neither host nor port are set.
+func (hp HostPort) IsZero() bool {
+	return hp.Host == "" && hp.Port == ""
+}
+
+func (hp HostPort) String() string {
+	if hp.IsZero() {
+		return ""
+	}
+	return net.JoinHostPort(hp.Host, hp.Port)
+}
+
+// The limits applied to loaded configurations unless LoadOptions set
+// others. They are far above the needs of any reasonable configuration.
+const (
+	DefaultMaxSize         = 32 << 20
+	DefaultMaxRoutes       = 100000
+	DefaultMaxReceivers    = 100000
+	DefaultMaxInhibitRules = 100000
+
+	// DefaultExecTimeout is the time after which secret commands are
+	// killed unless LoadOptions set another timeout.
+	DefaultExecTimeout = 10 * time.Second
+)
+
+// LoadOptions control how a configuration is loaded. The zero value
+// loads configurations like Load: the input is used as is, deprecated
+// fields are allowed and the default limits apply.
+type LoadOptions struct {
+	// ExpandEnv expands $VAR and ${VAR} references in all values with the
+	// process environment, like LoadWithEnv.
+	ExpandEnv bool
+
+	// Vars are the variables the input is executed with as a
+	// text/template, like LoadWithVars. The input is not executed if Vars
+	// is nil. Variables are expanded before environment variables.
+	Vars map[string]string
+
+	// StrictMode rejects configurations that use deprecated fields,
+	// which are otherwise only reported by Config.Lint.
+	StrictMode bool
+
+	// MergeDuplicateReceivers merges receivers of the same name into the
+	// first of them instead of rejecting the configuration. Their
+	// notifier configurations are concatenated, all other fields must
+	// be equal.
+	MergeDuplicateReceivers bool
+
+	// MaxSize is the maximum size of the input in bytes, which is checked
+	// before parsing it. MaxRoutes, MaxReceivers and MaxInhibitRules limit
+	// the number of the respective entries. Limits that are 0 default to
+	// the respective Default* constant, negative limits are disabled.
+	MaxSize         int
+	MaxRoutes       int
+	MaxReceivers    int
+	MaxInhibitRules int
+
+	// AllowExec allows running the commands configured to provide
+	// secrets. Configurations with secret commands fail to load without
+	// it. Commands running longer than ExecTimeout, or DefaultExecTimeout
+	// if it is 0, are killed.
+	AllowExec   bool
+	ExecTimeout time.Duration
+}
+
+// limit returns the limit l, or def if l is 0.
+func limit(l, def int) int {
+	if l == 0 {
+		return def
+	}
+	return l
+}
+
+// checkLimits returns an error if the config has more routes, receivers
+// or inhibit rules than allowed by opts.
+func (c *Config) checkLimits(opts LoadOptions) error {
+	routes := 0
+	if c.Route != nil {
+		c.Route.Walk(func(*Route) { routes++ })
+	}
+	for _, l := range []struct {
+		kind     string
+		n, limit int
+	}{
+		{"routes", routes, limit(opts.MaxRoutes, DefaultMaxRoutes)},
+		{"receivers", len(c.Receivers), limit(opts.MaxReceivers, DefaultMaxReceivers)},
+		{"inhibit rules", len(c.InhibitRules), limit(opts.MaxInhibitRules, DefaultMaxInhibitRules)},
+	} {
+		if l.limit >= 0 && l.n > l.limit {
+			return fmt.Errorf("config has %d %s, more than the limit of %d", l.n, l.kind, l.limit)
+		}
+	}
+	return nil
+}
+
 // Load parses the YAML input s into a Config.
 func Load(s string) (*Config, error) {
+	return LoadWithOptions(s, LoadOptions{})
+}
+
+// LoadWithOptions parses the YAML input s into a Config with the given
+// options.
+func LoadWithOptions(s string, opts LoadOptions) (*Config, error) {
+	cfg, err := loadWithOptions(s, opts)
+	if err != nil {
+		return nil, err
+	}
+	// Without a config file, secret files are read relative to the
+	// working directory.
+	if err := cfg.loadSecrets(opts); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadWithOptions is like LoadWithOptions but does not read the secret
+// files, which may first have to be resolved relative to a config file.
+func loadWithOptions(s string, opts LoadOptions) (*Config, error) {
+	if err := checkSize(s, opts); err != nil {
+		return nil, err
+	}
+	expanded, err := expand(s, opts)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := load(expanded, opts)
+	if err != nil {
+		if expanded != s {
+			clearLine(err)
+		}
+		return nil, err
+	}
+	// Keep the unexpanded input so secrets taken from the environment
+	// never show up in the config string.
+	cfg.original = s
+	return cfg, nil
+}
+
+// expand returns the input s with the variables and environment
+// variables expanded as configured by opts.
+func expand(s string, opts LoadOptions) (string, error) {
+	var err error
+	if opts.Vars != nil {
+		if s, err = expandVars(s, opts.Vars); err != nil {
+			return "", err
+		}
+	}
+	if opts.ExpandEnv {
+		if s, err = expandEnv(s); err != nil {
+			return "", err
+		}
+	}
+	return s, nil
+}
+
+// checkSize returns an error if s is larger than allowed by opts.
+func checkSize(s string, opts LoadOptions) error {
+	if max := limit(opts.MaxSize, DefaultMaxSize); max >= 0 && len(s) > max {
+		return fmt.Errorf("config size of %d bytes exceeds the limit of %d bytes", len(s), max)
+	}
+	return nil
+}
+
+// load parses the expanded YAML input s into a Config.
+func load(s string, opts LoadOptions) (*Config, error) {
+	// Expansion may have grown the input.
+	if err := checkSize(s, opts); err != nil {
+		return nil, err
+	}
+	in := s
+	if opts.MergeDuplicateReceivers {
+		merged, err := mergeDuplicateReceivers(s)
+		if err != nil {
+			return nil, newConfigError(s, err)
+		}
+		s = merged
+	}
+	cfg, err := decode(s, opts)
+	if err != nil {
+		if s != in {
+			clearLine(err)
+		}
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// decode parses and validates the YAML input s. All errors are returned
+// as a ConfigError.
+func decode(s string, opts LoadOptions) (*Config, error) {
 	cfg := &Config{}
 	err := yaml.Unmarshal([]byte(s), cfg)
 	if err != nil {
-		return nil, err
+		return nil, newConfigError(s, err)
 	}
-	// Check if we have a root route. We cannot check for it in the
-	// UnmarshalYAML method because it won't be called if the input is empty
-	// (e.g. the config file is empty or only contains whitespace).
-	if cfg.Route == nil {
-		return nil, errors.New("no route provided in config")
+	if err := cfg.checkLimits(opts); err != nil {
+		return nil, newConfigError(s, err)
+	}
+	// Validate the config as a whole. We cannot do it in the UnmarshalYAML
+	// method because it won't be called if the input is empty (e.g. the
+	// config file is empty or only contains whitespace).
+	if err := cfg.Validate(); err != nil {
+		return nil, newConfigError(s, err)
+	}
+	if opts.StrictMode {
+		if err := cfg.checkDeprecated(); err != nil {
+			return nil, newConfigError(s, err)
+		}
 	}
 
 	cfg.original = s
 	return cfg, nil
 }
 
+// LoadJSON parses the JSON input s into a Config.
+func LoadJSON(s string) (*Config, error) {
+	y, err := jsonToYAML(s)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Load(y)
+	if err != nil {
+		// Lines of the converted input are meaningless for the JSON input.
+		clearLine(err)
+		return nil, err
+	}
+	cfg.original = s
+	return cfg, nil
+}
+
+// jsonToYAML converts the JSON input s to YAML, so that JSON input is
+// decoded and validated exactly like YAML input.
+func jsonToYAML(s string) (string, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return "", err
+	}
+	b, err := yaml.Marshal(jsonNumbers(v))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// jsonNumbers replaces all JSON numbers in v with integers or floats so
+// that they are not marshaled as YAML strings.
+func jsonNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = jsonNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonNumbers(e)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return v
+}
+
+// LoadWithEnv parses the YAML input s into a Config after expanding
+// $VAR and ${VAR} references in all values with the process environment.
+// Referencing an unset variable is an error. $$ is an escaped $, and
+// notification template actions within {{ and }} are not expanded.
+func LoadWithEnv(s string) (*Config, error) {
+	return LoadWithOptions(s, LoadOptions{ExpandEnv: true})
+}
+
+// LoadWithVars parses the YAML input s into a Config after executing it
+// as a text/template with the given variables, so that e.g.
+// {{ .Region }} is replaced by vars["Region"]. Referencing a missing
+// variable is an error. The input is used as is if vars is nil.
+//
+// As the whole input is executed, notification templates within it must
+// be escaped, e.g. as {{ "{{ .CommonLabels.alertname }}" }}.
+func LoadWithVars(s string, vars map[string]string) (*Config, error) {
+	return LoadWithOptions(s, LoadOptions{Vars: vars})
+}
+
+// expandVars executes the input s as a template with the given variables.
+func expandVars(s string, vars map[string]string) (string, error) {
+	tmpl, err := template.New("config").Option("missingkey=error").Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("parsing config template: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("expanding config variables: %s", err)
+	}
+	return buf.String(), nil
+}
+
+// expandEnv expands environment variable references in the values
+// of the YAML document s. Keys are left untouched.
+func expandEnv(s string) (string, error) {
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal([]byte(s), &doc); err != nil {
+		return "", err
+	}
+	v, err := expandEnvValue(doc)
+	if err != nil {
+		return "", err
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func expandEnvValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		for i, item := range v {
+			ev, err := expandEnvValue(item.Value)
+			if err != nil {
+				return nil, err
+			}
+			v[i].Value = ev
+		}
+		return v, nil
+	case []interface{}:
+		for i, item := range v {
+			ev, err := expandEnvValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = ev
+		}
+		return v, nil
+	case string:
+		return expandEnvString(v)
+	}
+	return v, nil
+}
+
+// envNameRE matches a $VAR or ${VAR} reference at the start of a string.
+var envNameRE = regexp.MustCompile(`^\$(?:([A-Za-z_][A-Za-z0-9_]*)|\{([A-Za-z_][A-Za-z0-9_]*)\})`)
+
+// expandEnvString expands $VAR and ${VAR} references in s. $$ is replaced
+// by a single $. Notification template actions like {{ $i }} are left
+// untouched, as are $ signs not followed by a variable name.
+func expandEnvString(s string) (string, error) {
+	var buf bytes.Buffer
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, "{{"):
+			end := strings.Index(s, "}}")
+			if end < 0 {
+				end = len(s) - 2
+			}
+			buf.WriteString(s[:end+2])
+			s = s[end+2:]
+		case strings.HasPrefix(s, "$$"):
+			buf.WriteByte('$')
+			s = s[2:]
+		case s[0] == '$' && envNameRE.MatchString(s):
+			m := envNameRE.FindStringSubmatch(s)
+			name := m[1] + m[2]
+			val, ok := os.LookupEnv(name)
+			if !ok {
+				return "", fmt.Errorf("environment variable %q is not set", name)
+			}
+			buf.WriteString(val)
+			s = s[len(m[0]):]
+		default:
+			buf.WriteByte(s[0])
+			s = s[1:]
+		}
+	}
+	return buf.String(), nil
+}
+
 // LoadFile parses the given YAML file into a Config.
 func LoadFile(filename string) (*Config, error) {
-	content, err := ioutil.ReadFile(filename)
+	return LoadFileWithOptions(filename, LoadOptions{})
+}
+
+// LoadFileWithOptions parses the given YAML file into a Config with the
+// given options. They apply to the file with its includes expanded. The
+// size limit is also checked for every file as it is read, so that large
+// included files are never parsed.
+func LoadFileWithOptions(filename string, opts LoadOptions) (*Config, error) {
+	s, err := readConfigFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSize(s, opts); err != nil {
+		return nil, err
+	}
+	expanded, err := expandIncludes(filename, s, opts)
 	if err != nil {
 		return nil, err
 	}
-	cfg, err := Load(string(content))
+	cfg, err := loadResolved(filename, expanded, opts)
+	if err != nil {
+		// Lines of the merged input are meaningless for the file.
+		if expanded != s {
+			clearLine(err)
+		}
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadResolved parses the config s read from the file filename, resolves
+// the paths within it relative to the file and reads the files it
+// refers to.
+func loadResolved(filename, s string, opts LoadOptions) (*Config, error) {
+	cfg, err := loadWithOptions(s, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	resolveFilepaths(filepath.Dir(filename), cfg)
+
+	if err := cfg.checkTemplates(); err != nil {
+		return nil, err
+	}
+	if err := cfg.loadSecrets(opts); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
+// readConfigFile returns the content of the given configuration file as
+// YAML. Files with a .json extension are parsed as JSON, all others as
+// YAML.
+func readConfigFile(filename string) (string, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	if strings.ToLower(filepath.Ext(filename)) == ".json" {
+		return jsonToYAML(string(content))
+	}
+	return string(content), nil
+}
+
 // resolveFilepaths joins all relative paths in a configuration
 // with a given base directory.
 func resolveFilepaths(baseDir string, cfg *Config) {
@@ -82,6 +613,213 @@ func resolveFilepaths(baseDir string, cfg *Config) {
 	for i, tf := range cfg.Templates {
 		cfg.Templates[i] = join(tf)
 	}
+	for i, inc := range cfg.Include {
+		cfg.Include[i] = join(inc)
+	}
+	cfg.Global.HTTPConfig.resolveFilepaths(join)
+	for _, rcv := range cfg.Receivers {
+		for i, tf := range rcv.Templates {
+			rcv.Templates[i] = join(tf)
+		}
+		for _, wc := range rcv.WebhookConfigs {
+			wc.HTTPConfig.resolveFilepaths(join)
+		}
+		for _, sc := range rcv.SlackConfigs {
+			sc.HTTPConfig.resolveFilepaths(join)
+		}
+		for _, hc := range rcv.HipchatConfigs {
+			hc.HTTPConfig.resolveFilepaths(join)
+		}
+		for _, pdc := range rcv.PagerdutyConfigs {
+			pdc.HTTPConfig.resolveFilepaths(join)
+		}
+		for _, ogc := range rcv.OpsGenieConfigs {
+			ogc.HTTPConfig.resolveFilepaths(join)
+		}
+		for _, voc := range rcv.VictorOpsConfigs {
+			voc.HTTPConfig.resolveFilepaths(join)
+		}
+		for _, wcc := range rcv.WechatConfigs {
+			wcc.HTTPConfig.resolveFilepaths(join)
+		}
+		for _, tc := range rcv.TelegramConfigs {
+			tc.HTTPConfig.resolveFilepaths(join)
+		}
+		for _, mc := range rcv.MSTeamsConfigs {
+			mc.HTTPConfig.resolveFilepaths(join)
+		}
+		for _, dc := range rcv.DiscordConfigs {
+			dc.HTTPConfig.resolveFilepaths(join)
+		}
+		for _, kc := range rcv.KafkaConfigs {
+			kc.HTTPConfig.resolveFilepaths(join)
+		}
+		for _, sc := range rcv.SNSConfigs {
+			sc.HTTPConfig.resolveFilepaths(join)
+		}
+	}
+
+	cfg.Global.SlackAPIURLFile = join(cfg.Global.SlackAPIURLFile)
+	cfg.Global.HipchatAuthTokenFile = join(cfg.Global.HipchatAuthTokenFile)
+	cfg.Global.SMTPAuthPasswordFile = join(cfg.Global.SMTPAuthPasswordFile)
+}
+
+// readSecretFile returns the content of the file at the given path
+// with trailing newlines removed.
+func readSecretFile(filename string) (Secret, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("reading secret file: %s", err)
+	}
+	return Secret(strings.TrimRight(string(b), "\r\n")), nil
+}
+
+// readSecret returns the secret configured under key, which is read from
+// file or, if it is set, taken from the output of command.
+func readSecret(key, file string, command []string, opts LoadOptions) (Secret, error) {
+	if len(command) == 0 {
+		return readSecretFile(file)
+	}
+	if !opts.AllowExec {
+		return "", fmt.Errorf("%s_command is configured but running commands is not allowed", key)
+	}
+	timeout := opts.ExecTimeout
+	if timeout == 0 {
+		timeout = DefaultExecTimeout
+	}
+	return runSecretCommand(key, command, timeout)
+}
+
+// runSecretCommand runs the command configured under key and returns its
+// output with trailing white space removed. The command is killed after
+// timeout.
+func runSecretCommand(key string, command []string, timeout time.Duration) (Secret, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("%s_command did not finish within %s", key, timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("running %s_command: %s: %s", key, err, msg)
+		}
+		return "", fmt.Errorf("running %s_command: %s", key, err)
+	}
+	return Secret(strings.TrimRightFunc(stdout.String(), unicode.IsSpace)), nil
+}
+
+// checkTemplates verifies that template paths without glob meta characters
+// exist, unless AllowMissingTemplates is set. Patterns may match no files,
+// as the files may be added later on. All template files are parsed to
+// report syntax errors and calls of undefined functions.
+func (c *Config) checkTemplates() error {
+	templates := append([]string{}, c.Templates...)
+	for _, rcv := range c.Receivers {
+		templates = append(templates, rcv.Templates...)
+	}
+	for _, tf := range templates {
+		abs, err := filepath.Abs(tf)
+		if err != nil {
+			abs = tf
+		}
+		if !strings.ContainsAny(tf, "*?[") {
+			if _, err := os.Stat(tf); os.IsNotExist(err) {
+				if c.AllowMissingTemplates {
+					continue
+				}
+				return fmt.Errorf("template file %s does not exist", abs)
+			} else if err != nil {
+				return fmt.Errorf("template file %s: %s", abs, err)
+			}
+			if err := checkTemplateFile(tf); err != nil {
+				return err
+			}
+			continue
+		}
+		files, err := filepath.Glob(tf)
+		if err != nil {
+			return fmt.Errorf("invalid template pattern %s: %s", abs, err)
+		}
+		for _, f := range files {
+			if err := checkTemplateFile(f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkTemplateFile parses the template file filename with the functions
+// available to notification templates.
+func checkTemplateFile(filename string) error {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		abs = filename
+	}
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("template file %s: %s", abs, err)
+	}
+	if _, err := parseTemplate(filepath.Base(filename), string(b)); err != nil {
+		return fmt.Errorf("invalid template file %s: %s", abs, strings.TrimPrefix(err.Error(), "template: "))
+	}
+	return nil
+}
+
+// loadSecrets reads all secrets configured via a file or a command in the
+// global config and applies them to receivers that did not set their own
+// value.
+func (c *Config) loadSecrets(opts LoadOptions) error {
+	var err error
+
+	if c.Global.SlackAPIURLFile != "" || len(c.Global.SlackAPIURLCommand) > 0 {
+		s, err := readSecret("slack_api_url", c.Global.SlackAPIURLFile, c.Global.SlackAPIURLCommand, opts)
+		if err != nil {
+			return err
+		}
+		u, err := parseURL(string(s))
+		if err != nil {
+			src := c.Global.SlackAPIURLFile
+			if src == "" {
+				src = "output of slack_api_url_command"
+			}
+			return fmt.Errorf("invalid secret URL in %s, %s", src, err)
+		}
+		c.Global.SlackAPIURL = (*SecretURL)(u)
+	}
+	if c.Global.HipchatAuthTokenFile != "" || len(c.Global.HipchatAuthTokenCommand) > 0 {
+		if c.Global.HipchatAuthToken, err = readSecret("hipchat_auth_token", c.Global.HipchatAuthTokenFile, c.Global.HipchatAuthTokenCommand, opts); err != nil {
+			return err
+		}
+	}
+	if c.Global.SMTPAuthPasswordFile != "" || len(c.Global.SMTPAuthPasswordCommand) > 0 {
+		if c.Global.SMTPAuthPassword, err = readSecret("smtp_auth_password", c.Global.SMTPAuthPasswordFile, c.Global.SMTPAuthPasswordCommand, opts); err != nil {
+			return err
+		}
+	}
+
+	for _, rcv := range c.Receivers {
+		for _, ec := range rcv.EmailConfigs {
+			if ec.AuthPassword == "" {
+				ec.AuthPassword = c.Global.SMTPAuthPassword
+			}
+		}
+		for _, sc := range rcv.SlackConfigs {
+			if sc.APIURL == nil {
+				sc.APIURL = c.Global.SlackAPIURL
+			}
+		}
+		for _, hc := range rcv.HipchatConfigs {
+			if hc.AuthToken == "" {
+				hc.AuthToken = c.Global.HipchatAuthToken
+			}
+		}
+	}
+	return nil
 }
 
 // Config is the top-level configuration for Alertmanager's config files.
@@ -92,6 +830,19 @@ type Config struct {
 	Receivers    []*Receiver    `yaml:"receivers,omitempty"`
 	Templates    []string       `yaml:"templates"`
 
+	// AllowMissingTemplates disables the check of LoadFile that all
+	// template paths without glob meta characters exist.
+	AllowMissingTemplates bool `yaml:"allow_missing_templates,omitempty"`
+
+	// TimeIntervals defines named time intervals that routes can refer to.
+	TimeIntervals []*TimeInterval `yaml:"time_intervals,omitempty"`
+
+	// Include lists glob patterns of files whose receivers, inhibit rules
+	// and child routes are merged into the config. The global blocks of
+	// the files override single fields of the global block of the config.
+	// They are only processed by LoadFile.
+	Include []string `yaml:"include,omitempty"`
+
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline"`
 
@@ -99,15 +850,368 @@ type Config struct {
 	original string
 }
 
-func checkOverflow(m map[string]interface{}, ctx string) error {
-	if len(m) > 0 {
-		var keys []string
-		for k := range m {
-			keys = append(keys, k)
+// Validate checks the consistency of the configuration across its
+// sections, which cannot be done while unmarshaling them one by one.
+func (c *Config) Validate() error {
+	if c.Route == nil {
+		return errors.New("no route provided in config")
+	}
+	if c.Route.Receiver == "" {
+		return errors.New("root route must specify a default receiver")
+	}
+	// The root route has to match all alerts.
+	if len(c.Route.ActiveTimeIntervals) > 0 {
+		return errors.New("root route must not have any active time intervals")
+	}
+
+	names := map[string]struct{}{}
+	for i, rcv := range c.Receivers {
+		names[rcv.Name] = struct{}{}
+
+		// Receivers without integrations drop all notifications, which
+		// is only intended for blackhole receivers.
+		if rcv.IsEmpty() != rcv.Blackhole {
+			err := fmt.Errorf("receiver %q has no notification integrations configured", rcv.Name)
+			if rcv.Blackhole {
+				err = fmt.Errorf("blackhole receiver %q must not have notification integrations configured", rcv.Name)
+			}
+			return &ConfigError{Path: fmt.Sprintf("receivers[%d]", i), Err: err}
+		}
+	}
+	if err := checkReceivers(c.Route, "route", names); err != nil {
+		return err
+	}
+	if err := checkLeafReceivers(c.Route, "route", ""); err != nil {
+		return err
+	}
+
+	intervals := map[string]struct{}{}
+	for _, ti := range c.TimeIntervals {
+		intervals[ti.Name] = struct{}{}
+	}
+	return checkTimeIntervals(c.Route, "route", intervals)
+}
+
+// ReceiverByName returns the receiver with the given name and whether it
+// exists.
+func (c *Config) ReceiverByName(name string) (*Receiver, bool) {
+	for _, rcv := range c.Receivers {
+		if rcv.Name == name {
+			return rcv, true
+		}
+	}
+	return nil, false
+}
+
+// ResolveReceiver returns a deep copy of the receiver with the given
+// name. The settings taken from the global configuration, such as SMTP
+// smarthosts, API URLs and tokens, are already filled in, so the copy
+// holds the options its notifications are sent with. Its secrets are
+// intact, but hidden when it is printed.
+func (c *Config) ResolveReceiver(name string) (*Receiver, error) {
+	rcv, ok := c.ReceiverByName(name)
+	if !ok {
+		return nil, fmt.Errorf("undefined receiver %q", name)
+	}
+	res := &Receiver{}
+	deepCopy(reflect.ValueOf(res).Elem(), reflect.ValueOf(rcv).Elem())
+	return res, nil
+}
+
+// ReceiverNames returns the names of all receivers in the order they are
+// defined in.
+func (c *Config) ReceiverNames() []string {
+	names := make([]string, 0, len(c.Receivers))
+	for _, rcv := range c.Receivers {
+		names = append(names, rcv.Name)
+	}
+	return names
+}
+
+// checkLeafReceivers returns an error if a leaf route of the routing tree
+// neither has a receiver nor inherits one from its parents. A route with
+// an explicitly empty receiver clears the inherited one.
+func checkLeafReceivers(r *Route, path, receiver string) error {
+	if r.clearsReceiver {
+		receiver = ""
+	}
+	if r.Receiver != "" {
+		receiver = r.Receiver
+	}
+	if r.IsLeaf() && receiver == "" {
+		return fmt.Errorf("leaf route at path %s resolves to empty receiver", path)
+	}
+	for i, cr := range r.Routes {
+		if err := checkLeafReceivers(cr, fmt.Sprintf("%s.routes[%d]", path, i), receiver); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkReceivers returns an error if the route or any of its children
+// uses a receiver that is not in the given set of names.
+func checkReceivers(r *Route, path string, names map[string]struct{}) error {
+	if r.Receiver != "" {
+		if _, ok := names[r.Receiver]; !ok {
+			return &ConfigError{Path: path, Err: fmt.Errorf("undefined receiver %q", r.Receiver)}
+		}
+	}
+	for i, cr := range r.Routes {
+		if err := checkReceivers(cr, fmt.Sprintf("%s.routes[%d]", path, i), names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTimeIntervals returns an error if the route or any of its children
+// uses a time interval that is not in the given set of names.
+func checkTimeIntervals(r *Route, path string, names map[string]struct{}) error {
+	for _, name := range r.MuteTimeIntervals {
+		if _, ok := names[name]; !ok {
+			return &ConfigError{Path: path + ".mute_time_intervals", Err: fmt.Errorf("undefined time interval %q", name)}
+		}
+	}
+	for _, name := range r.ActiveTimeIntervals {
+		if _, ok := names[name]; !ok {
+			return &ConfigError{Path: path + ".active_time_intervals", Err: fmt.Errorf("undefined time interval %q", name)}
+		}
+	}
+	for i, cr := range r.Routes {
+		if err := checkTimeIntervals(cr, fmt.Sprintf("%s.routes[%d]", path, i), names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkOverflow returns an error listing the unknown fields in m, which
+// were left over when unmarshaling v. Unknown fields that are likely
+// misspellings of a field of v are annotated with the suggested name.
+func checkOverflow(m map[string]interface{}, ctx string, v interface{}) error {
+	if len(m) > 0 {
+		var (
+			valid = yamlKeys(reflect.TypeOf(v))
+			keys  []string
+		)
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			if s := closestName(k, valid); s != "" {
+				keys[i] = fmt.Sprintf("%s (did you mean %q?)", k, s)
+			}
+		}
+		return fmt.Errorf("unknown fields in %s: %s", ctx, strings.Join(keys, ", "))
+	}
+	return nil
+}
+
+// yamlKeys returns the sorted YAML keys of the fields of the struct type t,
+// including those of inlined structs.
+func yamlKeys(t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("yaml"), ",")
+		key := tag[0]
+		if key == "-" || f.PkgPath != "" {
+			continue
+		}
+		inline := false
+		for _, o := range tag[1:] {
+			if o == "inline" {
+				inline = true
+			}
+		}
+		if inline {
+			// Inlined maps collect the unknown fields.
+			keys = append(keys, yamlKeys(f.Type)...)
+			continue
+		}
+		if key == "" {
+			key = strings.ToLower(f.Name)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// closestName returns the candidate that name is most likely a misspelling
+// of, or the empty string if none is close enough. A candidate is close if
+// its edit distance to name is small relative to the length of name, or if
+// name merely extends it with a suffix like "_seconds".
+func closestName(name string, candidates []string) string {
+	threshold := len(name) / 3
+	if threshold < 1 {
+		threshold = 1
+	}
+	var (
+		best     string
+		bestDist = -1
+	)
+	for _, c := range candidates {
+		d := editDistance(name, c)
+		if d > threshold && !strings.HasPrefix(name, c+"_") {
+			continue
+		}
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// MarshalRedacted returns the YAML representation of the configuration
+// with all secrets replaced by "<hidden>".
+func (c *Config) MarshalRedacted() ([]byte, error) {
+	return c.marshal(false)
+}
+
+// MarshalWithSecrets returns the YAML representation of the configuration
+// including all secrets, which can be loaded again with Load.
+func (c *Config) MarshalWithSecrets() ([]byte, error) {
+	return c.marshal(true)
+}
+
+func (c *Config) marshal(secrets bool) ([]byte, error) {
+	// Included files have already been merged into the configuration.
+	cc := *c
+	cc.Include = nil
+	return marshalYAML(&cc, secrets)
+}
+
+// marshalYAML marshals v to YAML, revealing secrets if requested. The
+// secrets are filled into the redacted document, so that marshaling
+// never depends on state shared with concurrent marshaling. The keys of
+// maps are sorted by the encoder, so the result is stable as long as no
+// MarshalYAML method emits the entries of a map in iteration order.
+func marshalYAML(v interface{}, secrets bool) ([]byte, error) {
+	b, err := yaml.Marshal(v)
+	if err != nil || !secrets {
+		return b, err
+	}
+	// Mappings are decoded as MapSlice to keep the order of their keys.
+	var doc interface{}
+	var ms yaml.MapSlice
+	if err := yaml.Unmarshal(b, &ms); err == nil {
+		doc = ms
+	} else if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	doc, changed := revealSecrets(doc, reflect.ValueOf(v))
+	if !changed {
+		return b, nil
+	}
+	return yaml.Marshal(doc)
+}
+
+// revealSecrets replaces the hidden secrets in the node of a marshaled
+// document with the secrets of the value v it was marshaled from. It
+// returns the new node and whether any secret was revealed.
+func revealSecrets(node interface{}, v reflect.Value) (interface{}, bool) {
+	if !v.IsValid() || node == nil {
+		return node, false
+	}
+	switch x := v.Interface().(type) {
+	case Secret:
+		return string(x), true
+	case *SecretURL:
+		if x == nil || x.URL == nil {
+			return node, false
 		}
-		return fmt.Errorf("unknown fields in %s: %s", ctx, strings.Join(keys, ", "))
+		return x.URL.String(), true
 	}
-	return nil
+	// Follow custom marshalers that marshal a different struct, such as
+	// GlobalConfig. All others marshal scalars without secrets.
+	if m, ok := v.Interface().(yaml.Marshaler); ok {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return node, false
+		}
+		mv, err := m.MarshalYAML()
+		if err != nil {
+			return node, false
+		}
+		rv := reflect.ValueOf(mv)
+		if rv.Kind() != reflect.Struct || rv.Type() == v.Type() {
+			return node, false
+		}
+		v = rv
+	}
+
+	changed := false
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return node, false
+		}
+		return revealSecrets(node, v.Elem())
+	case reflect.Slice:
+		items, ok := node.([]interface{})
+		if !ok {
+			return node, false
+		}
+		for i := 0; i < len(items) && i < v.Len(); i++ {
+			var c bool
+			if items[i], c = revealSecrets(items[i], v.Index(i)); c {
+				changed = true
+			}
+		}
+	case reflect.Map:
+		items, ok := node.(yaml.MapSlice)
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return node, false
+		}
+		for i, item := range items {
+			key := reflect.ValueOf(fmt.Sprint(item.Key)).Convert(v.Type().Key())
+			var c bool
+			if items[i].Value, c = revealSecrets(item.Value, v.MapIndex(key)); c {
+				changed = true
+			}
+		}
+	case reflect.Struct:
+		items, ok := node.(yaml.MapSlice)
+		if !ok {
+			return node, false
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := strings.Split(f.Tag.Get("yaml"), ",")
+			if tag[0] == "-" || f.PkgPath != "" {
+				continue
+			}
+			if len(tag) > 1 && tag[1] == "inline" {
+				if _, c := revealSecrets(items, v.Field(i)); c {
+					changed = true
+				}
+				continue
+			}
+			key := tag[0]
+			if key == "" {
+				key = strings.ToLower(f.Name)
+			}
+			for j, item := range items {
+				if item.Key != key {
+					continue
+				}
+				var c bool
+				if items[j].Value, c = revealSecrets(item.Value, v.Field(i)); c {
+					changed = true
+				}
+			}
+		}
+	}
+	return node, changed
 }
 
 func (c Config) String() string {
@@ -115,7 +1219,7 @@ func (c Config) String() string {
 	if c.original != "" {
 		s = c.original
 	} else {
-		b, err := yaml.Marshal(c)
+		b, err := c.MarshalRedacted()
 		if err != nil {
 			return fmt.Sprintf("<error creating config string: %s>", err)
 		}
@@ -141,82 +1245,194 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		*c.Global = DefaultGlobalConfig
 	}
 
+	for _, ir := range c.InhibitRules {
+		if ir.TTL == nil {
+			ttl := c.Global.ResolveTimeout
+			ir.TTL = &ttl
+		}
+	}
+
 	names := map[string]struct{}{}
 
 	for _, rcv := range c.Receivers {
 		if _, ok := names[rcv.Name]; ok {
 			return fmt.Errorf("notification config name %q is not unique", rcv.Name)
 		}
+		for _, nc := range rcv.notifierConfigs() {
+			if nc.VResolveTimeout == nil {
+				resolveTimeout := c.Global.ResolveTimeout
+				nc.VResolveTimeout = &resolveTimeout
+			}
+		}
+		for _, wh := range rcv.WebhookConfigs {
+			wh.HTTPConfig = c.Global.receiverHTTPConfig(wh.HTTPConfig)
+		}
 		for _, ec := range rcv.EmailConfigs {
-			if ec.Smarthost == "" {
-				if c.Global.SMTPSmarthost == "" {
+			if ec.Smarthost.IsZero() {
+				if c.Global.SMTPSmarthost.IsZero() {
 					return fmt.Errorf("no global SMTP smarthost set")
 				}
 				ec.Smarthost = c.Global.SMTPSmarthost
 			}
+			if ec.Hello == "" {
+				ec.Hello = c.Global.SMTPHello
+			}
 			if ec.From == "" {
 				if c.Global.SMTPFrom == "" {
 					return fmt.Errorf("no global SMTP from set")
 				}
 				ec.From = c.Global.SMTPFrom
 			}
+			if ec.AuthUsername == "" {
+				ec.AuthUsername = c.Global.SMTPAuthUsername
+			}
+			if ec.AuthPassword == "" {
+				ec.AuthPassword = c.Global.SMTPAuthPassword
+			}
+			if ec.RequireTLS == nil {
+				requireTLS := c.Global.SMTPRequireTLS
+				ec.RequireTLS = &requireTLS
+			}
+			if ec.AuthUsername == "" && (ec.AuthPassword != "" || c.Global.SMTPAuthPasswordFile != "" || len(c.Global.SMTPAuthPasswordCommand) > 0) {
+				return fmt.Errorf("missing auth username for SMTP auth password in email config")
+			}
 		}
 		for _, sc := range rcv.SlackConfigs {
-			if sc.APIURL == "" {
-				if c.Global.SlackAPIURL == "" {
+			if sc.APIURL == nil {
+				if c.Global.SlackAPIURL == nil && c.Global.SlackAPIURLFile == "" && len(c.Global.SlackAPIURLCommand) == 0 {
 					return fmt.Errorf("no global Slack API URL set")
 				}
 				sc.APIURL = c.Global.SlackAPIURL
 			}
+			sc.HTTPConfig = c.Global.receiverHTTPConfig(sc.HTTPConfig)
 		}
 		for _, hc := range rcv.HipchatConfigs {
-			if hc.APIURL == "" {
-				if c.Global.HipchatURL == "" {
+			if hc.APIURL == nil {
+				if c.Global.HipchatURL == nil {
 					return fmt.Errorf("no global Hipchat API URL set")
 				}
 				hc.APIURL = c.Global.HipchatURL
 			}
-			if !strings.HasSuffix(hc.APIURL, "/") {
-				hc.APIURL += "/"
-			}
+			hc.APIURL = hc.APIURL.withTrailingSlash()
 			if hc.AuthToken == "" {
-				if c.Global.HipchatAuthToken == "" {
+				if c.Global.HipchatAuthToken == "" && c.Global.HipchatAuthTokenFile == "" && len(c.Global.HipchatAuthTokenCommand) == 0 {
 					return fmt.Errorf("no global Hipchat Auth Token set")
 				}
 				hc.AuthToken = c.Global.HipchatAuthToken
 			}
+			hc.HTTPConfig = c.Global.receiverHTTPConfig(hc.HTTPConfig)
 		}
 		for _, pdc := range rcv.PagerdutyConfigs {
-			if pdc.URL == "" {
-				if c.Global.PagerdutyURL == "" {
+			if pdc.URL == nil {
+				if c.Global.PagerdutyURL == nil {
 					return fmt.Errorf("no global PagerDuty URL set")
 				}
 				pdc.URL = c.Global.PagerdutyURL
+				// Routing keys belong to the Events API v2, which has
+				// its own endpoint.
+				if pdc.RoutingKey != "" && pdc.URL.String() == DefaultGlobalConfig.PagerdutyURL.String() {
+					pdc.URL = mustParseURL(DefaultPagerdutyV2URL)
+				}
 			}
+			pdc.HTTPConfig = c.Global.receiverHTTPConfig(pdc.HTTPConfig)
 		}
 		for _, ogc := range rcv.OpsGenieConfigs {
-			if ogc.APIHost == "" {
-				if c.Global.OpsGenieAPIHost == "" {
+			if ogc.APIHost == nil {
+				if c.Global.OpsGenieAPIHost == nil {
 					return fmt.Errorf("no global OpsGenie URL set")
 				}
 				ogc.APIHost = c.Global.OpsGenieAPIHost
 			}
-			if !strings.HasSuffix(ogc.APIHost, "/") {
-				ogc.APIHost += "/"
+			ogc.APIHost = ogc.APIHost.withTrailingSlash()
+			ogc.HTTPConfig = c.Global.receiverHTTPConfig(ogc.HTTPConfig)
+		}
+		for _, voc := range rcv.VictorOpsConfigs {
+			if voc.APIURL == nil {
+				if c.Global.VictorOpsAPIURL == nil {
+					return fmt.Errorf("no global VictorOps URL set")
+				}
+				voc.APIURL = c.Global.VictorOpsAPIURL
+			}
+			voc.APIURL = voc.APIURL.withTrailingSlash()
+			if voc.APIKey == "" {
+				if c.Global.VictorOpsAPIKey == "" {
+					return fmt.Errorf("no global VictorOps API Key set")
+				}
+				voc.APIKey = c.Global.VictorOpsAPIKey
+			}
+			voc.HTTPConfig = c.Global.receiverHTTPConfig(voc.HTTPConfig)
+		}
+		for _, wcc := range rcv.WechatConfigs {
+			if wcc.APIURL == nil {
+				if c.Global.WechatAPIURL == nil {
+					return fmt.Errorf("no global WeChat URL set")
+				}
+				wcc.APIURL = c.Global.WechatAPIURL
+			}
+			wcc.APIURL = wcc.APIURL.withTrailingSlash()
+			if wcc.APISecret == "" {
+				if c.Global.WechatAPISecret == "" {
+					return fmt.Errorf("no global WeChat API Secret set")
+				}
+				wcc.APISecret = c.Global.WechatAPISecret
+			}
+			if wcc.CorpID == "" {
+				if c.Global.WechatCorpID == "" {
+					return fmt.Errorf("no global WeChat CorpID set")
+				}
+				wcc.CorpID = c.Global.WechatCorpID
+			}
+			wcc.HTTPConfig = c.Global.receiverHTTPConfig(wcc.HTTPConfig)
+		}
+		for _, tc := range rcv.TelegramConfigs {
+			if tc.APIURL == nil {
+				if c.Global.TelegramAPIURL == nil {
+					return fmt.Errorf("no global Telegram API URL set")
+				}
+				tc.APIURL = c.Global.TelegramAPIURL
 			}
+			tc.APIURL = tc.APIURL.withTrailingSlash()
+			tc.HTTPConfig = c.Global.receiverHTTPConfig(tc.HTTPConfig)
+		}
+		for _, mc := range rcv.MSTeamsConfigs {
+			mc.HTTPConfig = c.Global.receiverHTTPConfig(mc.HTTPConfig)
+		}
+		for _, dc := range rcv.DiscordConfigs {
+			dc.HTTPConfig = c.Global.receiverHTTPConfig(dc.HTTPConfig)
+		}
+		for _, kc := range rcv.KafkaConfigs {
+			kc.HTTPConfig = c.Global.receiverHTTPConfig(kc.HTTPConfig)
+		}
+		for _, sc := range rcv.SNSConfigs {
+			sc.HTTPConfig = c.Global.receiverHTTPConfig(sc.HTTPConfig)
 		}
 		names[rcv.Name] = struct{}{}
 	}
-	return checkOverflow(c.XXX, "config")
+
+	tiNames := map[string]struct{}{}
+	for _, ti := range c.TimeIntervals {
+		if _, ok := tiNames[ti.Name]; ok {
+			return fmt.Errorf("time interval name %q is not unique", ti.Name)
+		}
+		tiNames[ti.Name] = struct{}{}
+	}
+	return checkOverflow(c.XXX, "config", c)
 }
 
+// DefaultPagerdutyV2URL is the PagerDuty URL used instead of the default
+// global one for configurations using the Events API v2.
+const DefaultPagerdutyV2URL = "https://events.pagerduty.com/v2/enqueue"
+
 // DefaultGlobalConfig provides global default values.
 var DefaultGlobalConfig = GlobalConfig{
-	ResolveTimeout: model.Duration(5 * time.Minute),
+	ResolveTimeout: Duration(5 * time.Minute),
 
-	PagerdutyURL:    "https://events.pagerduty.com/generic/2010-04-15/create_event.json",
-	HipchatURL:      "https://api.hipchat.com/",
-	OpsGenieAPIHost: "https://api.opsgenie.com/",
+	PagerdutyURL:    mustParseURL("https://events.pagerduty.com/generic/2010-04-15/create_event.json"),
+	HipchatURL:      mustParseURL("https://api.hipchat.com/"),
+	OpsGenieAPIHost: mustParseURL("https://api.opsgenie.com/"),
+	VictorOpsAPIURL: mustParseURL("https://alert.victorops.com/integrations/generic/20131114/alert/"),
+	WechatAPIURL:    mustParseURL("https://qyapi.weixin.qq.com/cgi-bin/"),
+	TelegramAPIURL:  mustParseURL("https://api.telegram.org/"),
 }
 
 // GlobalConfig defines configuration parameters that are valid globally
@@ -224,37 +1440,246 @@ var DefaultGlobalConfig = GlobalConfig{
 type GlobalConfig struct {
 	// ResolveTimeout is the time after which an alert is declared resolved
 	// if it has not been updated.
-	ResolveTimeout model.Duration `yaml:"resolve_timeout"`
+	ResolveTimeout Duration `yaml:"resolve_timeout"`
+
+	SMTPFrom         string     `yaml:"smtp_from"`
+	SMTPHello        string     `yaml:"smtp_hello"`
+	SMTPSmarthost    HostPort   `yaml:"smtp_smarthost"`
+	SMTPAuthUsername string     `yaml:"smtp_auth_username"`
+	SMTPAuthPassword Secret     `yaml:"smtp_auth_password"`
+	SMTPRequireTLS   bool       `yaml:"smtp_require_tls"`
+	SlackAPIURL      *SecretURL `yaml:"slack_api_url"`
+	PagerdutyURL     *URL       `yaml:"pagerduty_url"`
+	HipchatURL       *URL       `yaml:"hipchat_url"`
+	HipchatAuthToken Secret     `yaml:"hipchat_auth_token"`
+	OpsGenieAPIHost  *URL       `yaml:"opsgenie_api_host"`
+	VictorOpsAPIURL  *URL       `yaml:"victorops_api_url"`
+	VictorOpsAPIKey  Secret     `yaml:"victorops_api_key"`
+	WechatAPIURL     *URL       `yaml:"wechat_api_url"`
+	WechatAPISecret  Secret     `yaml:"wechat_api_secret"`
+	WechatCorpID     string     `yaml:"wechat_corp_id"`
+	TelegramAPIURL   *URL       `yaml:"telegram_api_url"`
+
+	// The default HTTP client configuration for receivers that do not
+	// define their own. It is not merged with receiver configurations.
+	HTTPConfig *HTTPClientConfig `yaml:"http_config,omitempty"`
+	// The URL of the proxy used by all receivers whose HTTP configuration
+	// does not set a proxy URL. Without it, the proxy is taken from the
+	// environment.
+	ProxyURL string `yaml:"proxy_url,omitempty"`
+
+	// Files from which the corresponding secrets are read when loading
+	// the configuration. Relative paths are resolved against the directory
+	// of the configuration file if it is loaded with LoadFile, and against
+	// the working directory otherwise.
+	SMTPAuthPasswordFile string `yaml:"smtp_auth_password_file"`
+	SlackAPIURLFile      string `yaml:"slack_api_url_file"`
+	HipchatAuthTokenFile string `yaml:"hipchat_auth_token_file"`
+	// SMTPPasswordFile is an alias of SMTPAuthPasswordFile. It is moved
+	// there when unmarshaling.
+	SMTPPasswordFile string `yaml:"smtp_password_file,omitempty"`
 
-	SMTPFrom         string `yaml:"smtp_from"`
-	SMTPSmarthost    string `yaml:"smtp_smarthost"`
-	SlackAPIURL      Secret `yaml:"slack_api_url"`
-	PagerdutyURL     string `yaml:"pagerduty_url"`
-	HipchatURL       string `yaml:"hipchat_url"`
-	HipchatAuthToken Secret `yaml:"hipchat_auth_token"`
-	OpsGenieAPIHost  string `yaml:"opsgenie_api_host"`
+	// Commands whose output is used as the corresponding secret, given as
+	// the program followed by its arguments. They are only run if
+	// LoadOptions.AllowExec is set.
+	SMTPAuthPasswordCommand []string `yaml:"smtp_auth_password_command,omitempty"`
+	SlackAPIURLCommand      []string `yaml:"slack_api_url_command,omitempty"`
+	HipchatAuthTokenCommand []string `yaml:"hipchat_auth_token_command,omitempty"`
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (c GlobalConfig) MarshalYAML() (interface{}, error) {
+	// Secrets read from files or commands must not be written inline as
+	// well, as the result could not be loaded again.
+	type plain GlobalConfig
+	p := plain(c)
+	if p.SMTPAuthPasswordFile != "" || len(p.SMTPAuthPasswordCommand) > 0 {
+		p.SMTPAuthPassword = ""
+	}
+	if p.SlackAPIURLFile != "" || len(p.SlackAPIURLCommand) > 0 {
+		p.SlackAPIURL = nil
+	}
+	if p.HipchatAuthTokenFile != "" || len(p.HipchatAuthTokenCommand) > 0 {
+		p.HipchatAuthToken = ""
+	}
+	return p, nil
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
 func (c *GlobalConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	*c = DefaultGlobalConfig
+	// URLs are unmarshaled in place, so the default ones must not be set
+	// before to leave them unmodified.
+	c.PagerdutyURL, c.HipchatURL, c.OpsGenieAPIHost = nil, nil, nil
+	c.VictorOpsAPIURL, c.WechatAPIURL, c.TelegramAPIURL = nil, nil, nil
 	type plain GlobalConfig
-	return unmarshal((*plain)(c))
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.PagerdutyURL == nil {
+		c.PagerdutyURL = DefaultGlobalConfig.PagerdutyURL
+	}
+	if c.HipchatURL == nil {
+		c.HipchatURL = DefaultGlobalConfig.HipchatURL
+	}
+	if c.OpsGenieAPIHost == nil {
+		c.OpsGenieAPIHost = DefaultGlobalConfig.OpsGenieAPIHost
+	}
+	if c.VictorOpsAPIURL == nil {
+		c.VictorOpsAPIURL = DefaultGlobalConfig.VictorOpsAPIURL
+	}
+	if c.WechatAPIURL == nil {
+		c.WechatAPIURL = DefaultGlobalConfig.WechatAPIURL
+	}
+	if c.TelegramAPIURL == nil {
+		c.TelegramAPIURL = DefaultGlobalConfig.TelegramAPIURL
+	}
+	if c.SMTPPasswordFile != "" {
+		if c.SMTPAuthPasswordFile != "" {
+			return fmt.Errorf("at most one of smtp_password_file and smtp_auth_password_file must be configured")
+		}
+		c.SMTPAuthPasswordFile, c.SMTPPasswordFile = c.SMTPPasswordFile, ""
+	}
+	if c.SMTPAuthPassword != "" && c.SMTPAuthPasswordFile != "" {
+		return fmt.Errorf("at most one of smtp_auth_password and smtp_auth_password_file must be configured")
+	}
+	if c.SlackAPIURL != nil && c.SlackAPIURLFile != "" {
+		return fmt.Errorf("at most one of slack_api_url and slack_api_url_file must be configured")
+	}
+	if c.HipchatAuthToken != "" && c.HipchatAuthTokenFile != "" {
+		return fmt.Errorf("at most one of hipchat_auth_token and hipchat_auth_token_file must be configured")
+	}
+	for _, sc := range []struct {
+		key     string
+		set     bool
+		command []string
+	}{
+		{"smtp_auth_password", c.SMTPAuthPassword != "" || c.SMTPAuthPasswordFile != "", c.SMTPAuthPasswordCommand},
+		{"slack_api_url", c.SlackAPIURL != nil || c.SlackAPIURLFile != "", c.SlackAPIURLCommand},
+		{"hipchat_auth_token", c.HipchatAuthToken != "" || c.HipchatAuthTokenFile != "", c.HipchatAuthTokenCommand},
+	} {
+		if len(sc.command) > 0 && sc.set {
+			return fmt.Errorf("%[1]s_command must not be combined with %[1]s or %[1]s_file", sc.key)
+		}
+		if len(sc.command) > 0 && sc.command[0] == "" {
+			return fmt.Errorf("empty program in %s_command", sc.key)
+		}
+	}
+	if c.ProxyURL != "" {
+		if c.HTTPConfig != nil && c.HTTPConfig.ProxyURL != "" {
+			return fmt.Errorf("at most one of proxy_url and http_config.proxy_url must be configured")
+		}
+		if err := validateProxyURL(c.ProxyURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// receiverHTTPConfig returns the HTTP client configuration for a receiver
+// with the given configuration. Receivers without a configuration get a
+// copy of the global one, and the global proxy URL applies to all that
+// do not set their own.
+func (c *GlobalConfig) receiverHTTPConfig(hc *HTTPClientConfig) *HTTPClientConfig {
+	if hc == nil {
+		hc = c.HTTPConfig.copy()
+	}
+	if c.ProxyURL == "" {
+		return hc
+	}
+	if hc == nil {
+		hc = &HTTPClientConfig{}
+	}
+	if hc.ProxyURL == "" {
+		hc.ProxyURL = c.ProxyURL
+	}
+	return hc
 }
 
 // A Route is a node that contains definitions of how to handle alerts.
 type Route struct {
-	Receiver string            `yaml:"receiver,omitempty"`
-	GroupBy  []model.LabelName `yaml:"group_by,omitempty"`
+	Receiver string `yaml:"receiver,omitempty"`
+
+	// GroupByStr holds the group_by list as written in the configuration.
+	// The special value "..." groups alerts by all of their labels, which
+	// sets GroupByAll and cannot be combined with other labels. Otherwise
+	// GroupBy holds the listed label names.
+	//
+	// A nil GroupBy means group_by is unset and inherited from the parent
+	// route. An empty, non-nil GroupBy is written as "group_by: []" and
+	// groups all alerts of the route into a single group.
+	GroupByStr []string          `yaml:"group_by,omitempty"`
+	GroupBy    []model.LabelName `yaml:"-"`
+	// GroupByAll can also be set as group_by_all, as a shortcut for
+	// "group_by: ['...']".
+	GroupByAll bool `yaml:"group_by_all,omitempty"`
 
 	Match    map[string]string `yaml:"match,omitempty"`
 	MatchRE  map[string]Regexp `yaml:"match_re,omitempty"`
 	Continue bool              `yaml:"continue,omitempty"`
 	Routes   []*Route          `yaml:"routes,omitempty"`
 
-	GroupWait      *model.Duration `yaml:"group_wait,omitempty"`
-	GroupInterval  *model.Duration `yaml:"group_interval,omitempty"`
-	RepeatInterval *model.Duration `yaml:"repeat_interval,omitempty"`
+	// MatchNot and MatchNotRE exclude alerts whose labels match any of
+	// the given values or regular expressions from the route.
+	MatchNot   map[string]string `yaml:"match_not,omitempty"`
+	MatchNotRE map[string]Regexp `yaml:"match_not_re,omitempty"`
+
+	// MatchIn requires the labels to have one of the given values,
+	// MatchNotIn excludes alerts whose labels have one of them.
+	MatchIn    map[string][]string `yaml:"match_in,omitempty"`
+	MatchNotIn map[string][]string `yaml:"match_not_in,omitempty"`
+	// MatchCIDR requires the labels to hold an IP address within one of
+	// the given networks.
+	MatchCIDR map[string][]CIDR `yaml:"match_cidr,omitempty"`
+
+	// AnnotationMatch and AnnotationMatchRE match the annotations of
+	// alerts rather than their labels. Unlike label names, annotation
+	// names may be any non-empty string.
+	AnnotationMatch   map[string]string `yaml:"annotation_match,omitempty"`
+	AnnotationMatchRE map[string]Regexp `yaml:"annotation_match_re,omitempty"`
+
+	// Matchers is a list of matcher expressions, which are combined
+	// with the other matchers of the route.
+	Matchers []*Matcher `yaml:"matchers,omitempty"`
+
+	GroupWait      *Duration `yaml:"group_wait,omitempty"`
+	GroupInterval  *Duration `yaml:"group_interval,omitempty"`
+	RepeatInterval *Duration `yaml:"repeat_interval,omitempty"`
+
+	// InitialDelay holds back the first notification of a new group. It
+	// is added to group_wait rather than replacing it, so that the first
+	// notification is sent group_wait plus initial_delay after the first
+	// alert of the group arrived. Later notifications are only timed by
+	// group_interval and repeat_interval. It is inherited like the other
+	// timers and must be positive if set.
+	InitialDelay *Duration `yaml:"initial_delay,omitempty"`
+
+	// GroupLimit is the maximum number of alerts included in a
+	// notification for a group. The remaining ones are only counted and
+	// the default templates mention how many were omitted. 0 means
+	// unlimited.
+	GroupLimit *int `yaml:"group_limit,omitempty"`
+
+	// MinGroupSize is the minimum number of firing alerts a group must
+	// have before it is notified about. It is applied when groups are
+	// evaluated for notification, not when alerts are matched, so
+	// matching alerts are still grouped by the route. 0 means no minimum.
+	MinGroupSize *int `yaml:"min_group_size,omitempty"`
+
+	// MuteTimeIntervals lists the names of time intervals during which
+	// notifications for the route are muted.
+	MuteTimeIntervals []string `yaml:"mute_time_intervals,omitempty"`
+	// ActiveTimeIntervals lists the names of time intervals outside of
+	// which the route does not match, so that alerts fall through to the
+	// following routes. Mute intervals take precedence: during a time
+	// that is in both an active and a mute interval the route matches,
+	// but its notifications are muted.
+	ActiveTimeIntervals []string `yaml:"active_time_intervals,omitempty"`
+
+	// clearsReceiver is set if the receiver is explicitly configured as
+	// empty, rather than unset. The routes below then do not inherit the
+	// receiver of the parents and every leaf among them must set one.
+	clearsReceiver bool
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline"`
@@ -266,6 +1691,13 @@ func (r *Route) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if err := unmarshal((*plain)(r)); err != nil {
 		return err
 	}
+	var explicit struct {
+		Receiver *string `yaml:"receiver"`
+	}
+	if err := unmarshal(&explicit); err != nil {
+		return err
+	}
+	r.clearsReceiver = explicit.Receiver != nil && *explicit.Receiver == ""
 
 	for k := range r.Match {
 		if !model.LabelNameRE.MatchString(k) {
@@ -279,16 +1711,146 @@ func (r *Route) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		}
 	}
 
+	for k := range r.MatchNot {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+
+	for k := range r.MatchNotRE {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+
+	if err := checkExclusiveMatchers("label", r.Match, r.MatchRE, "match", "match_re"); err != nil {
+		return err
+	}
+
+	for k := range r.AnnotationMatch {
+		if k == "" || !utf8.ValidString(k) {
+			return fmt.Errorf("invalid annotation name %q", k)
+		}
+	}
+	for k := range r.AnnotationMatchRE {
+		if k == "" || !utf8.ValidString(k) {
+			return fmt.Errorf("invalid annotation name %q", k)
+		}
+	}
+	if err := checkExclusiveMatchers("annotation", r.AnnotationMatch, r.AnnotationMatchRE, "annotation_match", "annotation_match_re"); err != nil {
+		return err
+	}
+
+	for k, vs := range r.MatchIn {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+		if len(vs) == 0 {
+			return fmt.Errorf("match_in for label %q must list at least one value", k)
+		}
+	}
+
+	for k, vs := range r.MatchNotIn {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+		if len(vs) == 0 {
+			return fmt.Errorf("match_not_in for label %q must list at least one value", k)
+		}
+	}
+
+	for k, nets := range r.MatchCIDR {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+		if len(nets) == 0 {
+			return fmt.Errorf("match_cidr for label %q must list at least one network", k)
+		}
+	}
+
+	if r.GroupByAll && r.GroupByStr != nil {
+		return fmt.Errorf("cannot combine group_by_all with group_by")
+	}
+	r.GroupBy = nil
+	if r.GroupByStr != nil {
+		r.GroupBy = []model.LabelName{}
+	}
 	groupBy := map[model.LabelName]struct{}{}
 
-	for _, ln := range r.GroupBy {
+	for _, l := range r.GroupByStr {
+		if l == groupByAllToken {
+			r.GroupByAll = true
+			continue
+		}
+		ln := model.LabelName(l)
+		if !ln.IsValid() {
+			return fmt.Errorf("%q is not a valid label name", l)
+		}
+		if strings.HasPrefix(l, model.ReservedLabelPrefix) {
+			return fmt.Errorf("cannot group by reserved label %q, labels starting with %q are internal", l, model.ReservedLabelPrefix)
+		}
 		if _, ok := groupBy[ln]; ok {
 			return fmt.Errorf("duplicated label %q in group_by", ln)
 		}
 		groupBy[ln] = struct{}{}
+		r.GroupBy = append(r.GroupBy, ln)
+	}
+
+	if r.GroupByAll {
+		if len(r.GroupByStr) > 1 {
+			return fmt.Errorf("cannot combine %q with other labels in group_by", groupByAllToken)
+		}
+		r.GroupBy = nil
+	}
+
+	if r.InitialDelay != nil && *r.InitialDelay <= 0 {
+		return fmt.Errorf("initial_delay must be positive")
+	}
+	if r.GroupLimit != nil && *r.GroupLimit < 0 {
+		return fmt.Errorf("group_limit must not be negative")
+	}
+	if r.MinGroupSize != nil && *r.MinGroupSize < 0 {
+		return fmt.Errorf("min_group_size must not be negative")
+	}
+
+	return checkOverflow(r.XXX, "route", r)
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (r Route) MarshalYAML() (interface{}, error) {
+	// GroupBy and GroupByAll take precedence over GroupByStr so that
+	// routes built in code are written as they behave.
+	type plain Route
+	p := plain(r)
+	p.GroupByStr = nil
+	if !r.GroupByAll {
+		for _, ln := range r.GroupBy {
+			p.GroupByStr = append(p.GroupByStr, string(ln))
+		}
+	}
+	emptyGroupBy := !r.GroupByAll && r.GroupBy != nil && len(r.GroupBy) == 0
+	clearsReceiver := r.clearsReceiver && r.Receiver == ""
+	if !emptyGroupBy && !clearsReceiver {
+		return p, nil
 	}
 
-	return checkOverflow(r.XXX, "route")
+	// An explicitly empty group_by or receiver would be dropped as empty,
+	// so it is added to the marshaled route.
+	b, err := yaml.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var m yaml.MapSlice
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	if clearsReceiver {
+		m = append(yaml.MapSlice{{Key: "receiver", Value: ""}}, m...)
+	}
+	if emptyGroupBy {
+		setMapValue(&m, "group_by", []string{})
+	}
+	return m, nil
 }
 
 // InhibitRule defines an inhibition rule that mutes alerts that match the
@@ -307,9 +1869,20 @@ type InhibitRule struct {
 	// TargetMatchRE defines pairs like TargetMatch but does regular expression
 	// matching.
 	TargetMatchRE map[string]Regexp `yaml:"target_match_re"`
+	// SourceMatchNot and SourceMatchNotRE exclude source alerts whose
+	// labels match any of the given values or regular expressions.
+	SourceMatchNot   map[string]string `yaml:"source_match_not,omitempty"`
+	SourceMatchNotRE map[string]Regexp `yaml:"source_match_not_re,omitempty"`
+	// TargetMatchNot and TargetMatchNotRE exclude target alerts whose
+	// labels match any of the given values or regular expressions.
+	TargetMatchNot   map[string]string `yaml:"target_match_not,omitempty"`
+	TargetMatchNotRE map[string]Regexp `yaml:"target_match_not_re,omitempty"`
 	// A set of labels that must be equal between the source and target alert
 	// for them to be a match.
 	Equal model.LabelNames `yaml:"equal"`
+	// TTL is the time after its last update for which a source alert
+	// inhibits target alerts. It defaults to the global resolve timeout.
+	TTL *Duration `yaml:"ttl,omitempty"`
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline"`
@@ -346,7 +1919,67 @@ func (r *InhibitRule) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		}
 	}
 
-	return checkOverflow(r.XXX, "inhibit rule")
+	for _, m := range []map[string]string{r.SourceMatchNot, r.TargetMatchNot} {
+		for k := range m {
+			if !model.LabelNameRE.MatchString(k) {
+				return fmt.Errorf("invalid label name %q", k)
+			}
+		}
+	}
+
+	for _, m := range []map[string]Regexp{r.SourceMatchNotRE, r.TargetMatchNotRE} {
+		for k := range m {
+			if !model.LabelNameRE.MatchString(k) {
+				return fmt.Errorf("invalid label name %q", k)
+			}
+		}
+	}
+
+	if err := checkExclusiveMatchers("label", r.SourceMatch, r.SourceMatchRE, "source_match", "source_match_re"); err != nil {
+		return err
+	}
+	if err := checkExclusiveMatchers("label", r.TargetMatch, r.TargetMatchRE, "target_match", "target_match_re"); err != nil {
+		return err
+	}
+
+	if len(r.SourceMatch)+len(r.SourceMatchRE)+len(r.SourceMatchNot)+len(r.SourceMatchNotRE) == 0 ||
+		len(r.TargetMatch)+len(r.TargetMatchRE)+len(r.TargetMatchNot)+len(r.TargetMatchNotRE) == 0 {
+		return fmt.Errorf("inhibit rule must have source and target matchers")
+	}
+
+	equal := map[model.LabelName]struct{}{}
+
+	// Label names in equal are already validated while unmarshaling them.
+	for _, ln := range r.Equal {
+		if _, ok := equal[ln]; ok {
+			return fmt.Errorf("duplicated label %q in equal", ln)
+		}
+		equal[ln] = struct{}{}
+	}
+
+	if r.TTL != nil && *r.TTL <= 0 {
+		return fmt.Errorf("invalid ttl in inhibit rule: must be positive")
+	}
+
+	return checkOverflow(r.XXX, "inhibit rule", r)
+}
+
+// checkExclusiveMatchers returns an error naming the first label or
+// annotation, as given by kind, in sorted order, that has both an
+// equality and a regular expression matcher. Such matchers are almost
+// always a copy-paste mistake.
+func checkExclusiveMatchers(kind string, match map[string]string, matchRE map[string]Regexp, key, reKey string) error {
+	var both []string
+	for k := range match {
+		if _, ok := matchRE[k]; ok {
+			both = append(both, k)
+		}
+	}
+	if len(both) == 0 {
+		return nil
+	}
+	sort.Strings(both)
+	return fmt.Errorf("%s %q must not be in both %s and %s", kind, both[0], key, reKey)
 }
 
 // Receiver configuration provides configuration on how to contact a receiver.
@@ -360,11 +1993,100 @@ type Receiver struct {
 	SlackConfigs     []*SlackConfig     `yaml:"slack_configs,omitempty"`
 	WebhookConfigs   []*WebhookConfig   `yaml:"webhook_configs,omitempty"`
 	OpsGenieConfigs  []*OpsGenieConfig  `yaml:"opsgenie_configs,omitempty"`
+	VictorOpsConfigs []*VictorOpsConfig `yaml:"victorops_configs,omitempty"`
+	WechatConfigs    []*WechatConfig    `yaml:"wechat_configs,omitempty"`
+	TelegramConfigs  []*TelegramConfig  `yaml:"telegram_configs,omitempty"`
+	MSTeamsConfigs   []*MSTeamsConfig   `yaml:"msteams_configs,omitempty"`
+	SNSConfigs       []*SNSConfig       `yaml:"sns_configs,omitempty"`
+	DiscordConfigs   []*DiscordConfig   `yaml:"discord_configs,omitempty"`
+	KafkaConfigs     []*KafkaConfig     `yaml:"kafka_configs,omitempty"`
+
+	// Templates are the files notification templates are read from for
+	// this receiver only, in addition to the global ones.
+	Templates []string `yaml:"templates,omitempty"`
+
+	// Blackhole marks a receiver without integrations as intentional.
+	// Notifications sent to it are dropped. Other receivers must
+	// configure at least one integration.
+	Blackhole bool `yaml:"blackhole,omitempty"`
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline"`
 }
 
+// ReceiverTemplates returns the template files used by the receiver with
+// the given name, which are the global ones followed by its own.
+func (c *Config) ReceiverTemplates(name string) []string {
+	templates := append([]string{}, c.Templates...)
+	for _, rcv := range c.Receivers {
+		if rcv.Name == name {
+			templates = append(templates, rcv.Templates...)
+		}
+	}
+	return templates
+}
+
+func (c Receiver) String() string {
+	b, err := marshalYAML(&c, false)
+	if err != nil {
+		return fmt.Sprintf("<error creating receiver string: %s>", err)
+	}
+	return string(b)
+}
+
+// notifierConfigs returns the options common to all notifier
+// configurations of the receiver.
+func (c *Receiver) notifierConfigs() []*NotifierConfig {
+	var ncs []*NotifierConfig
+	for _, ec := range c.EmailConfigs {
+		ncs = append(ncs, &ec.NotifierConfig)
+	}
+	for _, pdc := range c.PagerdutyConfigs {
+		ncs = append(ncs, &pdc.NotifierConfig)
+	}
+	for _, hc := range c.HipchatConfigs {
+		ncs = append(ncs, &hc.NotifierConfig)
+	}
+	for _, sc := range c.SlackConfigs {
+		ncs = append(ncs, &sc.NotifierConfig)
+	}
+	for _, wh := range c.WebhookConfigs {
+		ncs = append(ncs, &wh.NotifierConfig)
+	}
+	for _, ogc := range c.OpsGenieConfigs {
+		ncs = append(ncs, &ogc.NotifierConfig)
+	}
+	for _, voc := range c.VictorOpsConfigs {
+		ncs = append(ncs, &voc.NotifierConfig)
+	}
+	for _, wcc := range c.WechatConfigs {
+		ncs = append(ncs, &wcc.NotifierConfig)
+	}
+	for _, tc := range c.TelegramConfigs {
+		ncs = append(ncs, &tc.NotifierConfig)
+	}
+	for _, mtc := range c.MSTeamsConfigs {
+		ncs = append(ncs, &mtc.NotifierConfig)
+	}
+	for _, snc := range c.SNSConfigs {
+		ncs = append(ncs, &snc.NotifierConfig)
+	}
+	for _, dc := range c.DiscordConfigs {
+		ncs = append(ncs, &dc.NotifierConfig)
+	}
+	for _, kc := range c.KafkaConfigs {
+		ncs = append(ncs, &kc.NotifierConfig)
+	}
+	return ncs
+}
+
+// IsEmpty returns true if the receiver has no integrations configured, so
+// that notifications sent to it are dropped. Only blackhole receivers are
+// empty in loaded configurations.
+func (c *Receiver) IsEmpty() bool {
+	return len(c.notifierConfigs()) == 0
+}
+
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
 func (c *Receiver) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	type plain Receiver
@@ -374,32 +2096,19 @@ func (c *Receiver) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if c.Name == "" {
 		return fmt.Errorf("missing name in receiver")
 	}
-	return checkOverflow(c.XXX, "receiver config")
-}
-
-// Regexp encapsulates a regexp.Regexp and makes it YAML marshalable.
-type Regexp struct {
-	*regexp.Regexp
-}
-
-// UnmarshalYAML implements the yaml.Unmarshaler interface.
-func (re *Regexp) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	var s string
-	if err := unmarshal(&s); err != nil {
-		return err
+	for _, nc := range c.notifierConfigs() {
+		if nc.VResolveTimeout != nil && *nc.VResolveTimeout <= 0 {
+			return fmt.Errorf("invalid resolve_timeout in receiver %q: must be positive", c.Name)
+		}
+		if nc.MaxAlerts < 0 {
+			return fmt.Errorf("invalid max_alerts in receiver %q: must not be negative", c.Name)
+		}
 	}
-	regex, err := regexp.Compile("^(?:" + s + ")$")
-	if err != nil {
+	if err := c.parseInlineTemplates(); err != nil {
 		return err
 	}
-	re.Regexp = regex
-	return nil
-}
-
-// MarshalYAML implements the yaml.Marshaler interface.
-func (re *Regexp) MarshalYAML() (interface{}, error) {
-	if re != nil {
-		return re.String(), nil
+	if err := c.checkTemplateStrings(); err != nil {
+		return err
 	}
-	return nil, nil
+	return checkOverflow(c.XXX, "receiver config", c)
 }
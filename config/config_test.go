@@ -0,0 +1,1958 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+)
+
+func TestLoadWithEnv(t *testing.T) {
+	in := `
+global:
+  slack_api_url: ${AM_TEST_SLACK_URL}
+
+route:
+  receiver: team-$AM_TEST_TEAM
+
+receivers:
+- name: team-$AM_TEST_TEAM
+  slack_configs:
+  - channel: '#alerts'
+`
+	os.Setenv("AM_TEST_SLACK_URL", "https://hooks.slack.com/services/secret")
+	os.Setenv("AM_TEST_TEAM", "a")
+	defer os.Unsetenv("AM_TEST_SLACK_URL")
+	defer os.Unsetenv("AM_TEST_TEAM")
+
+	cfg, err := LoadWithEnv(in)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	if cfg.Route.Receiver != "team-a" {
+		t.Errorf("Expected receiver %q, got %q", "team-a", cfg.Route.Receiver)
+	}
+	if got := cfg.Receivers[0].SlackConfigs[0].APIURL.URL.String(); got != "https://hooks.slack.com/services/secret" {
+		t.Errorf("Expected expanded Slack API URL, got %q", got)
+	}
+	if strings.Contains(cfg.String(), "secret") {
+		t.Errorf("Config string reveals expanded secret:\n%s", cfg)
+	}
+}
+
+func TestLoadWithEnvUnset(t *testing.T) {
+	in := `
+route:
+  receiver: $AM_TEST_UNSET
+
+receivers:
+- name: default
+  blackhole: true
+`
+	os.Unsetenv("AM_TEST_UNSET")
+
+	_, err := LoadWithEnv(in)
+	if err == nil {
+		t.Fatal("Expected error for unset environment variable")
+	}
+	if !strings.Contains(err.Error(), `"AM_TEST_UNSET"`) {
+		t.Errorf("Expected error to name the missing variable, got %q", err)
+	}
+}
+
+func TestLoadWithEnvKeysUntouched(t *testing.T) {
+	in := `
+route:
+  receiver: default
+  match:
+    $AM_TEST_KEY: foo
+
+receivers:
+- name: default
+  blackhole: true
+`
+	os.Setenv("AM_TEST_KEY", "job")
+	defer os.Unsetenv("AM_TEST_KEY")
+
+	// Keys are not expanded, so the invalid label name must be rejected.
+	if _, err := LoadWithEnv(in); err == nil {
+		t.Fatal("Expected error for unexpanded label name key")
+	}
+}
+
+func TestLoadWithEnvTemplates(t *testing.T) {
+	in := `
+route:
+  receiver: default
+
+receivers:
+- name: default
+  slack_configs:
+  - api_url: https://hooks.slack.com/services/$AM_TEST_TOKEN
+    channel: '#alerts'
+    title: 'Costs: $$5, ${AM_TEST_TEAM}'
+    text: '{{ range $i, $a := .Alerts }}{{ $i }}: {{ $a.Labels.alertname }}{{ end }}'
+    pretext: '$ is not a reference, neither is $1'
+`
+	os.Setenv("AM_TEST_TOKEN", "token")
+	os.Setenv("AM_TEST_TEAM", "a")
+	defer os.Unsetenv("AM_TEST_TOKEN")
+	defer os.Unsetenv("AM_TEST_TEAM")
+
+	cfg, err := LoadWithEnv(in)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	sc := cfg.Receivers[0].SlackConfigs[0]
+	for _, c := range []struct{ got, want string }{
+		{sc.APIURL.URL.String(), "https://hooks.slack.com/services/token"},
+		{sc.Title, "Costs: $5, a"},
+		{sc.Text, "{{ range $i, $a := .Alerts }}{{ $i }}: {{ $a.Labels.alertname }}{{ end }}"},
+		{sc.Pretext, "$ is not a reference, neither is $1"},
+	} {
+		if c.got != c.want {
+			t.Errorf("Expected %q, got %q", c.want, c.got)
+		}
+	}
+}
+
+func TestLoadWithVars(t *testing.T) {
+	in := `
+global:
+  smtp_from: 'alerts-{{ .Region }}@example.com'
+  smtp_smarthost: 'smtp.{{ .Region }}.example.com:25'
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+  email_configs:
+  - to: team@example.com
+    headers:
+      subject: '{{ "{{ .CommonLabels.alertname }}" }}'
+`
+	cfg, err := LoadWithVars(in, map[string]string{"Region": "eu"})
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	ec := cfg.Receivers[0].EmailConfigs[0]
+	if ec.From != "alerts-eu@example.com" {
+		t.Errorf("Expected expanded from address, got %q", ec.From)
+	}
+	if got := cfg.Global.SMTPSmarthost.String(); got != "smtp.eu.example.com:25" {
+		t.Errorf("Expected expanded smarthost, got %q", got)
+	}
+	if got := ec.Headers["Subject"]; got != "{{ .CommonLabels.alertname }}" {
+		t.Errorf("Expected escaped notification template, got %q", got)
+	}
+
+	_, err = LoadWithVars(in, map[string]string{"Zone": "a"})
+	if err == nil || !strings.Contains(err.Error(), `"Region"`) {
+		t.Errorf("Expected error naming the missing variable, got %v", err)
+	}
+
+	// Without variables the input is not executed as a template.
+	cfg, err = LoadWithVars(in, nil)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	if got := cfg.Global.SMTPFrom; got != "alerts-{{ .Region }}@example.com" {
+		t.Errorf("Expected unexpanded from address, got %q", got)
+	}
+}
+
+func TestLoadFileSecretFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "am_config_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "slack_url"), []byte("https://hooks.slack.com/services/secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	conf := `
+global:
+  slack_api_url_file: slack_url
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+  slack_configs:
+  - channel: '#alerts'
+`
+	filename := filepath.Join(dir, "config.yml")
+	if err := ioutil.WriteFile(filename, []byte(conf), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFile(filename)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	if got, want := cfg.Global.SlackAPIURLFile, filepath.Join(dir, "slack_url"); got != want {
+		t.Errorf("Expected resolved file path %q, got %q", want, got)
+	}
+	if got := cfg.Receivers[0].SlackConfigs[0].APIURL.URL.String(); got != "https://hooks.slack.com/services/secret" {
+		t.Errorf("Expected Slack API URL from file, got %q", got)
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "am_config_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	passwordFile := filepath.Join(dir, "smtp_password")
+	if err := ioutil.WriteFile(passwordFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"smtp_password_file", "smtp_auth_password_file"} {
+		in := fmt.Sprintf(`
+global:
+  smtp_smarthost: localhost:25
+  smtp_from: alertmanager@example.com
+  smtp_auth_username: alertmanager
+  %s: %s
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+  email_configs:
+  - to: ops@example.com
+`, key, passwordFile)
+		cfg, err := Load(in)
+		if err != nil {
+			t.Fatalf("%s: error loading config: %s", key, err)
+		}
+		if got := cfg.Global.SMTPAuthPasswordFile; got != passwordFile {
+			t.Errorf("%s: expected password file %q, got %q", key, passwordFile, got)
+		}
+		if got := string(cfg.Receivers[0].EmailConfigs[0].AuthPassword); got != "secret" {
+			t.Errorf("%s: expected password from file, got %q", key, got)
+		}
+	}
+
+	expectLoadError(t, fmt.Sprintf(`
+global:
+  smtp_password_file: %[1]s
+  smtp_auth_password_file: %[1]s
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+`, passwordFile), "at most one of smtp_password_file and smtp_auth_password_file")
+
+	expectLoadError(t, `
+global:
+  hipchat_auth_token_file: /nonexistent/hipchat_token
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+`, "reading secret file")
+}
+
+func TestLoadSecretCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "am_config_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The fake command prints its first argument, or its second one to
+	// stderr and fails if there is one.
+	command := filepath.Join(dir, "get-secret")
+	script := `#!/bin/sh
+if [ -n "$2" ]; then
+  echo "$2" >&2
+  exit 3
+fi
+printf '%s \n\n' "$1"
+`
+	if err := ioutil.WriteFile(command, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	conf := func(args string) string {
+		return fmt.Sprintf(`
+global:
+  slack_api_url_command: [%s, %s]
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+  slack_configs:
+  - channel: '#alerts'
+`, command, args)
+	}
+
+	cfg, err := LoadWithOptions(conf("'https://hooks.slack.com/services/secret'"), LoadOptions{AllowExec: true})
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	if got := cfg.Receivers[0].SlackConfigs[0].APIURL.URL.String(); got != "https://hooks.slack.com/services/secret" {
+		t.Errorf("Expected Slack API URL from command, got %q", got)
+	}
+	// The secret from the command is not written inline when marshaling.
+	out, err := cfg.MarshalWithSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "slack_api_url: https") {
+		t.Errorf("Expected secret from command to be omitted, got:\n%s", out)
+	}
+
+	cases := []struct {
+		in   string
+		opts LoadOptions
+		err  string
+	}{
+		{
+			in:  conf("'https://hooks.slack.com/services/secret'"),
+			err: "slack_api_url_command is configured but running commands is not allowed",
+		},
+		{
+			in:   conf("'https://hooks.slack.com/services/secret', 'vault is sealed'"),
+			opts: LoadOptions{AllowExec: true},
+			err:  "running slack_api_url_command: exit status 3: vault is sealed",
+		},
+		{
+			in:   conf("'hooks.slack.com'"),
+			opts: LoadOptions{AllowExec: true},
+			err:  "invalid secret URL in output of slack_api_url_command",
+		},
+		{
+			in: `
+global:
+  slack_api_url_command: [sleep, '5']
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+`,
+			opts: LoadOptions{AllowExec: true, ExecTimeout: 100 * time.Millisecond},
+			err:  "slack_api_url_command did not finish within 100ms",
+		},
+		{
+			in: `
+global:
+  slack_api_url: https://hooks.slack.com/services/secret
+  slack_api_url_command: [get-secret]
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+`,
+			opts: LoadOptions{AllowExec: true},
+			err:  "slack_api_url_command must not be combined with slack_api_url or slack_api_url_file",
+		},
+	}
+	for _, c := range cases {
+		_, err := LoadWithOptions(c.in, c.opts)
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("Expected error containing %q, got %v", c.err, err)
+		}
+	}
+}
+
+func TestSecret(t *testing.T) {
+	s := Secret("mysecret")
+	for _, format := range []string{"%v", "%s", "%+v", "%#v", "%q"} {
+		if out := fmt.Sprintf(format, s); strings.Contains(out, "mysecret") {
+			t.Errorf("Formatting with %s reveals secret: %s", format, out)
+		}
+	}
+	// Secrets nested in structs are hidden as well.
+	hc := HTTPClientConfig{BearerToken: s}
+	for _, format := range []string{"%v", "%+v"} {
+		if out := fmt.Sprintf(format, hc); strings.Contains(out, "mysecret") {
+			t.Errorf("Formatting with %s reveals nested secret: %s", format, out)
+		}
+	}
+	if string(s) != "mysecret" {
+		t.Errorf("Expected secret value to be available by conversion, got %q", string(s))
+	}
+
+	if !s.Equal(Secret("mysecret")) {
+		t.Errorf("Expected equal secrets to be equal")
+	}
+	for _, other := range []Secret{"othersecret", "mysecre", ""} {
+		if s.Equal(other) {
+			t.Errorf("Expected secrets to differ from %q", string(other))
+		}
+	}
+}
+
+func TestSecretFileConflict(t *testing.T) {
+	in := `
+global:
+  slack_api_url: https://hooks.slack.com/services/secret
+  slack_api_url_file: /etc/alertmanager/slack_url
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+`
+	if _, err := Load(in); err == nil {
+		t.Fatal("Expected error when setting both inline secret and secret file")
+	}
+}
+
+func TestLoadExample(t *testing.T) {
+	// The template pattern of the example matches no files, as in the
+	// Docker image with its empty template directory.
+	if _, err := LoadFile("../doc/examples/simple.yml"); err != nil {
+		t.Fatalf("Error loading example config: %s", err)
+	}
+}
+
+func TestLoadFileTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "am_config_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.Mkdir(filepath.Join(dir, "templates"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "templates", "default.tmpl"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		templates string
+		allow     bool
+		err       string
+	}{
+		{
+			templates: `[templates/*.tmpl, templates/default.tmpl]`,
+		},
+		{
+			// Patterns may match no files, like the templates directory
+			// of the Docker image.
+			templates: `[templates/*.tpl]`,
+		},
+		{
+			templates: `[templates/missing.tmpl]`,
+			err:       "template file " + filepath.Join(dir, "templates", "missing.tmpl") + " does not exist",
+		},
+		{
+			templates: `['templates/[.tmpl']`,
+			err:       "invalid template pattern " + filepath.Join(dir, "templates", "[.tmpl"),
+		},
+		{
+			templates: `[templates/*.tpl, templates/missing.tmpl]`,
+			allow:     true,
+		},
+	}
+
+	for _, c := range cases {
+		conf := `
+route:
+  receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+
+templates: ` + c.templates + "\n"
+		if c.allow {
+			conf += "allow_missing_templates: true\n"
+		}
+		filename := filepath.Join(dir, "config.yml")
+		if err := ioutil.WriteFile(filename, []byte(conf), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := LoadFile(filename)
+		if c.err == "" {
+			if err != nil {
+				t.Errorf("Unexpected error loading templates %s: %s", c.templates, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("Expected error containing %q for templates %s, got %v", c.err, c.templates, err)
+		}
+	}
+}
+
+func TestLoadFileReceiverTemplates(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"templates/default.tmpl": "",
+		"templates/team-a.tmpl":  "",
+		"config.yml": `
+route:
+  receiver: default
+  routes:
+  - receiver: team-a
+    match:
+      team: a
+
+receivers:
+- name: default
+  blackhole: true
+- name: team-a
+  blackhole: true
+  templates: [templates/team-a.tmpl, /etc/alertmanager/*.tmpl]
+
+templates: [templates/default.tmpl]
+allow_missing_templates: true
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := LoadFile(filepath.Join(dir, "config.yml"))
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	want := []string{filepath.Join(dir, "templates", "team-a.tmpl"), "/etc/alertmanager/*.tmpl"}
+	if got := cfg.Receivers[1].Templates; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected receiver templates %v, got %v", want, got)
+	}
+	want = append([]string{filepath.Join(dir, "templates", "default.tmpl")}, want...)
+	if got := cfg.ReceiverTemplates("team-a"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected effective templates %v, got %v", want, got)
+	}
+	if got := cfg.ReceiverTemplates("default"); !reflect.DeepEqual(got, want[:1]) {
+		t.Errorf("Expected effective templates %v, got %v", want[:1], got)
+	}
+	if len(cfg.Templates) != 1 {
+		t.Errorf("Expected receiver templates not to change the global ones, got %v", cfg.Templates)
+	}
+
+	// Receiver templates are checked like the global ones.
+	missing := writeFiles(t, map[string]string{
+		"config.yml": `
+route:
+  receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+  templates: [missing.tmpl]
+`,
+	})
+	defer os.RemoveAll(missing)
+	_, err = LoadFile(filepath.Join(missing, "config.yml"))
+	if want := "template file " + filepath.Join(missing, "missing.tmpl") + " does not exist"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected error containing %q, got %v", want, err)
+	}
+}
+
+func TestLoadFileTemplateFunctions(t *testing.T) {
+	conf := `
+templates: [templates/*.tmpl]
+route:
+  receiver: default
+receivers:
+- name: default
+  blackhole: true
+`
+	dir := writeFiles(t, map[string]string{
+		"config.yml":         conf,
+		"templates/ok.tmpl":  `{{ define "team.title" }}{{ toUpper .CommonLabels.team }}{{ template "__subject" . }}{{ end }}`,
+		"templates/bad.tmpl": "{{ define \"team.text\" }}\n{{ .CommonLabels.team | upper }}\n{{ end }}",
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := LoadFile(filepath.Join(dir, "config.yml"))
+	if want := "invalid template file " + filepath.Join(dir, "templates", "bad.tmpl") + `: bad.tmpl:2: function "upper" not defined`; err == nil || err.Error() != want {
+		t.Errorf("Expected error %q, got %v", want, err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "templates", "bad.tmpl")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(filepath.Join(dir, "config.yml")); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+}
+
+func TestTemplateStrings(t *testing.T) {
+	loadReceiver(t, "", `
+  slack_configs:
+  - api_url: https://hooks.slack.com/services/token
+    channel: '#{{ .CommonLabels.team | toLower }}'
+    title: '{{ template "slack.default.title" . }}'
+`)
+
+	expectLoadError(t, configWithReceiver("", `
+  slack_configs:
+  - api_url: https://hooks.slack.com/services/token
+    channel: '#ops'
+    title: '{{ .CommonLabels.team | upper }}'
+`), `invalid template in slack_configs[0].title of receiver "default": template: :1: function "upper" not defined`)
+	expectLoadError(t, configWithReceiver(`
+  smtp_smarthost: localhost:25
+  smtp_from: alertmanager@example.org
+`, `
+  email_configs:
+  - to: ops@example.org
+    headers:
+      Subject: '{{ .CommonLabels.team | upper }}'
+`), `invalid template in email_configs[0].headers of receiver "default"`)
+}
+
+func TestValidateReceivers(t *testing.T) {
+	cases := []struct {
+		in  string
+		err string
+	}{
+		{
+			in: `
+route:
+  group_by: [alertname]
+
+receivers:
+- name: default
+  blackhole: true
+`,
+			err: "root route must specify a default receiver",
+		},
+		{
+			in: `
+route:
+  receiver: default
+  routes:
+  - receiver: default
+  - receiver: default
+    routes:
+    - match:
+        severity: critical
+      receiver: team-X
+
+receivers:
+- name: default
+  blackhole: true
+`,
+			err: `route.routes[1].routes[0]: undefined receiver "team-X"`,
+		},
+		{
+			in: `
+route:
+  receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+- name: team-X
+`,
+			err: `receivers[1]: receiver "team-X" has no notification integrations configured`,
+		},
+		{
+			in: `
+route:
+  receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+  webhook_configs:
+  - url: http://example.com/
+`,
+			err: `receivers[0]: blackhole receiver "default" must not have notification integrations configured`,
+		},
+	}
+
+	for _, c := range cases {
+		_, err := Load(c.in)
+		if err == nil {
+			t.Errorf("Expected error %q, got none", c.err)
+			continue
+		}
+		if err.Error() != c.err {
+			t.Errorf("Expected error %q, got %q", c.err, err)
+		}
+	}
+}
+
+func TestMergeDuplicateReceivers(t *testing.T) {
+	in := `
+global:
+  smtp_smarthost: localhost:25
+  smtp_from: alertmanager@example.org
+route:
+  receiver: team-a
+  routes:
+  - receiver: team-b
+    match:
+      team: b
+receivers:
+- name: team-a
+  webhook_configs:
+  - url: http://example.com/a
+- name: team-b
+  webhook_configs:
+  - url: http://example.com/b
+- name: team-a
+  email_configs:
+  - to: team-a@example.org
+  webhook_configs:
+  - url: http://example.com/a2
+`
+	expectLoadError(t, in, `notification config name "team-a" is not unique`)
+
+	cfg, err := LoadWithOptions(in, LoadOptions{MergeDuplicateReceivers: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if names := cfg.ReceiverNames(); !reflect.DeepEqual(names, []string{"team-a", "team-b"}) {
+		t.Fatalf("Expected merged receivers, got %v", names)
+	}
+	rcv := cfg.Receivers[0]
+	if len(rcv.WebhookConfigs) != 2 || rcv.WebhookConfigs[1].URL.String() != "http://example.com/a2" || len(rcv.EmailConfigs) != 1 {
+		t.Errorf("Expected the integrations of both receivers, got %v", rcv)
+	}
+	if ws := cfg.Lint(); len(ws) != 0 {
+		t.Errorf("Unexpected warnings %v", ws)
+	}
+
+	// Receivers sending to the same target are merged, but reported.
+	cfg, err = LoadWithOptions(strings.Replace(in, "example.com/a2", "example.com/a", 1), LoadOptions{MergeDuplicateReceivers: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := []LintWarning{{
+		Code:    LintDuplicateTarget,
+		Path:    "receivers[0].webhook_configs[1]",
+		Message: "notifications are sent to the same target as webhook_configs[0]",
+	}}
+	if got := cfg.Lint(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected warnings %v, got %v", expected, got)
+	}
+
+	_, err = LoadWithOptions(`
+route:
+  receiver: team-a
+receivers:
+- name: team-a
+  blackhole: true
+- name: team-a
+  webhook_configs:
+  - url: http://example.com/a
+  templates: [team-a.tmpl]
+- name: team-a
+  templates: [other.tmpl]
+`, LoadOptions{MergeDuplicateReceivers: true})
+	if want := `cannot merge receivers named "team-a": conflicting values of templates`; err == nil || err.Error() != want {
+		t.Errorf("Expected error %q, got %v", want, err)
+	}
+}
+
+func TestLeafReceivers(t *testing.T) {
+	cfg := mustLoad(t, `
+route:
+  receiver: default
+  routes:
+  - match:
+      team: a
+    routes:
+    - match:
+        severity: critical
+      routes:
+      - match:
+          env: prod
+    - match:
+        severity: warning
+      receiver: team-a
+      routes:
+      - match:
+          env: prod
+
+receivers:
+- name: default
+  blackhole: true
+- name: team-a
+  blackhole: true
+`)
+	if err := checkLeafReceivers(cfg.Route, "route", ""); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, c := range []struct {
+		path     []*Route
+		receiver string
+	}{
+		{[]*Route{cfg.Route, cfg.Route.Routes[0], cfg.Route.Routes[0].Routes[0], cfg.Route.Routes[0].Routes[0].Routes[0]}, "default"},
+		{[]*Route{cfg.Route, cfg.Route.Routes[0], cfg.Route.Routes[0].Routes[1], cfg.Route.Routes[0].Routes[1].Routes[0]}, "team-a"},
+	} {
+		if got := EffectiveConfigForPath(c.path).Receiver; got != c.receiver {
+			t.Errorf("Expected leaf route to resolve to receiver %q, got %q", c.receiver, got)
+		}
+	}
+
+	// An explicitly empty receiver clears the inherited one, so the
+	// leaf routes below have to set their own.
+	in := `
+route:
+  receiver: default
+  routes:
+  - match:
+      team: a
+    receiver: ""
+    routes:
+    - match:
+        severity: critical
+      receiver: team-a
+    - match:
+        severity: warning
+      routes:
+      - match:
+          env: prod
+receivers:
+- name: default
+  blackhole: true
+- name: team-a
+  blackhole: true
+`
+	expectLoadError(t, in, "leaf route at path route.routes[0].routes[1].routes[0] resolves to empty receiver")
+
+	cfg = mustLoad(t, strings.Replace(in, "          env: prod\n", "          env: prod\n        receiver: team-a\n", 1))
+	if !cfg.Route.Routes[0].clearsReceiver {
+		t.Fatal("Expected the explicitly empty receiver to be recorded")
+	}
+	// The empty receiver is kept when the configuration is written.
+	out := cfg.String()
+	if !strings.Contains(out, `receiver: ""`) {
+		t.Errorf("Expected the empty receiver in the marshaled configuration, got:\n%s", out)
+	}
+	expectLoadError(t, strings.Replace(out, "        receiver: team-a\n", "", 1), "leaf route at path route.routes[0].routes[1].routes[0] resolves to empty receiver")
+
+	// Routes built in code may leave the receiver of the root empty.
+	root := &Route{Routes: []*Route{
+		{Receiver: "team-a"},
+		{Routes: []*Route{{Receiver: "team-a"}, {}}},
+	}}
+	err := checkLeafReceivers(root, "route", "")
+	if want := "leaf route at path route.routes[1].routes[1] resolves to empty receiver"; err == nil || err.Error() != want {
+		t.Errorf("Expected error %q, got %v", want, err)
+	}
+}
+
+func TestReceiverLookup(t *testing.T) {
+	cfg, err := Load(`
+route:
+  receiver: team-b
+
+receivers:
+- name: team-b
+  webhook_configs:
+  - url: http://example.com/
+- name: team-a
+  blackhole: true
+`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if names := cfg.ReceiverNames(); !reflect.DeepEqual(names, []string{"team-b", "team-a"}) {
+		t.Errorf("Expected receiver names in definition order, got %v", names)
+	}
+
+	rcv, ok := cfg.ReceiverByName("team-b")
+	if !ok || rcv != cfg.Receivers[0] {
+		t.Errorf("Expected to find receiver team-b, got %v", rcv)
+	}
+	if rcv.IsEmpty() {
+		t.Errorf("Expected receiver team-b not to be empty")
+	}
+	if rcv, ok = cfg.ReceiverByName("team-a"); !ok || !rcv.IsEmpty() {
+		t.Errorf("Expected to find empty receiver team-a")
+	}
+	if rcv, ok = cfg.ReceiverByName("team-c"); ok || rcv != nil {
+		t.Errorf("Expected not to find receiver team-c, got %v", rcv)
+	}
+}
+
+func TestLoadLimits(t *testing.T) {
+	in := `
+route:
+  receiver: a
+  routes:
+  - receiver: a
+  - receiver: b
+
+receivers:
+- name: a
+  blackhole: true
+- name: b
+  blackhole: true
+
+inhibit_rules:
+- source_match:
+    severity: critical
+  target_match:
+    severity: warning
+- source_match:
+    severity: warning
+  target_match:
+    severity: info
+`
+	cases := []struct {
+		opts LoadOptions
+		err  string
+	}{
+		{opts: LoadOptions{}},
+		{
+			opts: LoadOptions{MaxSize: 100},
+			err:  fmt.Sprintf("config size of %d bytes exceeds the limit of 100 bytes", len(in)),
+		},
+		{opts: LoadOptions{MaxSize: len(in)}},
+		{
+			opts: LoadOptions{MaxRoutes: 2},
+			err:  "config has 3 routes, more than the limit of 2",
+		},
+		{
+			opts: LoadOptions{MaxReceivers: 1},
+			err:  "config has 2 receivers, more than the limit of 1",
+		},
+		{
+			opts: LoadOptions{MaxInhibitRules: 1},
+			err:  "config has 2 inhibit rules, more than the limit of 1",
+		},
+		{
+			// Negative limits are disabled.
+			opts: LoadOptions{MaxSize: -1, MaxRoutes: -1, MaxReceivers: -1, MaxInhibitRules: -1},
+		},
+	}
+
+	for i, c := range cases {
+		_, err := LoadWithOptions(in, c.opts)
+		if c.err == "" {
+			if err != nil {
+				t.Errorf("Case %d: unexpected error: %s", i, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != c.err {
+			t.Errorf("Case %d: expected error %q, got %v", i, c.err, err)
+		}
+	}
+}
+
+func TestLoadFileWithOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "am_config_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	in := `
+global:
+  smtp_from: 'alerts-{{ .Region }}@example.com'
+  smtp_smarthost: localhost:25
+
+route:
+  receiver: team-$AM_TEST_TEAM
+
+receivers:
+- name: team-$AM_TEST_TEAM
+  pagerduty_configs:
+  - service_key: key
+`
+	filename := filepath.Join(dir, "alertmanager.yml")
+	if err := ioutil.WriteFile(filename, []byte(in), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("AM_TEST_TEAM", "a")
+	defer os.Unsetenv("AM_TEST_TEAM")
+
+	cases := []struct {
+		opts     LoadOptions
+		receiver string
+		from     string
+		err      string
+	}{
+		{
+			// The zero value enables no option.
+			opts:     LoadOptions{},
+			receiver: "team-$AM_TEST_TEAM",
+			from:     "alerts-{{ .Region }}@example.com",
+		},
+		{
+			opts:     LoadOptions{ExpandEnv: true},
+			receiver: "team-a",
+			from:     "alerts-{{ .Region }}@example.com",
+		},
+		{
+			opts:     LoadOptions{Vars: map[string]string{"Region": "eu"}},
+			receiver: "team-$AM_TEST_TEAM",
+			from:     "alerts-eu@example.com",
+		},
+		{
+			opts:     LoadOptions{ExpandEnv: true, Vars: map[string]string{"Region": "eu"}},
+			receiver: "team-a",
+			from:     "alerts-eu@example.com",
+		},
+		{
+			opts: LoadOptions{StrictMode: true},
+			err:  "service_key is deprecated, use routing_key instead",
+		},
+		{
+			opts: LoadOptions{MaxSize: 100},
+			err:  fmt.Sprintf("config size of %d bytes exceeds the limit of 100 bytes", len(in)),
+		},
+		{
+			// Expanded input must be within the limit, too.
+			opts: LoadOptions{MaxSize: len(in), Vars: map[string]string{"Region": strings.Repeat("eu", 100)}},
+			err:  "exceeds the limit",
+		},
+	}
+
+	for i, c := range cases {
+		cfg, err := LoadFileWithOptions(filename, c.opts)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("Case %d: expected error containing %q, got %v", i, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Case %d: unexpected error: %s", i, err)
+			continue
+		}
+		if cfg.Route.Receiver != c.receiver {
+			t.Errorf("Case %d: expected receiver %q, got %q", i, c.receiver, cfg.Route.Receiver)
+		}
+		if cfg.Global.SMTPFrom != c.from {
+			t.Errorf("Case %d: expected from address %q, got %q", i, c.from, cfg.Global.SMTPFrom)
+		}
+		if !strings.Contains(cfg.String(), "{{ .Region }}") || !strings.Contains(cfg.String(), "$AM_TEST_TEAM") {
+			t.Errorf("Case %d: expected the unexpanded input as config string, got\n%s", i, cfg)
+		}
+	}
+}
+
+func TestResolveReceiver(t *testing.T) {
+	cfg, err := Load(`
+global:
+  smtp_smarthost: smtp.example.com:587
+  smtp_from: alertmanager@example.com
+  smtp_auth_username: alertmanager
+  smtp_auth_password: smtp-password
+  slack_api_url: https://hooks.slack.com/services/slack-token
+  hipchat_auth_token: hipchat-token
+
+route:
+  receiver: team-a
+
+receivers:
+- name: team-a
+  email_configs:
+  - to: team-a@example.com
+  slack_configs:
+  - channel: '#team-a'
+  hipchat_configs:
+  - room_id: 1
+`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	rcv, err := cfg.ResolveReceiver("team-a")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	ec := rcv.EmailConfigs[0]
+	if ec.Smarthost.String() != "smtp.example.com:587" || ec.From != "alertmanager@example.com" || ec.AuthPassword != "smtp-password" {
+		t.Errorf("Expected global SMTP settings in email config, got %+v", ec)
+	}
+	if u := rcv.SlackConfigs[0].APIURL; u == nil || u.URL.String() != "https://hooks.slack.com/services/slack-token" {
+		t.Errorf("Expected global Slack API URL in Slack config, got %v", u)
+	}
+	hc := rcv.HipchatConfigs[0]
+	if hc.AuthToken != "hipchat-token" || hc.APIURL.String() != "https://api.hipchat.com/" {
+		t.Errorf("Expected global HipChat settings in HipChat config, got %+v", hc)
+	}
+
+	// The copy does not share any state with the configuration.
+	ec.To = "changed@example.com"
+	rcv.SlackConfigs[0].APIURL.Path = "/changed"
+	if cfg.Receivers[0].EmailConfigs[0].To != "team-a@example.com" || cfg.Receivers[0].SlackConfigs[0].APIURL.Path != "/services/slack-token" {
+		t.Errorf("Changing the resolved receiver changed the configuration")
+	}
+
+	s := rcv.String()
+	for _, secret := range []string{"smtp-password", "slack-token", "hipchat-token"} {
+		if strings.Contains(s, secret) {
+			t.Errorf("Receiver string reveals secret %q:\n%s", secret, s)
+		}
+	}
+
+	if _, err := cfg.ResolveReceiver("team-b"); err == nil || err.Error() != `undefined receiver "team-b"` {
+		t.Errorf("Expected error for undefined receiver, got %v", err)
+	}
+}
+
+func TestResolveFilepathsHTTPConfig(t *testing.T) {
+	cfg, err := Load(`
+route:
+  receiver: default
+
+receivers:
+- name: default
+  webhook_configs:
+  - url: https://example.com/
+    http_config:
+      tls_config:
+        ca_file: ca.pem
+        cert_file: /etc/ssl/client.pem
+        key_file: client.key
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	resolveFilepaths("/etc/alertmanager", cfg)
+
+	tc := cfg.Receivers[0].WebhookConfigs[0].HTTPConfig.TLSConfig
+	if tc.CAFile != "/etc/alertmanager/ca.pem" {
+		t.Errorf("Expected resolved CA file, got %q", tc.CAFile)
+	}
+	if tc.CertFile != "/etc/ssl/client.pem" {
+		t.Errorf("Expected absolute cert file to be unchanged, got %q", tc.CertFile)
+	}
+	if tc.KeyFile != "/etc/alertmanager/client.key" {
+		t.Errorf("Expected resolved key file, got %q", tc.KeyFile)
+	}
+}
+
+func TestGlobalHTTPConfig(t *testing.T) {
+	in := `
+global:
+  slack_api_url: https://hooks.slack.com/services/secret
+  http_config:
+    basic_auth:
+      username: user
+      password: global-password
+    proxy_url: http://proxy.example.com:3128
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+  slack_configs:
+  - channel: '#alerts'
+  webhook_configs:
+  - url: https://example.com/
+    http_config:
+      bearer_token: webhook-token
+`
+	cfg, err := Load(in)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	sc := cfg.Receivers[0].SlackConfigs[0]
+	if sc.HTTPConfig == nil || sc.HTTPConfig.ProxyURL != "http://proxy.example.com:3128" {
+		t.Fatalf("Expected global HTTP config on Slack config, got %+v", sc.HTTPConfig)
+	}
+	if sc.HTTPConfig == cfg.Global.HTTPConfig {
+		t.Errorf("Expected Slack config to hold a copy of the global HTTP config")
+	}
+	wh := cfg.Receivers[0].WebhookConfigs[0]
+	if wh.HTTPConfig.BearerToken != "webhook-token" || wh.HTTPConfig.BasicAuth != nil {
+		t.Errorf("Expected webhook HTTP config to override the global one, got %+v", wh.HTTPConfig)
+	}
+
+	for _, s := range []string{cfg.String(), Config{Global: cfg.Global, Receivers: cfg.Receivers}.String()} {
+		for _, secret := range []string{"global-password", "webhook-token"} {
+			if strings.Contains(s, secret) {
+				t.Errorf("Config string reveals secret %q:\n%s", secret, s)
+			}
+		}
+	}
+}
+
+func TestGlobalProxyURL(t *testing.T) {
+	in := `
+global:
+  slack_api_url: https://hooks.slack.com/services/secret
+  proxy_url: http://global-proxy.example.com:3128
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+  slack_configs:
+  - channel: '#alerts'
+  webhook_configs:
+  - url: https://example.com/a
+    http_config:
+      bearer_token: webhook-token
+  - url: https://example.com/b
+    http_config:
+      proxy_url: http://receiver-proxy.example.com:3128
+  victorops_configs:
+  - api_key: key
+    routing_key: ops
+  wechat_configs:
+  - api_secret: secret
+    corp_id: corp
+    to_user: ops
+  telegram_configs:
+  - bot_token: token
+    chat_id: 1
+  msteams_configs:
+  - webhook_url: https://example.webhook.office.com/webhookb2/token
+  discord_configs:
+  - webhook_url: https://discord.com/api/webhooks/1/token
+  sns_configs:
+  - topic_arn: 'arn:aws:sns:eu-west-1:123456789012:alerts'
+    http_config:
+      proxy_url: http://receiver-proxy.example.com:3128
+`
+	cfg, err := Load(in)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	rcv := cfg.Receivers[0]
+	cases := []struct {
+		name string
+		hc   *HTTPClientConfig
+		want string
+	}{
+		{"slack without http config", rcv.SlackConfigs[0].HTTPConfig, "http://global-proxy.example.com:3128"},
+		{"webhook without proxy", rcv.WebhookConfigs[0].HTTPConfig, "http://global-proxy.example.com:3128"},
+		{"webhook with proxy", rcv.WebhookConfigs[1].HTTPConfig, "http://receiver-proxy.example.com:3128"},
+		{"victorops", rcv.VictorOpsConfigs[0].HTTPConfig, "http://global-proxy.example.com:3128"},
+		{"wechat", rcv.WechatConfigs[0].HTTPConfig, "http://global-proxy.example.com:3128"},
+		{"telegram", rcv.TelegramConfigs[0].HTTPConfig, "http://global-proxy.example.com:3128"},
+		{"msteams", rcv.MSTeamsConfigs[0].HTTPConfig, "http://global-proxy.example.com:3128"},
+		{"discord", rcv.DiscordConfigs[0].HTTPConfig, "http://global-proxy.example.com:3128"},
+		{"sns with proxy", rcv.SNSConfigs[0].HTTPConfig, "http://receiver-proxy.example.com:3128"},
+	}
+	for _, c := range cases {
+		if c.hc == nil {
+			t.Errorf("%s: expected HTTP config to be set", c.name)
+			continue
+		}
+		if c.hc.ProxyURL != c.want {
+			t.Errorf("%s: expected proxy URL %q, got %q", c.name, c.want, c.hc.ProxyURL)
+		}
+	}
+	if rcv.WebhookConfigs[0].HTTPConfig.BearerToken != "webhook-token" {
+		t.Errorf("Expected the webhook HTTP config to be kept, got %+v", rcv.WebhookConfigs[0].HTTPConfig)
+	}
+
+	// Without a global proxy URL, receivers keep using the environment.
+	cfg, err = Load(`
+route:
+  receiver: default
+receivers:
+- name: default
+  webhook_configs:
+  - url: https://example.com/
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	if hc := cfg.Receivers[0].WebhookConfigs[0].HTTPConfig; hc != nil {
+		t.Errorf("Expected no HTTP config, got %+v", hc)
+	}
+
+	errCases := []struct {
+		in  string
+		err string
+	}{
+		{
+			in:  "proxy_url: proxy.example.com",
+			err: `invalid proxy URL "proxy.example.com": scheme and host required`,
+		},
+		{
+			in:  "proxy_url: http://a.example.com\nhttp_config:\n  proxy_url: http://b.example.com",
+			err: "at most one of proxy_url and http_config.proxy_url must be configured",
+		},
+	}
+	for _, c := range errCases {
+		var gc GlobalConfig
+		err := yaml.Unmarshal([]byte(c.in), &gc)
+		if err == nil || err.Error() != c.err {
+			t.Errorf("%q: expected error %q, got %v", c.in, c.err, err)
+		}
+	}
+}
+
+func TestConfigStringRedactsSMTPAuth(t *testing.T) {
+	cfg, err := Load(`
+global:
+  smtp_smarthost: localhost:25
+  smtp_from: alertmanager@example.org
+  smtp_auth_username: alertmanager
+  smtp_auth_password: global-password
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+  email_configs:
+  - to: team-a@example.org
+    auth_secret: receiver-secret
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	for _, s := range []string{cfg.String(), Config{Global: cfg.Global, Receivers: cfg.Receivers}.String()} {
+		for _, secret := range []string{"global-password", "receiver-secret"} {
+			if strings.Contains(s, secret) {
+				t.Errorf("Config string reveals secret %q:\n%s", secret, s)
+			}
+		}
+	}
+}
+
+func TestInhibitRuleValidation(t *testing.T) {
+	cases := []struct {
+		rule string
+		err  string
+	}{
+		{
+			rule: `
+- source_match:
+    severity: critical
+  equal: [alertname]
+`,
+			err: "inhibit rule must have source and target matchers",
+		},
+		{
+			rule: `
+- target_match_re:
+    severity: warning|info
+`,
+			err: "inhibit rule must have source and target matchers",
+		},
+		{
+			rule: `
+- source_match:
+    severity: critical
+  target_match:
+    severity: warning
+  equal: [alertname, cluster, alertname]
+`,
+			err: `duplicated label "alertname" in equal`,
+		},
+		{
+			rule: `
+- source_match:
+    severity: critical
+  target_match:
+    severity: warning
+  equal: [alert-name]
+`,
+			err: `"alert-name" is not a valid label name`,
+		},
+		{
+			rule: `
+- source_match:
+    severity: critical
+  source_match_re:
+    severity: crit.*
+  target_match:
+    severity: warning
+`,
+			err: `label "severity" must not be in both source_match and source_match_re`,
+		},
+		{
+			rule: `
+- source_match:
+    severity: critical
+  target_match:
+    severity: warning
+    team: ops
+  target_match_re:
+    team: ops|dev
+    severity: warn.*
+`,
+			err: `label "severity" must not be in both target_match and target_match_re`,
+		},
+		{
+			rule: `
+- source_match:
+    severity: critical
+  target_match:
+    severity: warning
+  ttl: 0s
+`,
+			err: "invalid ttl in inhibit rule: must be positive",
+		},
+		{
+			rule: `
+- source_match:
+    severity: critical
+  target_match:
+    severity: warning
+  ttl: soon
+`,
+			err: `inhibit_rules[0].ttl: invalid duration "soon"`,
+		},
+	}
+
+	for _, c := range cases {
+		expectLoadError(t, `
+route:
+  receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+
+inhibit_rules:`+c.rule, c.err)
+	}
+}
+
+func TestInhibitRuleTTL(t *testing.T) {
+	cfg, err := Load(`
+global:
+  resolve_timeout: 10m
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+
+inhibit_rules:
+- source_match:
+    severity: critical
+  target_match:
+    severity: warning
+- source_match:
+    severity: warning
+  target_match:
+    severity: info
+  ttl: 2m
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	for i, want := range []Duration{Duration(10 * time.Minute), Duration(2 * time.Minute)} {
+		if got := cfg.InhibitRules[i].TTL; got == nil || *got != want {
+			t.Errorf("Expected ttl %s for inhibit rule %d, got %v", want, i, got)
+		}
+	}
+}
+
+func TestRouteGroupByAll(t *testing.T) {
+	for groupBy, err := range map[string]string{
+		`[alertname, "..."]`:       `cannot combine "..." with other labels in group_by`,
+		`["...", alertname]`:       `cannot combine "..." with other labels in group_by`,
+		`["...", "..."]`:           `cannot combine "..." with other labels in group_by`,
+		`[alertname, "...."]`:      `"...." is not a valid label name`,
+		`[job, job]`:               `duplicated label "job" in group_by`,
+		"[]\n  group_by_all: true": `cannot combine group_by_all with group_by`,
+	} {
+		expectLoadError(t, `
+route:
+  receiver: default
+  group_by: `+groupBy+`
+
+receivers:
+- name: default
+  blackhole: true
+`, err)
+	}
+
+	cfg, err := Load(`
+route:
+  receiver: default
+  group_by: [alertname]
+  routes:
+  - receiver: default
+    group_by: ['...']
+  - receiver: default
+    group_by_all: true
+
+receivers:
+- name: default
+  blackhole: true
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	if r := cfg.Route.Routes[1]; !r.GroupByAll || r.GroupBy != nil {
+		t.Fatalf("Expected wildcard group_by from group_by_all, got %v (all: %t)", r.GroupBy, r.GroupByAll)
+	}
+	child := cfg.Route.Routes[0]
+	if !child.GroupByAll || child.GroupBy != nil {
+		t.Fatalf("Expected wildcard group_by, got %v (all: %t)", child.GroupBy, child.GroupByAll)
+	}
+	if cfg.Route.GroupByAll {
+		t.Fatalf("Unexpected wildcard group_by in root route")
+	}
+
+	ec := EffectiveConfigForPath([]*Route{cfg.Route, child})
+	if !ec.GroupByAll || ec.GroupBy != nil {
+		t.Errorf("Expected effective wildcard group_by, got %v (all: %t)", ec.GroupBy, ec.GroupByAll)
+	}
+
+	// The wildcard must survive a round trip, also for routes built in
+	// code that do not set GroupByStr.
+	cfg.Route.Routes = append(cfg.Route.Routes, &Route{Receiver: "default", GroupByAll: true})
+	reloaded, err := Load(cfg.String())
+	if err != nil {
+		t.Fatalf("Error loading marshaled config: %s", err)
+	}
+	for i, r := range reloaded.Route.Routes {
+		if !r.GroupByAll {
+			t.Errorf("Expected wildcard group_by in route %d after round trip", i)
+		}
+	}
+	if !reflect.DeepEqual(reloaded.Route.GroupBy, []model.LabelName{"alertname"}) {
+		t.Errorf("Unexpected group_by of root route after round trip: %v", reloaded.Route.GroupBy)
+	}
+}
+
+func TestRouteGroupByEmpty(t *testing.T) {
+	cfg, err := Load(`
+route:
+  receiver: default
+  group_by: [alertname, cluster]
+  routes:
+  - receiver: default
+    group_by: []
+    routes:
+    - receiver: default
+  - receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	check := func(cfg *Config) {
+		root := cfg.Route
+		empty, unset := root.Routes[0], root.Routes[1]
+		if empty.GroupBy == nil || len(empty.GroupBy) != 0 {
+			t.Errorf("Expected explicitly empty group_by, got %#v", empty.GroupBy)
+		}
+		if unset.GroupBy != nil {
+			t.Errorf("Expected unset group_by, got %#v", unset.GroupBy)
+		}
+
+		for _, path := range [][]*Route{
+			{root, empty},
+			{root, empty, empty.Routes[0]},
+		} {
+			ec := EffectiveConfigForPath(path)
+			if ec.GroupBy == nil || len(ec.GroupBy) != 0 || ec.GroupByAll {
+				t.Errorf("Expected effective empty group_by for path of length %d, got %#v", len(path), ec.GroupBy)
+			}
+		}
+		ec := EffectiveConfigForPath([]*Route{root, unset})
+		if want := []model.LabelName{"alertname", "cluster"}; !reflect.DeepEqual(ec.GroupBy, want) {
+			t.Errorf("Expected inherited group_by %v, got %v", want, ec.GroupBy)
+		}
+	}
+	check(cfg)
+
+	reloaded, err := Load(cfg.String())
+	if err != nil {
+		t.Fatalf("Error loading marshaled config: %s", err)
+	}
+	check(reloaded)
+}
+
+func TestMarshalRedactedAndWithSecrets(t *testing.T) {
+	cfg, err := Load(`
+global:
+  smtp_smarthost: localhost:25
+  smtp_from: alertmanager@example.org
+  smtp_auth_username: alertmanager
+  smtp_auth_password: smtp-secret
+
+route:
+  receiver: default
+  routes:
+  - match_re:
+      service: foo1|foo2|baz
+    receiver: team-X
+
+receivers:
+- name: default
+  email_configs:
+  - to: team@example.org
+- name: team-X
+  webhook_configs:
+  - url: http://example.com/
+    http_config:
+      bearer_token: bearer-secret
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	redacted, err := cfg.MarshalRedacted()
+	if err != nil {
+		t.Fatalf("Error marshaling redacted config: %s", err)
+	}
+	for _, secret := range []string{"smtp-secret", "bearer-secret"} {
+		if strings.Contains(string(redacted), secret) {
+			t.Errorf("Redacted config contains secret %q:\n%s", secret, redacted)
+		}
+	}
+	rcfg, err := Load(string(redacted))
+	if err != nil {
+		t.Fatalf("Error loading redacted config: %s", err)
+	}
+	if !reflect.DeepEqual(rcfg.Route, cfg.Route) {
+		t.Errorf("Routes of redacted config differ from original")
+	}
+	if rcfg.Global.SMTPAuthPassword != "<hidden>" {
+		t.Errorf("Expected hidden SMTP password, got %q", rcfg.Global.SMTPAuthPassword)
+	}
+
+	withSecrets, err := cfg.MarshalWithSecrets()
+	if err != nil {
+		t.Fatalf("Error marshaling config with secrets: %s", err)
+	}
+	scfg, err := Load(string(withSecrets))
+	if err != nil {
+		t.Fatalf("Error loading config with secrets: %s", err)
+	}
+	if scfg.Global.SMTPAuthPassword != "smtp-secret" {
+		t.Errorf("Expected SMTP password to be preserved, got %q", scfg.Global.SMTPAuthPassword)
+	}
+	if hc := scfg.Receivers[1].WebhookConfigs[0].HTTPConfig; hc.BearerToken != "bearer-secret" {
+		t.Errorf("Expected bearer token to be preserved, got %q", hc.BearerToken)
+	}
+	again, err := scfg.MarshalWithSecrets()
+	if err != nil {
+		t.Fatalf("Error marshaling reloaded config: %s", err)
+	}
+	if string(again) != string(withSecrets) {
+		t.Errorf("Config changed after reloading:\n%s\n\nvs\n\n%s", withSecrets, again)
+	}
+
+	// Secrets must not leak into other marshaling afterwards.
+	if strings.Contains(Config{Global: cfg.Global}.String(), "smtp-secret") {
+		t.Errorf("Config string contains secret after marshaling with secrets")
+	}
+}
+
+func TestMarshalWithSecretsConcurrently(t *testing.T) {
+	cfg := mustLoad(t, `
+global:
+  slack_api_url: https://hooks.slack.com/services/slack-secret
+route:
+  receiver: default
+receivers:
+- name: default
+  slack_configs:
+  - channel: '#ops'
+`)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if _, err := cfg.MarshalWithSecrets(); err != nil {
+				t.Errorf("Error marshaling config with secrets: %s", err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		b, err := cfg.MarshalRedacted()
+		if err != nil {
+			t.Fatalf("Error marshaling redacted config: %s", err)
+		}
+		if strings.Contains(string(b), "slack-secret") {
+			t.Fatalf("Redacted config contains secret:\n%s", b)
+		}
+	}
+	<-done
+}
+
+func TestMarshalStable(t *testing.T) {
+	cfg := mustLoad(t, `
+route:
+  receiver: default
+  routes:
+  - receiver: default
+    match:
+      team: a
+      service: api
+      env: prod
+      cluster: eu-1
+      zone: b
+    match_re:
+      severity: critical|page
+      instance: web-.*
+      job: node|blackbox
+    annotation_match:
+      runbook: none
+      owner: ops
+receivers:
+- name: default
+  email_configs:
+  - to: ops@example.org
+    from: alertmanager@example.org
+    smarthost: localhost:25
+    headers:
+      X-Team: ops
+      Reply-To: ops@example.org
+      X-Priority: "1"
+      Subject: alert
+inhibit_rules:
+- source_match:
+    severity: critical
+    team: a
+    env: prod
+  target_match:
+    severity: warning
+    team: a
+    env: prod
+  equal: [instance, job]
+`)
+	// Marshal the configuration itself rather than the original input.
+	cc := *cfg
+	cc.original = ""
+
+	want := cc.String()
+	wantSecrets, err := cc.MarshalWithSecrets()
+	if err != nil {
+		t.Fatalf("Error marshaling config with secrets: %s", err)
+	}
+	for i := 0; i < 20; i++ {
+		if got := cc.String(); got != want {
+			t.Fatalf("Marshaled config changed:\n%s\nexpected:\n%s", got, want)
+		}
+		b, err := cc.MarshalWithSecrets()
+		if err != nil {
+			t.Fatalf("Error marshaling config with secrets: %s", err)
+		}
+		if string(b) != string(wantSecrets) {
+			t.Fatalf("Marshaled config with secrets changed:\n%s\nexpected:\n%s", b, wantSecrets)
+		}
+	}
+	if !strings.Contains(want, "match:\n      cluster: eu-1\n      env: prod\n      service: api\n      team: a\n      zone: b\n") {
+		t.Errorf("Expected sorted match keys, got:\n%s", want)
+	}
+}
+
+// fillSecrets sets all fields of type Secret reachable from v to a value
+// derived from their name, allocating the values leading to them. It
+// returns the number of secrets set.
+func fillSecrets(v reflect.Value, path string) int {
+	switch v.Kind() {
+	case reflect.Ptr:
+		// Do not recurse into types containing themselves.
+		if strings.Contains(path, v.Type().Elem().Name()+".") {
+			return 0
+		}
+		e := reflect.New(v.Type().Elem())
+		n := fillSecrets(e.Elem(), path)
+		if n > 0 {
+			v.Set(e)
+		}
+		return n
+	case reflect.Slice:
+		e := reflect.New(v.Type().Elem()).Elem()
+		n := fillSecrets(e, path)
+		if n > 0 {
+			v.Set(reflect.Append(v, e))
+		}
+		return n
+	case reflect.Struct:
+		n := 0
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			if f.Type == reflect.TypeOf(Secret("")) {
+				v.Field(i).SetString("secret-" + path + v.Type().Name() + "." + f.Name)
+				n++
+				continue
+			}
+			n += fillSecrets(v.Field(i), path+v.Type().Name()+".")
+		}
+		return n
+	}
+	return 0
+}
+
+func TestConfigStringRedactsAllSecrets(t *testing.T) {
+	cfg := &Config{}
+	n := fillSecrets(reflect.ValueOf(cfg).Elem(), "")
+	if n == 0 {
+		t.Fatal("No secrets found in config")
+	}
+
+	b, err := cfg.MarshalWithSecrets()
+	if err != nil {
+		t.Fatalf("Error marshaling config: %s", err)
+	}
+	if c := strings.Count(string(b), "secret-"); c != n {
+		t.Fatalf("Expected %d secrets in marshaled config, found %d:\n%s", n, c, b)
+	}
+
+	for _, s := range []string{Config{original: string(b)}.String(), cfg.String()} {
+		if strings.Contains(s, "secret-") {
+			t.Errorf("Config string contains secrets:\n%s", s)
+		}
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	in := `{
+	"global": {"smtp_smarthost": "localhost:25", "smtp_from": "am@example.org", "hipchat_auth_token": "hc-secret"},
+	"route": {"receiver": "default", "group_wait": "10s", "routes": [{"match": {"team": "a"}, "receiver": "team-a"}]},
+	"receivers": [
+		{"name": "default", "email_configs": [{"to": "team@example.org"}]},
+		{"name": "team-a", "hipchat_configs": [{"room_id": 1234567890, "api_url": "https://hipchat.example.com/"}]}
+	]
+}`
+	cfg, err := LoadJSON(in)
+	if err != nil {
+		t.Fatalf("Error loading JSON config: %s", err)
+	}
+	if cfg.Receivers[1].HipchatConfigs[0].RoomID != "1234567890" {
+		t.Errorf("Unexpected room ID %q", cfg.Receivers[1].HipchatConfigs[0].RoomID)
+	}
+	if cfg.Receivers[0].EmailConfigs[0].Smarthost.String() != "localhost:25" {
+		t.Errorf("Expected global smarthost to be applied, got %q", cfg.Receivers[0].EmailConfigs[0].Smarthost)
+	}
+	if s := cfg.String(); strings.Contains(s, "hc-secret") || !strings.Contains(s, `"receiver": "team-a"`) {
+		t.Errorf("Expected redacted JSON input as config string, got:\n%s", s)
+	}
+
+	dir := writeFiles(t, map[string]string{"config.json": in})
+	defer os.RemoveAll(dir)
+	fcfg, err := LoadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatalf("Error loading JSON config file: %s", err)
+	}
+	if !reflect.DeepEqual(fcfg.Route, cfg.Route) {
+		t.Errorf("Expected same route from file and string input")
+	}
+
+	cases := []struct {
+		in  string
+		err string
+	}{
+		{in: `{"route": {"receiver": "default"}, "receivers": [{"name": "default"}], "foo": 1}`, err: "unknown fields in config: foo"},
+		{in: `{"receivers": [{"name": "default"}]}`, err: "no route provided in config"},
+		{in: `{"route": {"receiver": "default"}, "receivers": [{"name": "default"}, {"name": "default"}]}`, err: `notification config name "default" is not unique`},
+		{in: `{"route": {"receiver": "default"`, err: "unexpected EOF"},
+	}
+	for _, c := range cases {
+		_, err := LoadJSON(c.in)
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("Expected error containing %q, got %v", c.err, err)
+		}
+	}
+}
+
+func TestUnknownFieldSuggestions(t *testing.T) {
+	cases := []struct {
+		in  string
+		err string
+	}{
+		{
+			in: `
+route:
+  receiver: default
+  group_wait_seconds: 30
+receivers:
+- name: default
+  blackhole: true
+`,
+			err: `unknown fields in route: group_wait_seconds (did you mean "group_wait"?)`,
+		},
+		{
+			in: `
+route:
+  receiver: default
+  reciever: other
+  foo: bar
+receivers:
+- name: default
+  blackhole: true
+`,
+			err: `unknown fields in route: foo, reciever (did you mean "receiver"?)`,
+		},
+		{
+			// Fields of inlined structs are suggested as well.
+			in: `
+route:
+  receiver: default
+receivers:
+- name: default
+  webhook_configs:
+  - url: http://example.com/
+    send_resolve: false
+`,
+			err: `unknown fields in webhook config: send_resolve (did you mean "send_resolved"?)`,
+		},
+		{
+			in: `
+route:
+  receiver: default
+receivers:
+- name: default
+  email_configs:
+  - to: team@example.com
+    foo: bar
+`,
+			err: `unknown fields in email config: foo`,
+		},
+	}
+	for i, c := range cases {
+		_, err := Load(c.in)
+		if err == nil || !strings.HasSuffix(err.Error(), c.err) {
+			t.Errorf("Case %d: expected error ending in %q, got %v", i, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// deprecatedField is a field that is still supported but will be removed
+// in favor of its replacement.
+type deprecatedField struct {
+	// typ is the type of the section the field belongs to.
+	typ         reflect.Type
+	key         string
+	replacement string
+}
+
+// deprecatedFields is the registry of all deprecated fields.
+var deprecatedFields = []deprecatedField{
+	// The Events API v1 is superseded by the Events API v2.
+	{typ: reflect.TypeOf(PagerdutyConfig{}), key: "service_key", replacement: "routing_key"},
+}
+
+// Deprecation is the use of a deprecated field in a configuration.
+type Deprecation struct {
+	// Path is the path of the section the field is set in, such as
+	// receivers[0].pagerduty_configs[1].
+	Path string
+	// Field is the deprecated field and Replacement the field to use
+	// instead.
+	Field       string
+	Replacement string
+}
+
+func (d Deprecation) String() string {
+	return fmt.Sprintf("%s: %s", d.Path, d.message())
+}
+
+func (d Deprecation) message() string {
+	return fmt.Sprintf("%s is deprecated, use %s instead", d.Field, d.Replacement)
+}
+
+// DeprecatedFields returns all uses of deprecated fields in the
+// configuration.
+func (c *Config) DeprecatedFields() []Deprecation {
+	var res []Deprecation
+	findDeprecated(reflect.ValueOf(c), "", &res)
+	return res
+}
+
+// checkDeprecated returns an error for the first use of a deprecated
+// field in the configuration.
+func (c *Config) checkDeprecated() error {
+	ds := c.DeprecatedFields()
+	if len(ds) == 0 {
+		return nil
+	}
+	return &ConfigError{
+		Path: joinPath(ds[0].Path, ds[0].Field),
+		Err:  fmt.Errorf("%s", ds[0].message()),
+	}
+}
+
+// findDeprecated appends the deprecated fields set in v and its nested
+// sections to res.
+func findDeprecated(v reflect.Value, path string, res *[]Deprecation) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			findDeprecated(v.Elem(), path, res)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			findDeprecated(v.Index(i), fmt.Sprintf("%s[%d]", path, i), res)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := strings.Split(f.Tag.Get("yaml"), ",")
+			if tag[0] == "-" || f.PkgPath != "" {
+				continue
+			}
+			if len(tag) > 1 && tag[1] == "inline" {
+				findDeprecated(v.Field(i), path, res)
+				continue
+			}
+			key := tag[0]
+			if key == "" {
+				key = strings.ToLower(f.Name)
+			}
+			for _, d := range deprecatedFields {
+				if d.typ == t && d.key == key && !isZeroValue(v.Field(i)) {
+					*res = append(*res, Deprecation{Path: path, Field: key, Replacement: d.replacement})
+				}
+			}
+			findDeprecated(v.Field(i), joinPath(path, key), res)
+		}
+	}
+}
+
+func isZeroValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
@@ -0,0 +1,395 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ChangeType is the kind of a Change.
+type ChangeType int
+
+// The kinds of changes between two configurations.
+const (
+	ChangeAdded ChangeType = iota
+	ChangeRemoved
+	ChangeModified
+)
+
+func (t ChangeType) String() string {
+	switch t {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	}
+	return fmt.Sprintf("<unknown change type %d>", int(t))
+}
+
+// Change is a route, receiver or inhibit rule that differs between two
+// configurations.
+type Change struct {
+	Type ChangeType
+	// Path identifies the changed entry, e.g. route.routes[1],
+	// receivers["team-a"] or inhibit_rules[0].
+	Path string
+	// Fields lists the differing fields of modified entries.
+	Fields []FieldChange
+}
+
+func (c Change) String() string {
+	s := fmt.Sprintf("%s %s", c.Type, c.Path)
+	for _, f := range c.Fields {
+		s += "\n  " + f.String()
+	}
+	return s
+}
+
+// FieldChange is a field that differs between two versions of an entry.
+type FieldChange struct {
+	// Path is the path of the field within the entry.
+	Path string
+	// Old and New are the values of the field formatted for display.
+	// Both are empty if the field holds a secret.
+	Old, New string
+	Secret   bool
+}
+
+func (f FieldChange) String() string {
+	if f.Secret {
+		return fmt.Sprintf("%s: <changed>", f.Path)
+	}
+	return fmt.Sprintf("%s: %s -> %s", f.Path, f.Old, f.New)
+}
+
+// unsetValue is displayed for fields missing in one version of an entry.
+const unsetValue = "<unset>"
+
+// secretKeySet holds the keys of all secrets in a configuration.
+var secretKeySet = func() map[string]struct{} {
+	keys := map[string]struct{}{}
+	for _, k := range secretKeys(reflect.TypeOf(Config{})) {
+		keys[k] = struct{}{}
+	}
+	return keys
+}()
+
+// Diff returns the semantic differences of the routes, receivers and
+// inhibit rules between the old and the new configuration.
+//
+// Receivers are identified by name, so reordering them is no change.
+// Routes are identified by their path in the routing tree, as their order
+// determines which alerts they match. Inhibit rules are unordered, a rule
+// is only reported as modified if a differing rule takes its position.
+func Diff(old, new *Config) []Change {
+	var changes []Change
+
+	oldRoutes, newRoutes := routesByPath(old.Route), routesByPath(new.Route)
+	for _, p := range mergedKeys(oldRoutes.paths, newRoutes.paths) {
+		if c := diffEntry(p, oldRoutes.routes[p], newRoutes.routes[p]); c != nil {
+			changes = append(changes, *c)
+		}
+	}
+
+	var (
+		oldRcvs, newRcvs = map[string]interface{}{}, map[string]interface{}{}
+		names            []string
+	)
+	for _, rcv := range old.Receivers {
+		oldRcvs[rcv.Name] = rcv
+		names = append(names, rcv.Name)
+	}
+	for _, rcv := range new.Receivers {
+		newRcvs[rcv.Name] = rcv
+		if _, ok := oldRcvs[rcv.Name]; !ok {
+			names = append(names, rcv.Name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if c := diffEntry(fmt.Sprintf("receivers[%q]", name), oldRcvs[name], newRcvs[name]); c != nil {
+			changes = append(changes, *c)
+		}
+	}
+
+	return append(changes, diffInhibitRules(old.InhibitRules, new.InhibitRules)...)
+}
+
+// Hash returns a hash of the configuration that only changes if its
+// content does. The formatting and order of keys in the input it was
+// loaded from do not affect it. Secrets are included in the hash.
+func (c *Config) Hash() [32]byte {
+	// Included files have already been merged into the configuration.
+	cc := *c
+	cc.Include = nil
+
+	fields := flattenEntry(&cc)
+	lines := make([]string, 0, len(fields))
+	for p, v := range fields {
+		lines = append(lines, p+"="+v)
+	}
+	sort.Strings(lines)
+	return sha256.Sum256([]byte(strings.Join(lines, "\n")))
+}
+
+// Equal returns true if the configuration is equivalent to other. Unlike
+// hashes, it ignores the order of receivers, inhibit rules and time
+// intervals, which has no effect. Regular expressions are compared by
+// their source, secrets by their value.
+func (c *Config) Equal(other *Config) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+	return reflect.DeepEqual(c.normalizedFields(), other.normalizedFields())
+}
+
+// normalizedFields returns the fields of the configuration like
+// flattenEntry, with the unordered lists sorted.
+func (c *Config) normalizedFields() map[string]string {
+	// Included files have already been merged into the configuration.
+	cc := *c
+	cc.Include = nil
+
+	cc.Receivers = append([]*Receiver{}, c.Receivers...)
+	sort.SliceStable(cc.Receivers, func(i, j int) bool {
+		return cc.Receivers[i].Name < cc.Receivers[j].Name
+	})
+	cc.TimeIntervals = append([]*TimeInterval{}, c.TimeIntervals...)
+	sort.SliceStable(cc.TimeIntervals, func(i, j int) bool {
+		return cc.TimeIntervals[i].Name < cc.TimeIntervals[j].Name
+	})
+
+	// Inhibit rules have no name, so they are ordered by their content.
+	keys := make(map[*InhibitRule]string, len(c.InhibitRules))
+	for _, ir := range c.InhibitRules {
+		fields := flattenEntry(ir)
+		lines := make([]string, 0, len(fields))
+		for p, v := range fields {
+			lines = append(lines, p+"="+v)
+		}
+		sort.Strings(lines)
+		keys[ir] = strings.Join(lines, "\n")
+	}
+	cc.InhibitRules = append([]*InhibitRule{}, c.InhibitRules...)
+	sort.SliceStable(cc.InhibitRules, func(i, j int) bool {
+		return keys[cc.InhibitRules[i]] < keys[cc.InhibitRules[j]]
+	})
+
+	return flattenEntry(&cc)
+}
+
+// diffEntry compares two versions of an entry, either of which may be
+// nil, and returns the resulting change or nil if both are equal.
+func diffEntry(path string, old, new interface{}) *Change {
+	switch {
+	case old == nil && new == nil:
+		return nil
+	case old == nil:
+		return &Change{Type: ChangeAdded, Path: path}
+	case new == nil:
+		return &Change{Type: ChangeRemoved, Path: path}
+	}
+
+	oldFields, newFields := flattenEntry(old), flattenEntry(new)
+
+	var paths []string
+	for p := range oldFields {
+		paths = append(paths, p)
+	}
+	for p := range newFields {
+		if _, ok := oldFields[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	var fields []FieldChange
+	for _, p := range paths {
+		o, ok := oldFields[p]
+		if !ok {
+			o = unsetValue
+		}
+		n, ok := newFields[p]
+		if !ok {
+			n = unsetValue
+		}
+		if o == n {
+			continue
+		}
+		if isSecretPath(p) {
+			fields = append(fields, FieldChange{Path: p, Secret: true})
+		} else {
+			fields = append(fields, FieldChange{Path: p, Old: o, New: n})
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &Change{Type: ChangeModified, Path: path, Fields: fields}
+}
+
+// diffInhibitRules matches equal inhibit rules regardless of their order.
+// Remaining rules at the same position are reported as modified.
+func diffInhibitRules(old, new []*InhibitRule) []Change {
+	key := func(r *InhibitRule) string {
+		fields := flattenEntry(r)
+		keys := make([]string, 0, len(fields))
+		for p, v := range fields {
+			keys = append(keys, p+"="+v)
+		}
+		sort.Strings(keys)
+		return strings.Join(keys, "\n")
+	}
+
+	unmatched := map[string][]int{}
+	for i, r := range old {
+		k := key(r)
+		unmatched[k] = append(unmatched[k], i)
+	}
+	oldLeft := make([]bool, len(old))
+	for i := range oldLeft {
+		oldLeft[i] = true
+	}
+	newLeft := make([]bool, len(new))
+	for i, r := range new {
+		k := key(r)
+		if idx := unmatched[k]; len(idx) > 0 {
+			oldLeft[idx[0]] = false
+			unmatched[k] = idx[1:]
+			continue
+		}
+		newLeft[i] = true
+	}
+
+	var changes []Change
+	for i := 0; i < len(old) || i < len(new); i++ {
+		var o, n interface{}
+		if i < len(old) && oldLeft[i] {
+			o = old[i]
+		}
+		if i < len(new) && newLeft[i] {
+			n = new[i]
+		}
+		if c := diffEntry(fmt.Sprintf("inhibit_rules[%d]", i), o, n); c != nil {
+			changes = append(changes, *c)
+		}
+	}
+	return changes
+}
+
+// routeIndex holds the routes of a routing tree by their path.
+type routeIndex struct {
+	paths  []string
+	routes map[string]interface{}
+}
+
+// routesByPath indexes the routes of the tree rooted at r by their path in
+// depth-first order. The child routes are removed from the indexed routes
+// so that each route only holds its own options.
+func routesByPath(r *Route) routeIndex {
+	idx := routeIndex{routes: map[string]interface{}{}}
+	var walk func(r *Route, path string)
+	walk = func(r *Route, path string) {
+		own := *r
+		own.Routes = nil
+		idx.paths = append(idx.paths, path)
+		idx.routes[path] = &own
+		for i, cr := range r.Routes {
+			walk(cr, fmt.Sprintf("%s.routes[%d]", path, i))
+		}
+	}
+	if r != nil {
+		walk(r, "route")
+	}
+	return idx
+}
+
+// mergedKeys returns the keys of a followed by the keys of b that are not
+// in a.
+func mergedKeys(a, b []string) []string {
+	seen := map[string]struct{}{}
+	res := append([]string(nil), a...)
+	for _, k := range a {
+		seen[k] = struct{}{}
+	}
+	for _, k := range b {
+		if _, ok := seen[k]; !ok {
+			res = append(res, k)
+		}
+	}
+	return res
+}
+
+// flattenEntry returns the values of all fields of v, including secrets,
+// by their path as they appear in the YAML representation. Entries that
+// cannot be represented are returned as a single field holding the error.
+func flattenEntry(v interface{}) map[string]string {
+	b, err := marshalYAML(v, true)
+	if err != nil {
+		return map[string]string{"": fmt.Sprintf("<error: %s>", err)}
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return map[string]string{"": fmt.Sprintf("<error: %s>", err)}
+	}
+	fields := map[string]string{}
+	flattenValue(fields, "", doc)
+	return fields
+}
+
+func flattenValue(fields map[string]string, path string, v interface{}) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		if len(v) == 0 {
+			fields[path] = "{}"
+		}
+		for k, e := range v {
+			p := fmt.Sprint(k)
+			if path != "" {
+				p = path + "." + p
+			}
+			flattenValue(fields, p, e)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			fields[path] = "[]"
+		}
+		for i, e := range v {
+			flattenValue(fields, fmt.Sprintf("%s[%d]", path, i), e)
+		}
+	case nil:
+	case string:
+		fields[path] = fmt.Sprintf("%q", v)
+	default:
+		fields[path] = fmt.Sprint(v)
+	}
+}
+
+// isSecretPath returns true if the field at the path holds a secret.
+func isSecretPath(path string) bool {
+	key := path[strings.LastIndex(path, ".")+1:]
+	if i := strings.Index(key, "["); i >= 0 {
+		key = key[:i]
+	}
+	_, ok := secretKeySet[key]
+	return ok
+}
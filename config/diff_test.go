@@ -0,0 +1,407 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old, err := Load(`
+route:
+  receiver: default
+  group_by: [alertname]
+  routes:
+  - match:
+      team: a
+    receiver: team-a
+  - match:
+      team: b
+    receiver: team-b
+
+receivers:
+- name: default
+  blackhole: true
+- name: team-a
+  webhook_configs:
+  - url: http://team-a.example.com/
+- name: team-b
+  slack_configs:
+  - api_url: https://hooks.slack.com/services/old-token
+    channel: '#team-b'
+
+inhibit_rules:
+- source_match:
+    severity: critical
+  target_match:
+    severity: warning
+- source_match:
+    severity: warning
+  target_match:
+    severity: info
+`)
+	if err != nil {
+		t.Fatalf("Error loading old config: %s", err)
+	}
+
+	cases := []struct {
+		in      string
+		changes []string
+	}{
+		{
+			// Reordering receivers and inhibit rules is no change.
+			in: `
+route:
+  receiver: default
+  group_by: [alertname]
+  routes:
+  - match:
+      team: a
+    receiver: team-a
+  - match:
+      team: b
+    receiver: team-b
+
+receivers:
+- name: team-b
+  slack_configs:
+  - api_url: https://hooks.slack.com/services/old-token
+    channel: '#team-b'
+- name: team-a
+  webhook_configs:
+  - url: http://team-a.example.com/
+- name: default
+  blackhole: true
+
+inhibit_rules:
+- source_match:
+    severity: warning
+  target_match:
+    severity: info
+- source_match:
+    severity: critical
+  target_match:
+    severity: warning
+`,
+		},
+		{
+			in: `
+route:
+  receiver: default
+  group_by: [alertname, cluster]
+  routes:
+  - match:
+      team: a
+    receiver: team-a
+    continue: true
+
+receivers:
+- name: default
+  blackhole: true
+- name: team-a
+  webhook_configs:
+  - url: http://team-a.example.org/
+- name: team-c
+  blackhole: true
+
+inhibit_rules:
+- source_match:
+    severity: critical
+  target_match:
+    severity: warning
+- source_match:
+    severity: warning
+  target_match:
+    severity: warning
+`,
+			changes: []string{
+				"modified route\n  group_by[1]: <unset> -> \"cluster\"",
+				"modified route.routes[0]\n  continue: <unset> -> true",
+				"removed route.routes[1]",
+				"modified receivers[\"team-a\"]\n  webhook_configs[0].url: \"http://team-a.example.com/\" -> \"http://team-a.example.org/\"",
+				"removed receivers[\"team-b\"]",
+				"added receivers[\"team-c\"]",
+				"modified inhibit_rules[1]\n  target_match.severity: \"info\" -> \"warning\"",
+			},
+		},
+		{
+			// Secrets are compared, but their values are not revealed.
+			in: `
+route:
+  receiver: default
+  group_by: [alertname]
+  routes:
+  - match:
+      team: a
+    receiver: team-a
+  - match:
+      team: b
+    receiver: team-b
+
+receivers:
+- name: default
+  blackhole: true
+- name: team-a
+  webhook_configs:
+  - url: http://team-a.example.com/
+- name: team-b
+  slack_configs:
+  - api_url: https://hooks.slack.com/services/new-token
+    channel: '#team-b'
+
+inhibit_rules:
+- source_match:
+    severity: critical
+  target_match:
+    severity: warning
+`,
+			changes: []string{
+				"modified receivers[\"team-b\"]\n  slack_configs[0].api_url: <changed>",
+				"removed inhibit_rules[1]",
+			},
+		},
+	}
+
+	for i, c := range cases {
+		cfg, err := Load(c.in)
+		if err != nil {
+			t.Fatalf("Case %d: error loading new config: %s", i, err)
+		}
+		var got []string
+		for _, ch := range Diff(old, cfg) {
+			got = append(got, ch.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(c.changes, "\n") {
+			t.Errorf("Case %d: expected changes\n%s\ngot\n%s", i, strings.Join(c.changes, "\n"), strings.Join(got, "\n"))
+		}
+		for _, s := range got {
+			if strings.Contains(s, "token") {
+				t.Errorf("Case %d: change reveals secret: %s", i, s)
+			}
+		}
+	}
+}
+
+func TestConfigHash(t *testing.T) {
+	base := `
+global:
+  slack_api_url: https://hooks.slack.com/services/token
+route:
+  receiver: default
+  group_by: [alertname]
+  routes:
+  - match:
+      team: a
+      severity: critical
+    receiver: default
+receivers:
+- name: default
+  webhook_configs:
+  - url: http://example.com/
+`
+	cases := []struct {
+		in    string
+		equal bool
+	}{
+		{
+			// Whitespace, comments and quoting.
+			in: `
+# The global settings.
+global:
+    slack_api_url:   "https://hooks.slack.com/services/token"
+
+route:
+    receiver:   'default'
+    group_by:   ['alertname']
+    routes:
+    -   match: {team: "a", severity: critical}
+        receiver: default
+
+receivers:
+-   name: default
+    webhook_configs: [{url: 'http://example.com/'}]
+`,
+			equal: true,
+		},
+		{
+			// Reordered keys.
+			in: `
+receivers:
+- webhook_configs:
+  - url: http://example.com/
+  name: default
+route:
+  routes:
+  - receiver: default
+    match:
+      severity: critical
+      team: a
+  group_by: [alertname]
+  receiver: default
+global:
+  slack_api_url: https://hooks.slack.com/services/token
+`,
+			equal: true,
+		},
+		{
+			// Changed secret.
+			in:    strings.Replace(base, "services/token", "services/other", 1),
+			equal: false,
+		},
+		{
+			// Added route.
+			in: strings.Replace(base, "receivers:", `  - match:
+      team: b
+    receiver: default
+receivers:`, 1),
+			equal: false,
+		},
+	}
+
+	want := mustLoad(t, base).Hash()
+	if got := mustLoad(t, base).Hash(); got != want {
+		t.Fatalf("Expected equal hashes for the same config")
+	}
+	for i, c := range cases {
+		got := mustLoad(t, c.in).Hash()
+		if (got == want) != c.equal {
+			t.Errorf("Case %d: expected equal hashes to be %t", i, c.equal)
+		}
+	}
+}
+
+func TestConfigEqual(t *testing.T) {
+	base := `
+global:
+  slack_api_url: https://hooks.slack.com/services/token
+route:
+  receiver: a
+  routes:
+  - match_re:
+      service: api|db
+    receiver: b
+  - match:
+      team: c
+    receiver: a
+receivers:
+- name: a
+  webhook_configs:
+  - url: http://a.example.com/
+- name: b
+  webhook_configs:
+  - url: http://b.example.com/
+inhibit_rules:
+- source_match: {severity: critical}
+  target_match: {severity: warning}
+  equal: [alertname]
+- source_match: {severity: warning}
+  target_match: {severity: info}
+`
+	cases := []struct {
+		in    string
+		equal bool
+	}{
+		{
+			// Reordered receivers and inhibit rules.
+			in: `
+global:
+  slack_api_url: https://hooks.slack.com/services/token
+route:
+  receiver: a
+  routes:
+  - match_re:
+      service: api|db
+    receiver: b
+  - match:
+      team: c
+    receiver: a
+receivers:
+- name: b
+  webhook_configs:
+  - url: http://b.example.com/
+- name: a
+  webhook_configs:
+  - url: http://a.example.com/
+inhibit_rules:
+- target_match: {severity: info}
+  source_match: {severity: warning}
+- equal: [alertname]
+  source_match: {severity: critical}
+  target_match: {severity: warning}
+`,
+			equal: true,
+		},
+		{
+			// Reordered routes match alerts differently.
+			in: `
+global:
+  slack_api_url: https://hooks.slack.com/services/token
+route:
+  receiver: a
+  routes:
+  - match:
+      team: c
+    receiver: a
+  - match_re:
+      service: api|db
+    receiver: b
+receivers:
+- name: a
+  webhook_configs:
+  - url: http://a.example.com/
+- name: b
+  webhook_configs:
+  - url: http://b.example.com/
+`,
+			equal: false,
+		},
+		{
+			// Changed regular expression.
+			in:    strings.Replace(base, "api|db", "api|web", 1),
+			equal: false,
+		},
+		{
+			// Changed secret.
+			in:    strings.Replace(base, "services/token", "services/other", 1),
+			equal: false,
+		},
+		{
+			// Changed inhibit rule.
+			in:    strings.Replace(base, "target_match: {severity: info}", "target_match: {severity: none}", 1),
+			equal: false,
+		},
+	}
+
+	cfg := mustLoad(t, base)
+	if !cfg.Equal(mustLoad(t, base)) || !cfg.Equal(cfg.Clone()) {
+		t.Fatalf("Expected the same config to be equal")
+	}
+	for i, c := range cases {
+		if got := cfg.Equal(mustLoad(t, c.in)); got != c.equal {
+			t.Errorf("Case %d: expected Equal to return %t", i, c.equal)
+		}
+	}
+	if cfg.Equal(nil) || !(*Config)(nil).Equal(nil) {
+		t.Errorf("Expected only nil configs to equal nil")
+	}
+}
+
+func mustLoad(t *testing.T, in string) *Config {
+	cfg, err := Load(in)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	return cfg
+}
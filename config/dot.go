@@ -0,0 +1,107 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DOT returns the routing tree as a directed graph in the Graphviz DOT
+// language. Routes are boxes named by their path, receivers are ellipses
+// named by the receiver. Edges between routes are labeled with the
+// matchers of the child route, every route has an edge to the receiver
+// it notifies, which is dashed if the receiver is inherited.
+func (c *Config) DOT() string {
+	var buf bytes.Buffer
+	buf.WriteString("digraph routes {\n")
+	if c.Route != nil {
+		var edges []string
+		var walk func(r *Route, path, receiver string)
+		walk = func(r *Route, path, receiver string) {
+			label := []string{path}
+			if r.Continue {
+				label = append(label, "continue")
+			}
+			fmt.Fprintf(&buf, "  %s [shape=box, label=%s];\n", dotQuote(path), dotLabel(label))
+
+			style := ""
+			if r.Receiver != "" {
+				receiver = r.Receiver
+			} else {
+				style = " [style=dashed]"
+			}
+			edges = append(edges, fmt.Sprintf("  %s -> %s%s;\n", dotQuote(path), dotQuote("receiver/"+receiver), style))
+
+			for i, cr := range r.Routes {
+				cpath := fmt.Sprintf("%s.routes[%d]", path, i)
+				edges = append(edges, fmt.Sprintf("  %s -> %s [label=%s];\n", dotQuote(path), dotQuote(cpath), dotLabel(cr.matcherStrings())))
+				walk(cr, cpath, receiver)
+			}
+		}
+		walk(c.Route, "route", "")
+
+		for _, rcv := range c.Receivers {
+			fmt.Fprintf(&buf, "  %s [shape=ellipse, label=%s];\n", dotQuote("receiver/"+rcv.Name), dotQuote(rcv.Name))
+		}
+		for _, e := range edges {
+			buf.WriteString(e)
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// matcherStrings returns the matchers of the route itself as strings,
+// including the networks and annotations it matches.
+func (r *Route) matcherStrings() []string {
+	var ss []string
+	for _, m := range r.allMatchers() {
+		ss = append(ss, m.String())
+	}
+	var cidrs []string
+	for ln, nets := range r.MatchCIDR {
+		ns := make([]string, 0, len(nets))
+		for _, n := range nets {
+			ns = append(ns, n.String())
+		}
+		cidrs = append(cidrs, fmt.Sprintf("%s in %s", ln, strings.Join(ns, ",")))
+	}
+	sort.Strings(cidrs)
+	ss = append(ss, cidrs...)
+	for _, m := range labelMatchers(r.AnnotationMatch, r.AnnotationMatchRE, nil, nil) {
+		ss = append(ss, "annotation "+m.String())
+	}
+	return ss
+}
+
+// dotLabel returns the lines as a quoted DOT string.
+func dotLabel(lines []string) string {
+	quoted := make([]string, 0, len(lines))
+	for _, l := range lines {
+		q := dotQuote(l)
+		quoted = append(quoted, q[1:len(q)-1])
+	}
+	return `"` + strings.Join(quoted, `\n`) + `"`
+}
+
+// dotQuote returns s as a quoted DOT string.
+func dotQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+	return `"` + s + `"`
+}
@@ -0,0 +1,76 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+)
+
+func TestDOT(t *testing.T) {
+	cfg, err := Load(`
+route:
+  receiver: default
+  routes:
+  - receiver: team-a
+    match:
+      team: a
+    match_re:
+      service: api|db
+    continue: true
+    routes:
+    - matchers: ['severity="critical"']
+  - receiver: team-b
+    match_cidr:
+      instance: [10.0.0.0/8]
+
+receivers:
+- name: default
+  blackhole: true
+- name: team-a
+  blackhole: true
+- name: team-b
+  blackhole: true
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	want := `digraph routes {
+  "route" [shape=box, label="route"];
+  "route.routes[0]" [shape=box, label="route.routes[0]\ncontinue"];
+  "route.routes[0].routes[0]" [shape=box, label="route.routes[0].routes[0]"];
+  "route.routes[1]" [shape=box, label="route.routes[1]"];
+  "receiver/default" [shape=ellipse, label="default"];
+  "receiver/team-a" [shape=ellipse, label="team-a"];
+  "receiver/team-b" [shape=ellipse, label="team-b"];
+  "route" -> "receiver/default";
+  "route" -> "route.routes[0]" [label="service=~\"api|db\"\nteam=\"a\""];
+  "route.routes[0]" -> "receiver/team-a";
+  "route.routes[0]" -> "route.routes[0].routes[0]" [label="severity=\"critical\""];
+  "route.routes[0].routes[0]" -> "receiver/team-a" [style=dashed];
+  "route" -> "route.routes[1]" [label="instance in 10.0.0.0/8"];
+  "route.routes[1]" -> "receiver/team-b";
+}
+`
+	// The output must be stable across calls.
+	for i := 0; i < 3; i++ {
+		if got := cfg.DOT(); got != want {
+			t.Fatalf("Unexpected DOT output:\n%s\nexpected:\n%s", got, want)
+		}
+	}
+
+	if got, want := (&Config{}).DOT(), "digraph routes {\n}\n"; got != want {
+		t.Errorf("Expected empty graph %q, got %q", want, got)
+	}
+}
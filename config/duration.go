@@ -0,0 +1,81 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+var patDuration = regexp.MustCompile(`^([0-9]*\.?[0-9]+)([a-z]*)$`)
+
+// Duration is a model.Duration that additionally accepts weeks, as in
+// "2w", and milliseconds, as in "500ms". It is always marshaled in the
+// canonical form of model.Duration unless it is not a multiple of a
+// second.
+type Duration model.Duration
+
+// ParseDuration parses a duration of the form <integer><unit>, where the
+// unit is one of w, d, h, m, s and ms.
+func ParseDuration(s string) (Duration, error) {
+	m := patDuration.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("invalid duration %q: must be an integer followed by a unit", s)
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: fractional values are not supported", s)
+	}
+	switch m[2] {
+	case "w":
+		return Duration(time.Duration(n) * 7 * 24 * time.Hour), nil
+	case "ms":
+		return Duration(time.Duration(n) * time.Millisecond), nil
+	}
+	d, err := model.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: unit must be one of w, d, h, m, s and ms", s)
+	}
+	return Duration(d), nil
+}
+
+func (d Duration) String() string {
+	if time.Duration(d)%time.Second != 0 {
+		return fmt.Sprintf("%dms", time.Duration(d)/time.Millisecond)
+	}
+	return model.Duration(d).String()
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	dur, err := ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = dur
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	cases := []struct {
+		in  string
+		d   time.Duration
+		out string
+		err string
+	}{
+		{in: "30s", d: 30 * time.Second, out: "30s"},
+		{in: "90m", d: 90 * time.Minute, out: "90m"},
+		{in: "24h", d: 24 * time.Hour, out: "1d"},
+		{in: "1d", d: 24 * time.Hour, out: "1d"},
+		{in: "2w", d: 14 * 24 * time.Hour, out: "14d"},
+		{in: "500ms", d: 500 * time.Millisecond, out: "500ms"},
+		{in: "2000ms", d: 2 * time.Second, out: "2s"},
+		{in: "1.5d", err: `invalid duration "1.5d": fractional values are not supported`},
+		{in: "1d12h", err: `invalid duration "1d12h": must be an integer followed by a unit`},
+		{in: "5", err: `invalid duration "5": unit must be one of w, d, h, m, s and ms`},
+		{in: "5y", err: `invalid duration "5y": unit must be one of w, d, h, m, s and ms`},
+		{in: "-5m", err: `invalid duration "-5m": must be an integer followed by a unit`},
+	}
+
+	for _, c := range cases {
+		d, err := ParseDuration(c.in)
+		if c.err != "" {
+			if err == nil || err.Error() != c.err {
+				t.Errorf("%q: expected error %q, got %v", c.in, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", c.in, err)
+			continue
+		}
+		if time.Duration(d) != c.d {
+			t.Errorf("%q: expected %s, got %s", c.in, c.d, time.Duration(d))
+		}
+		if d.String() != c.out {
+			t.Errorf("%q: expected canonical form %q, got %q", c.in, c.out, d.String())
+		}
+	}
+}
+
+func TestDurationsInConfig(t *testing.T) {
+	cfg, err := Load(`
+global:
+  resolve_timeout: 1d
+route:
+  receiver: default
+  group_wait: 1m
+  group_interval: 1d
+  repeat_interval: 1w
+receivers:
+- name: default
+  blackhole: true
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	if time.Duration(cfg.Global.ResolveTimeout) != 24*time.Hour {
+		t.Errorf("Unexpected resolve timeout %s", cfg.Global.ResolveTimeout)
+	}
+	if time.Duration(*cfg.Route.RepeatInterval) != 7*24*time.Hour {
+		t.Errorf("Unexpected repeat interval %s", cfg.Route.RepeatInterval)
+	}
+
+	out, err := cfg.MarshalRedacted()
+	if err != nil {
+		t.Fatalf("Error marshaling config: %s", err)
+	}
+	for _, s := range []string{"resolve_timeout: 1d", "group_interval: 1d", "repeat_interval: 7d"} {
+		if !strings.Contains(string(out), s) {
+			t.Errorf("Expected %q in marshaled config:\n%s", s, out)
+		}
+	}
+
+	_, err = Load(`
+route:
+  receiver: default
+  group_interval: 1.5d
+receivers:
+- name: default
+  blackhole: true
+`)
+	if err == nil || err.Error() != `route.group_interval: invalid duration "1.5d": fractional values are not supported` {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
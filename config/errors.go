@@ -0,0 +1,199 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigError is an error in a configuration together with the location
+// it was found at.
+type ConfigError struct {
+	// Path is the path of the invalid section or field, such as
+	// route.routes[2].match. It is empty if the location is unknown.
+	Path string
+	// Line is the line of the input the error was found at. It is 0 if
+	// the line is unknown.
+	Line int
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+var patErrorLine = regexp.MustCompile(`\bline (\d+):`)
+
+// newConfigError returns a ConfigError for an error that occurred while
+// loading the YAML input s.
+func newConfigError(s string, err error) *ConfigError {
+	if ce, ok := err.(*ConfigError); ok {
+		return ce
+	}
+	ce := &ConfigError{Err: err}
+
+	// Syntax and type errors of the YAML parser carry their line, errors
+	// returned by the UnmarshalYAML methods have to be located.
+	if m := patErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		ce.Line, _ = strconv.Atoi(m[1])
+		return ce
+	}
+	// The document is decoded in order, as the first error depends on the
+	// order of the sections.
+	var doc yaml.MapSlice
+	if yaml.Unmarshal([]byte(s), &doc) == nil {
+		ce.Path, _ = locateError(doc, reflect.TypeOf(Config{}), "", err.Error())
+	}
+	return ce
+}
+
+// clearLine removes the line from err if it is a ConfigError. It is used
+// for errors in input that was rewritten before it was decoded, such as
+// JSON converted to YAML or YAML with expanded variables, as the line of
+// the rewritten input does not refer to the original one.
+func clearLine(err error) {
+	if ce, ok := err.(*ConfigError); ok {
+		ce.Line = 0
+	}
+}
+
+// locateError returns the path of the innermost section of the decoded
+// YAML node that fails to unmarshal into a value of type t with the given
+// error message. It returns false if the node unmarshals without that
+// error.
+func locateError(node interface{}, t reflect.Type, path, msg string) (string, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !failsWith(node, t, msg) {
+		return "", false
+	}
+
+	switch n := node.(type) {
+	case []interface{}:
+		if t.Kind() != reflect.Slice {
+			break
+		}
+		for i, e := range n {
+			if p, ok := locateError(e, t.Elem(), fmt.Sprintf("%s[%d]", path, i), msg); ok {
+				return p, true
+			}
+		}
+	case map[interface{}]interface{}:
+		if t.Kind() != reflect.Struct {
+			break
+		}
+		keys := make([]string, 0, len(n))
+		for k := range n {
+			if key, ok := k.(string); ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if p, ok := locateFieldError(key, n[key], t, path, msg); ok {
+				return p, true
+			}
+		}
+	case yaml.MapSlice:
+		if t.Kind() != reflect.Struct {
+			break
+		}
+		for _, item := range n {
+			key, ok := item.Key.(string)
+			if !ok {
+				continue
+			}
+			if p, ok := locateFieldError(key, item.Value, t, path, msg); ok {
+				return p, true
+			}
+		}
+	}
+	return path, true
+}
+
+// locateFieldError returns the path of the innermost section of the value
+// v of the field key of the struct type t at path that fails with the
+// given error message.
+func locateFieldError(key string, v interface{}, t reflect.Type, path, msg string) (string, bool) {
+	ft, ok := yamlFieldType(t, key)
+	if !ok {
+		return "", false
+	}
+	fpath := joinPath(path, key)
+	if p, ok := locateError(v, ft, fpath, msg); ok {
+		return p, true
+	}
+	// Errors of fields without nested sections are attributed to the
+	// field if it fails on its own.
+	if failsWith(yaml.MapSlice{{Key: key, Value: v}}, t, msg) {
+		return fpath, true
+	}
+	return "", false
+}
+
+// failsWith returns true if unmarshaling the node into a value of type t
+// fails with the given error message.
+func failsWith(node interface{}, t reflect.Type, msg string) bool {
+	b, err := yaml.Marshal(node)
+	if err != nil {
+		return false
+	}
+	err = yaml.Unmarshal(b, reflect.New(t).Interface())
+	return err != nil && err.Error() == msg
+}
+
+// yamlFieldType returns the type of the field of struct type t that is
+// decoded from the given YAML key.
+func yamlFieldType(t reflect.Type, key string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("yaml"), ",")
+		if len(tag) > 1 && tag[1] == "inline" {
+			if f.Type.Kind() == reflect.Struct {
+				if ft, ok := yamlFieldType(f.Type, key); ok {
+					return ft, true
+				}
+			}
+			continue
+		}
+		name := tag[0]
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		if name == key && f.PkgPath == "" {
+			return f.Type, true
+		}
+	}
+	return nil, false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
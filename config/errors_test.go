@@ -0,0 +1,191 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigErrorLocation(t *testing.T) {
+	cases := []struct {
+		in   string
+		path string
+		line int
+		err  string
+	}{
+		{
+			in: `
+route:
+  receiver: default
+  routes:
+  - receiver: default
+  - receiver: default
+    match:
+      "x y": foo
+
+receivers:
+- name: default
+  blackhole: true
+`,
+			path: "route.routes[1].match",
+			err:  `route.routes[1].match: invalid label name "x y"`,
+		},
+		{
+			in: `
+route:
+  receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+- name: team-X
+  email_configs:
+  - to: team-X@example.org
+    smarthost: localhost:25
+    from: am@example.org
+    headers:
+      subject: a
+      Subject: b
+`,
+			path: "receivers[1].email_configs[0]",
+			err:  `receivers[1].email_configs[0]: duplicate header "Subject" in email config`,
+		},
+		{
+			in: `
+route:
+  receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+- name: default
+  blackhole: true
+`,
+			path: "receivers",
+			err:  `receivers: notification config name "default" is not unique`,
+		},
+		{
+			in: `
+route:
+  receiver: default
+  group_wait: [10s]
+
+receivers:
+- name: default
+  blackhole: true
+`,
+			line: 4,
+		},
+		{
+			in: `
+route:
+  receiver: default
+  - foo
+`,
+			// The line as reported by the YAML parser.
+			line: 3,
+		},
+	}
+
+	for _, c := range cases {
+		_, err := Load(c.in)
+		ce, ok := err.(*ConfigError)
+		if !ok {
+			t.Errorf("Expected ConfigError, got %#v", err)
+			continue
+		}
+		if ce.Path != c.path || ce.Line != c.line {
+			t.Errorf("Expected error at path %q and line %d, got %q and %d (%s)", c.path, c.line, ce.Path, ce.Line, ce)
+		}
+		if c.err != "" && ce.Error() != c.err {
+			t.Errorf("Expected error %q, got %q", c.err, ce)
+		}
+	}
+}
+
+func TestConfigErrorRewrittenInput(t *testing.T) {
+	in := `
+route:
+  receiver: default
+  group_wait: [10s]
+
+receivers:
+- name: default
+  blackhole: true
+`
+	_, err := Load(in)
+	if ce, ok := err.(*ConfigError); !ok || ce.Line != 4 {
+		t.Fatalf("Expected ConfigError at line 4, got %#v", err)
+	}
+
+	dir := writeFiles(t, map[string]string{
+		"config.yml":   "include: [more.yml]\n" + in,
+		"more.yml":     "receivers:\n- name: other\n  blackhole: true\n",
+		"original.yml": in,
+	})
+	defer os.RemoveAll(dir)
+
+	// The lines of rewritten input do not refer to the original one.
+	for name, load := range map[string]func() (*Config, error){
+		"env": func() (*Config, error) { return LoadWithEnv(in) },
+		"merged receivers": func() (*Config, error) {
+			return LoadWithOptions(in+"- name: default\n  blackhole: true\n", LoadOptions{MergeDuplicateReceivers: true})
+		},
+		"include": func() (*Config, error) { return LoadFile(filepath.Join(dir, "config.yml")) },
+	} {
+		_, err := load()
+		ce, ok := err.(*ConfigError)
+		if !ok {
+			t.Errorf("%s: expected ConfigError, got %#v", name, err)
+			continue
+		}
+		if ce.Line != 0 {
+			t.Errorf("%s: expected unknown line, got %d (%s)", name, ce.Line, ce)
+		}
+	}
+
+	// Unchanged input keeps its lines.
+	_, err = LoadFile(filepath.Join(dir, "original.yml"))
+	if ce, ok := err.(*ConfigError); !ok || ce.Line != 4 {
+		t.Errorf("Expected ConfigError at line 4, got %#v", err)
+	}
+
+	// Errors found after decoding are ConfigErrors as well.
+	for name, c := range map[string]struct {
+		in   string
+		opts LoadOptions
+	}{
+		"limits": {
+			strings.Replace(in, "[10s]", "10s", 1) + "- name: other\n  blackhole: true\n",
+			LoadOptions{MaxReceivers: 1},
+		},
+		"merged receivers": {`
+route:
+  receiver: default
+receivers:
+- name: default
+  templates: [a.tmpl]
+- name: default
+  templates: [b.tmpl]
+`, LoadOptions{MergeDuplicateReceivers: true}},
+	} {
+		_, err := LoadWithOptions(c.in, c.opts)
+		if _, ok := err.(*ConfigError); !ok {
+			t.Errorf("%s: expected ConfigError, got %#v", name, err)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+// restrictedHeaders are the HTTP headers that are set by the HTTP client
+// or the notifier and cannot be configured.
+var restrictedHeaders = map[string]struct{}{
+	"Connection":        {},
+	"Content-Length":    {},
+	"Content-Type":      {},
+	"Host":              {},
+	"Keep-Alive":        {},
+	"Te":                {},
+	"Trailer":           {},
+	"Transfer-Encoding": {},
+	"Upgrade":           {},
+}
+
+// HTTPClientConfig configures the HTTP client used to send notifications.
+type HTTPClientConfig struct {
+	// The HTTP basic authentication credentials for the receiver.
+	BasicAuth *BasicAuth `yaml:"basic_auth,omitempty"`
+	// The bearer token for the receiver.
+	BearerToken Secret `yaml:"bearer_token,omitempty"`
+	// The TLS configuration for connections to the receiver.
+	TLSConfig TLSConfig `yaml:"tls_config,omitempty"`
+	// The URL of the proxy requests are sent through.
+	ProxyURL string `yaml:"proxy_url,omitempty"`
+	// Headers added to the requests. Values may be templates, which are
+	// executed for each notification. They are only sent by webhook
+	// notifiers so far.
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+
+	headerTemplates map[string]*InlineTemplate
+}
+
+// HeaderTemplate returns the parsed template of the value of a header. It
+// returns nil if the value is not a template or was changed after the
+// configuration was loaded.
+func (c *HTTPClientConfig) HeaderTemplate(name string) *InlineTemplate {
+	it, ok := c.headerTemplates[name]
+	if !ok || it.Text != c.Headers[name] {
+		return nil
+	}
+	return it
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *HTTPClientConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain HTTPClientConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.BasicAuth != nil && c.BearerToken != "" {
+		return fmt.Errorf("at most one of basic_auth and bearer_token must be configured")
+	}
+	if c.ProxyURL != "" {
+		if err := validateProxyURL(c.ProxyURL); err != nil {
+			return err
+		}
+	}
+	if err := c.parseHeaders(); err != nil {
+		return err
+	}
+	return checkOverflow(c.XXX, "http config", c)
+}
+
+// parseHeaders canonicalizes the names of the headers and parses the
+// values that are templates.
+func (c *HTTPClientConfig) parseHeaders() error {
+	if len(c.Headers) == 0 {
+		return nil
+	}
+	headers := make(map[string]string, len(c.Headers))
+	c.headerTemplates = map[string]*InlineTemplate{}
+	for h, v := range c.Headers {
+		name := textproto.CanonicalMIMEHeaderKey(h)
+		if _, ok := headers[name]; ok {
+			return fmt.Errorf("duplicate header %q in http config", name)
+		}
+		if _, ok := restrictedHeaders[name]; ok {
+			return fmt.Errorf("header %q cannot be set in http config", name)
+		}
+		if name == "Authorization" && (c.BasicAuth != nil || c.BearerToken != "") {
+			return fmt.Errorf("header %q cannot be set in http config together with basic_auth or bearer_token", name)
+		}
+		headers[name] = v
+		if !strings.Contains(v, "{{") {
+			continue
+		}
+		it := &InlineTemplate{Text: v}
+		if err := it.parse(); err != nil {
+			return fmt.Errorf("invalid template of header %q in http config: %s", name, err)
+		}
+		c.headerTemplates[name] = it
+	}
+	c.Headers = headers
+	return nil
+}
+
+func validateProxyURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL %q: %s", s, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q: scheme and host required", s)
+	}
+	return nil
+}
+
+// copy returns a copy of the configuration that can be modified without
+// affecting c. It returns nil if c is nil.
+func (c *HTTPClientConfig) copy() *HTTPClientConfig {
+	if c == nil {
+		return nil
+	}
+	cc := *c
+	if c.BasicAuth != nil {
+		ba := *c.BasicAuth
+		cc.BasicAuth = &ba
+	}
+	if c.Headers != nil {
+		cc.Headers = make(map[string]string, len(c.Headers))
+		for h, v := range c.Headers {
+			cc.Headers[h] = v
+		}
+	}
+	return &cc
+}
+
+// resolveFilepaths joins all relative paths in the configuration
+// using the given join function.
+func (c *HTTPClientConfig) resolveFilepaths(join func(string) string) {
+	if c == nil {
+		return
+	}
+	c.TLSConfig.CAFile = join(c.TLSConfig.CAFile)
+	c.TLSConfig.CertFile = join(c.TLSConfig.CertFile)
+	c.TLSConfig.KeyFile = join(c.TLSConfig.KeyFile)
+}
+
+// BasicAuth contains basic HTTP authentication credentials.
+type BasicAuth struct {
+	Username string `yaml:"username"`
+	Password Secret `yaml:"password,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (a *BasicAuth) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain BasicAuth
+	if err := unmarshal((*plain)(a)); err != nil {
+		return err
+	}
+	if a.Username == "" {
+		return fmt.Errorf("missing username in basic auth config")
+	}
+	return checkOverflow(a.XXX, "basic auth config", a)
+}
+
+// TLSConfig configures the options for TLS connections.
+type TLSConfig struct {
+	// The CA cert to use for the receiver.
+	CAFile string `yaml:"ca_file,omitempty"`
+	// The client cert file for the receiver.
+	CertFile string `yaml:"cert_file,omitempty"`
+	// The client key file for the receiver.
+	KeyFile string `yaml:"key_file,omitempty"`
+	// Disable validation of the server certificate.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *TLSConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain TLSConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("cert_file and key_file must be configured together")
+	}
+	return checkOverflow(c.XXX, "tls config", c)
+}
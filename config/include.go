@@ -0,0 +1,561 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// includeKeys are the top-level keys allowed in included files.
+var includeKeys = map[string]struct{}{
+	"global":        {},
+	"include":       {},
+	"receivers":     {},
+	"inhibit_rules": {},
+	"routes":        {},
+	"templates":     {},
+}
+
+// includer merges included config fragments into a base document.
+type includer struct {
+	// The files currently being included, used to detect cycles.
+	stack []string
+	// The file each receiver name was first defined in.
+	receivers map[string]string
+	// ignoreGlobal is set if global blocks of included files are
+	// ignored rather than rejected.
+	ignoreGlobal bool
+	// mergeReceivers is set if receivers defined in several files are
+	// merged when the config is loaded rather than rejected.
+	mergeReceivers bool
+	// The total size of all files read so far and the limit for it.
+	// A negative limit disables it.
+	size, maxSize int
+}
+
+// mergeIncludes merges all files included by the document parsed from
+// filename into it. Receivers and inhibit rules of included files are
+// appended to the respective lists, routes are appended to the children
+// of the root route.
+//
+// The global blocks of included files are merged into the global block of
+// the document field by field, so that they only override the fields they
+// set. Nested blocks like http_config are merged the same way, lists are
+// replaced as a whole. Fields are merged in the order the files are
+// included, so an included file overrides the file including it and files
+// matched by later patterns or later in the order of names override
+// earlier ones. Relative paths of *_file fields are resolved against the
+// directory of the file setting them.
+func mergeIncludes(filename string, doc yaml.MapSlice, size int, opts LoadOptions) (yaml.MapSlice, error) {
+	filename, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	inc := newIncluder(filename)
+	inc.size, inc.maxSize = size, limit(opts.MaxSize, DefaultMaxSize)
+	inc.mergeReceivers = opts.MergeDuplicateReceivers
+	if err := inc.includeBase(&doc, filename); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func newIncluder(filename string) *includer {
+	return &includer{
+		stack:     []string{filename},
+		receivers: map[string]string{},
+		maxSize:   -1,
+	}
+}
+
+// includeBase merges all files included by the base document doc parsed
+// from filename into it.
+func (inc *includer) includeBase(doc *yaml.MapSlice, filename string) error {
+	if err := inc.addReceivers(filename, *doc); err != nil {
+		return err
+	}
+	return inc.includeAll(doc, filename, *doc)
+}
+
+// includeAll merges all files matched by the include patterns of the
+// fragment read from filename into doc.
+func (inc *includer) includeAll(doc *yaml.MapSlice, filename string, frag yaml.MapSlice) error {
+	patterns, ok := mapValue(frag, "include").([]interface{})
+	if !ok && mapValue(frag, "include") != nil {
+		return fmt.Errorf("include in %s must be a list", filename)
+	}
+	for _, p := range patterns {
+		pattern, ok := p.(string)
+		if !ok {
+			return fmt.Errorf("invalid include pattern %v in %s", p, filename)
+		}
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(filename), pattern)
+		}
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid include pattern %q in %s: %s", pattern, filename, err)
+		}
+		for _, f := range files {
+			if err := inc.include(doc, f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// include merges the fragment file into doc.
+func (inc *includer) include(doc *yaml.MapSlice, filename string) error {
+	for _, f := range inc.stack {
+		if f == filename {
+			return fmt.Errorf("circular include of %s", filename)
+		}
+	}
+
+	content, err := inc.read(filename)
+	if err != nil {
+		return err
+	}
+	var frag yaml.MapSlice
+	if err := yaml.Unmarshal([]byte(content), &frag); err != nil {
+		return fmt.Errorf("parsing %s: %s", filename, err)
+	}
+
+	var unknown []string
+	for _, item := range frag {
+		k, _ := item.Key.(string)
+		if k == "global" && inc.ignoreGlobal {
+			continue
+		}
+		if _, ok := includeKeys[k]; !ok {
+			unknown = append(unknown, fmt.Sprint(item.Key))
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown fields in included file %s: %s", filename, strings.Join(unknown, ", "))
+	}
+
+	if err := inc.addReceivers(filename, frag); err != nil {
+		return err
+	}
+	if !inc.ignoreGlobal {
+		if err := mergeGlobal(doc, frag, filepath.Dir(filename)); err != nil {
+			return fmt.Errorf("%s: %s", filename, err)
+		}
+	}
+	if err := appendList(doc, "receivers", frag); err != nil {
+		return fmt.Errorf("%s: %s", filename, err)
+	}
+	if err := appendList(doc, "inhibit_rules", frag); err != nil {
+		return fmt.Errorf("%s: %s", filename, err)
+	}
+	if err := resolveTemplates(frag, filepath.Dir(filename)); err != nil {
+		return fmt.Errorf("%s: %s", filename, err)
+	}
+	rcvs, _ := mapValue(frag, "receivers").([]interface{})
+	for _, r := range rcvs {
+		if rcv, ok := r.(yaml.MapSlice); ok {
+			if err := resolveTemplates(rcv, filepath.Dir(filename)); err != nil {
+				return fmt.Errorf("%s: receiver %v: %s", filename, mapValue(rcv, "name"), err)
+			}
+		}
+	}
+	if err := appendList(doc, "templates", frag); err != nil {
+		return fmt.Errorf("%s: %s", filename, err)
+	}
+	if mapValue(frag, "routes") != nil {
+		route, ok := mapValue(*doc, "route").(yaml.MapSlice)
+		if !ok {
+			return fmt.Errorf("%s: cannot include routes without a root route", filename)
+		}
+		if err := appendList(&route, "routes", frag); err != nil {
+			return fmt.Errorf("%s: %s", filename, err)
+		}
+		setMapValue(doc, "route", route)
+	}
+
+	inc.stack = append(inc.stack, filename)
+	defer func() { inc.stack = inc.stack[:len(inc.stack)-1] }()
+
+	return inc.includeAll(doc, filename, frag)
+}
+
+// read returns the content of the included file filename. It returns an
+// error if the file makes the total size of all files read exceed the
+// limit.
+func (inc *includer) read(filename string) (string, error) {
+	content, err := readConfigFile(filename)
+	if err != nil {
+		return "", err
+	}
+	inc.size += len(content)
+	if inc.maxSize >= 0 && inc.size > inc.maxSize {
+		return "", fmt.Errorf("config size of %d bytes including %s exceeds the limit of %d bytes", inc.size, filename, inc.maxSize)
+	}
+	return content, nil
+}
+
+// addReceivers records the names of all receivers in the fragment read
+// from filename and returns an error if one was defined before.
+func (inc *includer) addReceivers(filename string, frag yaml.MapSlice) error {
+	rcvs, _ := mapValue(frag, "receivers").([]interface{})
+	for _, r := range rcvs {
+		rcv, ok := r.(yaml.MapSlice)
+		if !ok {
+			continue
+		}
+		name, _ := mapValue(rcv, "name").(string)
+		if prev, ok := inc.receivers[name]; ok && !inc.mergeReceivers {
+			return fmt.Errorf("receiver %q is defined in both %s and %s", name, prev, filename)
+		}
+		inc.receivers[name] = filename
+	}
+	return nil
+}
+
+// mergeDuplicateReceivers returns the YAML input s with the receivers of
+// the same name merged into the first of them. Their notifier
+// configurations are concatenated, all other fields must be equal. If no
+// name is used twice, s is returned unchanged.
+func mergeDuplicateReceivers(s string) (string, error) {
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal([]byte(s), &doc); err != nil {
+		// The error is reported when the input is loaded.
+		return s, nil
+	}
+	rcvs, _ := mapValue(doc, "receivers").([]interface{})
+	var (
+		merged  = make([]interface{}, 0, len(rcvs))
+		indexes = map[string]int{}
+	)
+	for _, r := range rcvs {
+		rcv, ok := r.(yaml.MapSlice)
+		if !ok {
+			merged = append(merged, r)
+			continue
+		}
+		name, _ := mapValue(rcv, "name").(string)
+		i, ok := indexes[name]
+		if !ok {
+			indexes[name] = len(merged)
+			merged = append(merged, rcv)
+			continue
+		}
+		m, err := mergeReceiver(merged[i].(yaml.MapSlice), rcv)
+		if err != nil {
+			return "", fmt.Errorf("cannot merge receivers named %q: %s", name, err)
+		}
+		merged[i] = m
+	}
+	if len(merged) == len(rcvs) {
+		return s, nil
+	}
+	setMapValue(&doc, "receivers", merged)
+	b, err := yaml.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// mergeReceiver returns the receiver dst with the notifier configurations
+// of src appended to its own. All other fields of src must be unset in
+// dst or equal to those of dst.
+func mergeReceiver(dst, src yaml.MapSlice) (yaml.MapSlice, error) {
+	m := append(yaml.MapSlice(nil), dst...)
+	for _, item := range src {
+		key, _ := item.Key.(string)
+		if strings.HasSuffix(key, "_configs") {
+			if err := appendList(&m, key, src); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		v := mapValue(m, key)
+		if v == nil {
+			setMapValue(&m, key, item.Value)
+		} else if !reflect.DeepEqual(v, item.Value) {
+			return nil, fmt.Errorf("conflicting values of %s", key)
+		}
+	}
+	return m, nil
+}
+
+// resolveTemplates joins the relative template paths of the fragment, or
+// of a receiver in it, with the directory of its file, as they are merged
+// into a document read from another directory.
+func resolveTemplates(frag yaml.MapSlice, dir string) error {
+	v := mapValue(frag, "templates")
+	if v == nil {
+		return nil
+	}
+	tmpls, ok := v.([]interface{})
+	if !ok {
+		return fmt.Errorf("templates must be a list")
+	}
+	for i, t := range tmpls {
+		if p, ok := t.(string); ok && p != "" && !filepath.IsAbs(p) {
+			tmpls[i] = filepath.Join(dir, p)
+		}
+	}
+	return nil
+}
+
+// mergeGlobal merges the global block of the fragment read from a file in
+// dir into the one of doc.
+func mergeGlobal(doc *yaml.MapSlice, frag yaml.MapSlice, dir string) error {
+	v := mapValue(frag, "global")
+	if v == nil {
+		return nil
+	}
+	global, ok := v.(yaml.MapSlice)
+	if !ok {
+		return fmt.Errorf("global must be a map")
+	}
+	resolveFileFields(global, dir)
+	base, _ := mapValue(*doc, "global").(yaml.MapSlice)
+	setMapValue(doc, "global", mergeMaps(base, global))
+	return nil
+}
+
+// mergeMaps returns dst with the fields of src merged into it. Fields set
+// in both are taken from src unless both are maps, which are merged.
+func mergeMaps(dst, src yaml.MapSlice) yaml.MapSlice {
+	merged := append(yaml.MapSlice(nil), dst...)
+	for _, item := range src {
+		key, ok := item.Key.(string)
+		if !ok {
+			merged = append(merged, item)
+			continue
+		}
+		sm, sok := item.Value.(yaml.MapSlice)
+		dm, dok := mapValue(merged, key).(yaml.MapSlice)
+		if sok && dok {
+			setMapValue(&merged, key, mergeMaps(dm, sm))
+			continue
+		}
+		setMapValue(&merged, key, item.Value)
+	}
+	return merged
+}
+
+// resolveFileFields joins the relative paths of all *_file fields of m,
+// including those of nested maps, with dir.
+func resolveFileFields(m yaml.MapSlice, dir string) {
+	for i, item := range m {
+		switch v := item.Value.(type) {
+		case yaml.MapSlice:
+			resolveFileFields(v, dir)
+		case string:
+			key, _ := item.Key.(string)
+			if strings.HasSuffix(key, "_file") && v != "" && !filepath.IsAbs(v) {
+				m[i].Value = filepath.Join(dir, v)
+			}
+		}
+	}
+}
+
+// appendList appends the list stored under key in src to the one in dst.
+func appendList(dst *yaml.MapSlice, key string, src yaml.MapSlice) error {
+	v := mapValue(src, key)
+	if v == nil {
+		return nil
+	}
+	add, ok := v.([]interface{})
+	if !ok {
+		return fmt.Errorf("%s must be a list", key)
+	}
+	list, _ := mapValue(*dst, key).([]interface{})
+	setMapValue(dst, key, append(list, add...))
+	return nil
+}
+
+// mapValue returns the value stored under key in m or nil.
+func mapValue(m yaml.MapSlice, key string) interface{} {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+// setMapValue stores v under key in m, appending the key if necessary.
+func setMapValue(m *yaml.MapSlice, key string, v interface{}) {
+	for i, item := range *m {
+		if item.Key == key {
+			(*m)[i].Value = v
+			return
+		}
+	}
+	*m = append(*m, yaml.MapItem{Key: key, Value: v})
+}
+
+// expandIncludes returns the content s of the config file filename with
+// all included files merged into it. If nothing is included, s is returned
+// unchanged. The size of the included files is limited as configured by
+// opts.
+func expandIncludes(filename, s string, opts LoadOptions) (string, error) {
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal([]byte(s), &doc); err != nil {
+		return "", err
+	}
+	if mapValue(doc, "include") == nil {
+		return s, nil
+	}
+	doc, err := mergeIncludes(filename, doc, len(s), opts)
+	if err != nil {
+		return "", err
+	}
+	b, err := yaml.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// LoadGlob loads the config from all files matching the glob pattern. The
+// one file containing the global block is loaded like by LoadFile, the
+// receivers, inhibit rules and child routes of the other files are merged
+// into it like included files in order of their names. Receiver names must
+// be unique across all files.
+func LoadGlob(pattern string) (*Config, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config pattern %q: %s", pattern, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("config pattern %q does not match any files", pattern)
+	}
+	sort.Strings(files)
+
+	docs, err := readConfigDocs(files)
+	if err != nil {
+		return nil, err
+	}
+	base := -1
+	for i, doc := range docs {
+		if mapValue(doc, "global") == nil {
+			continue
+		}
+		if base >= 0 {
+			return nil, fmt.Errorf("global block is defined in both %s and %s", files[base], files[i])
+		}
+		base = i
+	}
+	if base < 0 {
+		return nil, fmt.Errorf("no file matching %q defines the global block", pattern)
+	}
+	return loadMerged(files, docs, base, newIncluder(files[base]))
+}
+
+// LoadDir loads the config from all .yml files in the directory dir in
+// order of their names. The global block is taken from the first file
+// that defines one, global blocks of later files are ignored. That file
+// is loaded like by LoadFile, the receivers, inhibit rules, child routes
+// and templates of the other files are merged into it like included
+// files. Relative template paths are resolved against the directory of
+// the file they are defined in. Receiver names must be unique across all
+// files.
+func LoadDir(dir string) (*Config, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, fi := range infos {
+		if fi.IsDir() || filepath.Ext(fi.Name()) != ".yml" {
+			continue
+		}
+		fn, err := filepath.Abs(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, fn)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .yml config files in %s", dir)
+	}
+
+	docs, err := readConfigDocs(files)
+	if err != nil {
+		return nil, err
+	}
+	base := -1
+	for i, doc := range docs {
+		if mapValue(doc, "global") != nil {
+			base = i
+			break
+		}
+	}
+	if base < 0 {
+		return nil, fmt.Errorf("no config file in %s defines the global block", dir)
+	}
+	inc := newIncluder(files[base])
+	inc.ignoreGlobal = true
+	return loadMerged(files, docs, base, inc)
+}
+
+// readConfigDocs reads and parses the given config files, replacing their
+// names with absolute paths.
+func readConfigDocs(files []string) ([]yaml.MapSlice, error) {
+	docs := make([]yaml.MapSlice, 0, len(files))
+	for i, f := range files {
+		var err error
+		if files[i], err = filepath.Abs(f); err != nil {
+			return nil, err
+		}
+		content, err := readConfigFile(files[i])
+		if err != nil {
+			return nil, err
+		}
+		var doc yaml.MapSlice
+		if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+			return nil, fmt.Errorf("parsing %s: %s", files[i], err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// loadMerged loads the config from the base document docs[base] with all
+// other files merged into it by inc.
+func loadMerged(files []string, docs []yaml.MapSlice, base int, inc *includer) (*Config, error) {
+	doc := docs[base]
+	if err := inc.includeBase(&doc, files[base]); err != nil {
+		return nil, err
+	}
+	for i, f := range files {
+		if i == base {
+			continue
+		}
+		if err := inc.include(&doc, f); err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return loadResolved(files[base], string(b), LoadOptions{})
+}
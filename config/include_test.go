@@ -0,0 +1,682 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFiles writes the given files relative to a new temporary directory
+// and returns the directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "am_config_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		fn := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fn, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadFileInclude(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yml": `
+include:
+- receivers.d/*.yml
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+`,
+		"receivers.d/a.yml": `
+routes:
+- match:
+    team: a
+  receiver: team-a
+
+receivers:
+- name: team-a
+  webhook_configs:
+  - url: http://team-a.example.com/
+`,
+		"receivers.d/b.yml": `
+include:
+- ../more/*.yml
+
+receivers:
+- name: team-b
+  webhook_configs:
+  - url: http://team-b.example.com/
+
+inhibit_rules:
+- source_match:
+    severity: critical
+  target_match:
+    severity: warning
+`,
+		"more/c.yml": `
+receivers:
+- name: team-c
+  blackhole: true
+  templates: [team-c.tmpl]
+`,
+		"more/team-c.tmpl": "",
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := LoadFile(filepath.Join(dir, "config.yml"))
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	var names []string
+	for _, rcv := range cfg.Receivers {
+		names = append(names, rcv.Name)
+	}
+	if got, want := strings.Join(names, ","), "default,team-a,team-b,team-c"; got != want {
+		t.Errorf("Expected receivers %q, got %q", want, got)
+	}
+	if len(cfg.Route.Routes) != 1 || cfg.Route.Routes[0].Receiver != "team-a" {
+		t.Errorf("Expected included child route for team-a, got %v", cfg.Route.Routes)
+	}
+	if len(cfg.InhibitRules) != 1 {
+		t.Errorf("Expected 1 included inhibit rule, got %d", len(cfg.InhibitRules))
+	}
+	if got, want := cfg.Include[0], filepath.Join(dir, "receivers.d/*.yml"); got != want {
+		t.Errorf("Expected resolved include pattern %q, got %q", want, got)
+	}
+	if got, want := cfg.Receivers[3].Templates, []string{filepath.Join(dir, "more", "team-c.tmpl")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected receiver templates resolved against the included file, got %v", got)
+	}
+}
+
+func TestLoadFileIncludeErrors(t *testing.T) {
+	cases := []struct {
+		files map[string]string
+		err   string
+	}{
+		{
+			files: map[string]string{
+				"config.yml": `
+include: [a.yml]
+route:
+  receiver: default
+receivers:
+- name: default
+  blackhole: true
+`,
+				"a.yml": `
+receivers:
+- name: default
+  blackhole: true
+`,
+			},
+			err: `receiver "default" is defined in both`,
+		},
+		{
+			files: map[string]string{
+				"config.yml": `
+include: [a.yml]
+route:
+  receiver: default
+receivers:
+- name: default
+  blackhole: true
+`,
+				"a.yml": `
+include: [b.yml]
+`,
+				"b.yml": `
+include: [a.yml]
+`,
+			},
+			err: "circular include of",
+		},
+		{
+			files: map[string]string{
+				"config.yml": `
+include: [a.yml]
+route:
+  receiver: default
+receivers:
+- name: default
+  blackhole: true
+`,
+				"a.yml": `
+route:
+  receiver: default
+`,
+			},
+			err: "unknown fields in included file",
+		},
+		{
+			files: map[string]string{
+				"config.yml": `
+include: [a.yml]
+route:
+  receiver: default
+receivers:
+- name: default
+  blackhole: true
+`,
+				"a.yml": `
+global: [resolve_timeout]
+`,
+			},
+			err: "global must be a map",
+		},
+	}
+
+	for _, c := range cases {
+		dir := writeFiles(t, c.files)
+		defer os.RemoveAll(dir)
+
+		_, err := LoadFile(filepath.Join(dir, "config.yml"))
+		if err == nil {
+			t.Errorf("Expected error containing %q, got none", c.err)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.err) {
+			t.Errorf("Expected error containing %q, got %q", c.err, err)
+		}
+	}
+}
+
+func TestLoadFileIncludeGlobal(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yml": `
+include: [teams/*.yml]
+
+global:
+  resolve_timeout: 10m
+  smtp_smarthost: mail.example.org:25
+  smtp_from: alertmanager@example.org
+  http_config:
+    bearer_token: token
+    proxy_url: http://proxy.example.com:3128
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+`,
+		"teams/a.yml": `
+global:
+  resolve_timeout: 15m
+  http_config:
+    proxy_url: http://proxy-a.example.com:3128
+`,
+		"teams/b.yml": `
+global:
+  smtp_from: team-b@example.org
+  slack_api_url_file: slack.url
+`,
+		"teams/slack.url": "https://hooks.slack.com/services/secret",
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := LoadFile(filepath.Join(dir, "config.yml"))
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	g := cfg.Global
+	if g.ResolveTimeout != Duration(15*time.Minute) {
+		t.Errorf("Expected overridden resolve_timeout, got %s", g.ResolveTimeout)
+	}
+	if g.SMTPSmarthost.String() != "mail.example.org:25" {
+		t.Errorf("Expected smarthost of the base config, got %q", g.SMTPSmarthost)
+	}
+	if g.SMTPFrom != "team-b@example.org" {
+		t.Errorf("Expected smtp_from of the later file, got %q", g.SMTPFrom)
+	}
+	if g.HTTPConfig == nil || g.HTTPConfig.BearerToken != "token" || g.HTTPConfig.ProxyURL != "http://proxy-a.example.com:3128" {
+		t.Errorf("Expected merged HTTP config, got %+v", g.HTTPConfig)
+	}
+	if got, want := g.SlackAPIURLFile, filepath.Join(dir, "teams", "slack.url"); got != want {
+		t.Errorf("Expected slack_api_url_file resolved against the included file %q, got %q", want, got)
+	}
+	if g.SlackAPIURL == nil || g.SlackAPIURL.URL.String() != "https://hooks.slack.com/services/secret" {
+		t.Errorf("Expected Slack API URL read from the included file")
+	}
+}
+
+func TestLoadGlob(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"teams/b.yml": `
+routes:
+- match:
+    team: b
+  receiver: team-b
+
+receivers:
+- name: team-b
+  webhook_configs:
+  - url: http://team-b.example.com/
+`,
+		"teams/a.yml": `
+routes:
+- match:
+    team: a
+  receiver: team-a
+
+receivers:
+- name: team-a
+  blackhole: true
+
+inhibit_rules:
+- source_match:
+    severity: critical
+  target_match:
+    severity: warning
+`,
+		"teams/base.yml": `
+global:
+  resolve_timeout: 1m
+
+include:
+- ../shared/*.yml
+
+templates:
+- ../templates/*.tmpl
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+`,
+		"shared/c.yml": `
+receivers:
+- name: shared
+  blackhole: true
+`,
+		"templates/default.tmpl": ``,
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := LoadGlob(filepath.Join(dir, "teams", "*.yml"))
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	var names []string
+	for _, rcv := range cfg.Receivers {
+		names = append(names, rcv.Name)
+	}
+	if got, want := strings.Join(names, ","), "default,shared,team-a,team-b"; got != want {
+		t.Errorf("Expected receivers %q, got %q", want, got)
+	}
+	var routes []string
+	for _, r := range cfg.Route.Routes {
+		routes = append(routes, r.Receiver)
+	}
+	if got, want := strings.Join(routes, ","), "team-a,team-b"; got != want {
+		t.Errorf("Expected child routes in file order %q, got %q", want, got)
+	}
+	if len(cfg.InhibitRules) != 1 {
+		t.Errorf("Expected 1 inhibit rule, got %d", len(cfg.InhibitRules))
+	}
+	if got, want := cfg.Templates[0], filepath.Join(dir, "templates", "*.tmpl"); got != want {
+		t.Errorf("Expected template path resolved against the base file %q, got %q", want, got)
+	}
+}
+
+func TestLoadGlobErrors(t *testing.T) {
+	base := `
+global:
+  resolve_timeout: 1m
+route:
+  receiver: default
+receivers:
+- name: default
+  blackhole: true
+`
+	cases := []struct {
+		files map[string]string
+		err   string
+	}{
+		{
+			files: map[string]string{
+				"a.yml": base,
+				"b.yml": base,
+			},
+			err: "global block is defined in both",
+		},
+		{
+			files: map[string]string{
+				"a.yml": `
+receivers:
+- name: team-a
+  blackhole: true
+`,
+			},
+			err: "defines the global block",
+		},
+		{
+			files: map[string]string{
+				"a.yml": base,
+				"b.yml": `
+receivers:
+- name: team-b
+  blackhole: true
+`,
+				"c.yml": `
+receivers:
+- name: team-b
+  blackhole: true
+`,
+			},
+			err: `receiver "team-b" is defined in both`,
+		},
+		{
+			files: map[string]string{
+				"a.yml": `
+include: [shared/*.yml]
+` + base,
+				"b.yml": `
+receivers:
+- name: shared
+  blackhole: true
+`,
+				"shared/s.yml": `
+receivers:
+- name: shared
+  blackhole: true
+`,
+			},
+			err: `receiver "shared" is defined in both`,
+		},
+		{
+			files: map[string]string{
+				"a.yml": base,
+				"b.yml": `
+route:
+  receiver: default
+`,
+			},
+			err: "unknown fields in included file",
+		},
+		{
+			files: map[string]string{
+				"a.txt": base,
+			},
+			err: "does not match any files",
+		},
+	}
+
+	for _, c := range cases {
+		dir := writeFiles(t, c.files)
+		defer os.RemoveAll(dir)
+
+		_, err := LoadGlob(filepath.Join(dir, "*.yml"))
+		if err == nil {
+			t.Errorf("Expected error containing %q, got none", c.err)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.err) {
+			t.Errorf("Expected error containing %q, got %q", c.err, err)
+		}
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"conf.d/10-base.yml": `
+global:
+  resolve_timeout: 1m
+
+templates:
+- templates/*.tmpl
+
+route:
+  receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+`,
+		"conf.d/20-team-a.yml": `
+# Global blocks of later files are ignored.
+global:
+  resolve_timeout: 5m
+
+include:
+- teams/*.yml
+
+routes:
+- match:
+    team: a
+  receiver: team-a
+
+receivers:
+- name: team-a
+  blackhole: true
+`,
+		"conf.d/05-shared.yml": `
+receivers:
+- name: shared
+  blackhole: true
+`,
+		"conf.d/teams/b.yml": `
+templates:
+- b.tmpl
+
+routes:
+- match:
+    team: b
+  receiver: team-b
+
+receivers:
+- name: team-b
+  blackhole: true
+`,
+		"conf.d/README.md":              `Not a config file.`,
+		"conf.d/templates/default.tmpl": ``,
+		"conf.d/teams/b.tmpl":           ``,
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := LoadDir(filepath.Join(dir, "conf.d"))
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	if got := time.Duration(cfg.Global.ResolveTimeout); got != time.Minute {
+		t.Errorf("Expected resolve timeout of the first global block, got %s", got)
+	}
+	var names []string
+	for _, rcv := range cfg.Receivers {
+		names = append(names, rcv.Name)
+	}
+	if got, want := strings.Join(names, ","), "default,shared,team-a,team-b"; got != want {
+		t.Errorf("Expected receivers %q, got %q", want, got)
+	}
+	var routes []string
+	for _, r := range cfg.Route.Routes {
+		routes = append(routes, r.Receiver)
+	}
+	if got, want := strings.Join(routes, ","), "team-a,team-b"; got != want {
+		t.Errorf("Expected child routes in file order %q, got %q", want, got)
+	}
+	expected := []string{
+		filepath.Join(dir, "conf.d", "templates", "*.tmpl"),
+		filepath.Join(dir, "conf.d", "teams", "b.tmpl"),
+	}
+	if !reflect.DeepEqual(cfg.Templates, expected) {
+		t.Errorf("Expected templates resolved against their files %v, got %v", expected, cfg.Templates)
+	}
+}
+
+func TestLoadDirErrors(t *testing.T) {
+	base := `
+global:
+  resolve_timeout: 1m
+route:
+  receiver: default
+receivers:
+- name: default
+  blackhole: true
+`
+	cases := []struct {
+		files map[string]string
+		err   string
+	}{
+		{
+			files: map[string]string{
+				"a.yml": base,
+				"b.yml": `
+receivers:
+- name: team-b
+  blackhole: true
+`,
+				"c.yml": `
+receivers:
+- name: team-b
+  blackhole: true
+`,
+			},
+			err: `receiver "team-b" is defined in both %[1]s/b.yml and %[1]s/c.yml`,
+		},
+		{
+			files: map[string]string{
+				"a.yml": `
+receivers:
+- name: team-a
+  blackhole: true
+`,
+			},
+			err: "no config file in %s defines the global block",
+		},
+		{
+			files: map[string]string{
+				"a.yaml": base,
+			},
+			err: "no .yml config files in %s",
+		},
+	}
+
+	for _, c := range cases {
+		dir := writeFiles(t, c.files)
+		defer os.RemoveAll(dir)
+
+		_, err := LoadDir(dir)
+		if err == nil {
+			t.Errorf("Expected error, got none")
+			continue
+		}
+		if want := fmt.Sprintf(c.err, dir); err.Error() != want {
+			t.Errorf("Expected error %q, got %q", want, err)
+		}
+	}
+}
+
+func TestLoadFileIncludeMaxSize(t *testing.T) {
+	base := `
+include: [a.yml, b.yml]
+route:
+  receiver: default
+receivers:
+- name: default
+  blackhole: true
+`
+	a := "# " + strings.Repeat("a", 100) + "\n"
+	// b.yml is invalid YAML, so any error but the size limit shows that
+	// it was parsed.
+	b := "receivers: [" + strings.Repeat("b", 100)
+	dir := writeFiles(t, map[string]string{
+		"config.yml": base,
+		"a.yml":      a,
+		"b.yml":      b,
+	})
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "config.yml")
+
+	cases := []struct {
+		maxSize int
+		err     string
+	}{
+		{
+			maxSize: len(base) - 1,
+			err:     fmt.Sprintf("config size of %d bytes exceeds the limit of %d bytes", len(base), len(base)-1),
+		},
+		{
+			maxSize: len(base) + len(a) - 1,
+			err:     fmt.Sprintf("config size of %d bytes including %s exceeds the limit of %d bytes", len(base)+len(a), filepath.Join(dir, "a.yml"), len(base)+len(a)-1),
+		},
+		{
+			maxSize: len(base) + len(a) + len(b) - 1,
+			err:     fmt.Sprintf("config size of %d bytes including %s exceeds the limit of %d bytes", len(base)+len(a)+len(b), filepath.Join(dir, "b.yml"), len(base)+len(a)+len(b)-1),
+		},
+	}
+	for _, c := range cases {
+		_, err := LoadFileWithOptions(filename, LoadOptions{MaxSize: c.maxSize})
+		if err == nil || err.Error() != c.err {
+			t.Errorf("Expected error %q with max size %d, got %v", c.err, c.maxSize, err)
+		}
+	}
+}
+
+func TestLoadFileIncludeMergeDuplicateReceivers(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yml": `
+include: [a.yml]
+route:
+  receiver: default
+receivers:
+- name: default
+  webhook_configs:
+  - url: http://example.com/a
+`,
+		"a.yml": `
+receivers:
+- name: default
+  webhook_configs:
+  - url: http://example.com/b
+`,
+	})
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "config.yml")
+
+	if _, err := LoadFile(filename); err == nil || !strings.Contains(err.Error(), `receiver "default" is defined in both`) {
+		t.Errorf("Expected error for duplicate receiver, got %v", err)
+	}
+	cfg, err := LoadFileWithOptions(filename, LoadOptions{MergeDuplicateReceivers: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(cfg.Receivers) != 1 || len(cfg.Receivers[0].WebhookConfigs) != 2 {
+		t.Errorf("Expected one receiver with both webhooks, got %v", cfg.Receivers)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+
+	amtemplate "github.com/prometheus/alertmanager/template"
+)
+
+// InlineTemplate is a notification template given in the configuration
+// rather than in a template file. It is parsed when its receiver is
+// loaded, so that syntax errors are reported by Load instead of when
+// notifying.
+type InlineTemplate struct {
+	// Text is the source of the template.
+	Text string
+
+	tmpl *template.Template
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (t *InlineTemplate) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*t = InlineTemplate{}
+	return unmarshal(&t.Text)
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (t *InlineTemplate) MarshalYAML() (interface{}, error) {
+	if t == nil {
+		return nil, nil
+	}
+	return t.Text, nil
+}
+
+// Template returns the parsed template. Templates of the notification
+// template files can only be referenced once it is added to them.
+func (t *InlineTemplate) Template() *template.Template {
+	return t.tmpl
+}
+
+// parse parses the template with the functions available to notification
+// templates.
+func (t *InlineTemplate) parse() error {
+	tmpl, err := parseTemplate("", t.Text)
+	if err != nil {
+		return err
+	}
+	t.tmpl = tmpl
+	return nil
+}
+
+// parseTemplate parses text as a template with the given name and the
+// functions available to notification templates, which are those of the
+// template package, so that calls of undefined functions are reported.
+// Templates defined elsewhere may be referenced, they are only looked up
+// when the template is executed.
+func parseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).
+		Option("missingkey=zero").
+		Funcs(template.FuncMap(amtemplate.DefaultFuncs)).
+		Parse(text)
+}
+
+// parseInlineTemplates parses the inline templates of all notifier
+// configurations of the receiver.
+func (c *Receiver) parseInlineTemplates() error {
+	parse := func(key string, i int, field string, t *InlineTemplate) error {
+		if t == nil {
+			return nil
+		}
+		if err := t.parse(); err != nil {
+			return fmt.Errorf("invalid %s[%d].%s in receiver %q: %s", key, i, field, c.Name, err)
+		}
+		return nil
+	}
+	for i, sc := range c.SlackConfigs {
+		if err := parse("slack_configs", i, "title_template", sc.TitleTemplate); err != nil {
+			return err
+		}
+		if err := parse("slack_configs", i, "text_template", sc.TextTemplate); err != nil {
+			return err
+		}
+	}
+	for i, pdc := range c.PagerdutyConfigs {
+		if err := parse("pagerduty_configs", i, "text_template", pdc.TextTemplate); err != nil {
+			return err
+		}
+	}
+	for i, wc := range c.WebhookConfigs {
+		if err := parse("webhook_configs", i, "text_template", wc.TextTemplate); err != nil {
+			return err
+		}
+	}
+	for i, kc := range c.KafkaConfigs {
+		if err := parse("kafka_configs", i, "key_template", kc.KeyTemplate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTemplateStrings parses the string options of all notifier
+// configurations of the receiver that contain templates, such as the
+// titles and texts of messages, which are executed when notifying.
+func (c *Receiver) checkTemplateStrings() error {
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		key := strings.Split(v.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if !strings.HasSuffix(key, "_configs") {
+			continue
+		}
+		for j := 0; j < v.Field(i).Len(); j++ {
+			nc := v.Field(i).Index(j).Elem()
+			for k := 0; k < nc.NumField(); k++ {
+				f := nc.Type().Field(k)
+				field := strings.Split(f.Tag.Get("yaml"), ",")[0]
+				var texts []string
+				switch fv := nc.Field(k); {
+				case f.PkgPath != "":
+				case fv.Type() == reflect.TypeOf(""):
+					texts = []string{fv.String()}
+				case fv.Type() == reflect.TypeOf(map[string]string{}):
+					for _, mk := range fv.MapKeys() {
+						texts = append(texts, fv.MapIndex(mk).String())
+					}
+				}
+				for _, text := range texts {
+					if !strings.Contains(text, "{{") {
+						continue
+					}
+					if _, err := parseTemplate("", text); err != nil {
+						return fmt.Errorf("invalid template in %s[%d].%s of receiver %q: %s", key, j, field, c.Name, err)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
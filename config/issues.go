@@ -0,0 +1,417 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The codes of lint issues for errors. Like the codes of lint warnings,
+// they do not change.
+const (
+	LintSyntax                = "syntax"
+	LintInvalidType           = "invalid-type"
+	LintUnknownField          = "unknown-field"
+	LintInvalidLabelName      = "invalid-label-name"
+	LintUndefinedReceiver     = "undefined-receiver"
+	LintDuplicateReceiver     = "duplicate-receiver"
+	LintMissingIntegrations   = "missing-integrations"
+	LintUndefinedTimeInterval = "undefined-time-interval"
+	LintInvalid               = "invalid"
+)
+
+// LintSeverity is the severity of a lint issue.
+type LintSeverity string
+
+// The severities of lint issues. Configurations with errors cannot be
+// loaded, warnings are reported by Config.Lint.
+const (
+	LintSeverityError   LintSeverity = "error"
+	LintSeverityWarning LintSeverity = "warning"
+)
+
+// maxLintIssues is the maximum number of errors Lint reports.
+const maxLintIssues = 100
+
+// LintIssue is a problem found by Lint.
+type LintIssue struct {
+	// Severity is error for problems that prevent loading the
+	// configuration and warning for those reported by Config.Lint.
+	Severity LintSeverity
+	// Code identifies the kind of problem, such as unknown-field.
+	Code string
+	// Path is the path of the section the issue refers to, such as
+	// route.routes[2]. It is empty if the location is unknown.
+	Path string
+	// Line is the line of the input the issue was found at. It is 0 if
+	// the line is unknown.
+	Line int
+	// Message describes the problem.
+	Message string
+}
+
+func (i LintIssue) String() string {
+	loc := i.Path
+	if i.Line > 0 {
+		loc = fmt.Sprintf("line %d", i.Line)
+	}
+	if loc == "" {
+		return fmt.Sprintf("%s: %s [%s]", i.Severity, i.Message, i.Code)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", i.Severity, loc, i.Message, i.Code)
+}
+
+// Lint returns all problems of the configuration s. Unlike Load, which
+// stops at the first error, it removes each invalid section it finds and
+// checks the remaining configuration again, so that errors are reported
+// together. Once the remaining configuration is valid, the warnings of
+// Config.Lint are added. Errors found after the first one may be caused
+// by the removal of sections, and their lines are unknown as the
+// configuration is checked in a normalized form. Lint returns an error
+// only if the configuration could not be checked at all.
+func Lint(s string) ([]LintIssue, error) {
+	var (
+		doc    yaml.MapSlice
+		issues []LintIssue
+	)
+	if err := yaml.Unmarshal([]byte(s), &doc); err != nil {
+		ce := newConfigError(s, err)
+		return []LintIssue{{Severity: LintSeverityError, Code: LintSyntax, Line: ce.Line, Message: ce.Err.Error()}}, nil
+	}
+
+	in := s
+	for len(issues) < maxLintIssues {
+		cfg, err := Load(in)
+		if err == nil {
+			for _, w := range cfg.Lint() {
+				issues = append(issues, LintIssue{Severity: LintSeverityWarning, Code: w.Code, Path: w.Path, Message: w.Message})
+			}
+			return issues, nil
+		}
+		ce, ok := err.(*ConfigError)
+		if !ok {
+			ce = &ConfigError{Err: err}
+		}
+		issue := LintIssue{
+			Severity: LintSeverityError,
+			Code:     lintCode(ce),
+			Path:     ce.Path,
+			Message:  ce.Err.Error(),
+		}
+		if in == s {
+			issue.Line = ce.Line
+		}
+		issues = append(issues, issue)
+
+		if !fixLintIssue(&doc, issue) {
+			return issues, nil
+		}
+		b, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		if string(b) == in {
+			return issues, nil
+		}
+		in = string(b)
+	}
+	return issues, nil
+}
+
+var (
+	lintCodes = []struct {
+		re   *regexp.Regexp
+		code string
+	}{
+		{regexp.MustCompile(`^unknown fields in `), LintUnknownField},
+		{regexp.MustCompile(`invalid label name|is not a valid label name`), LintInvalidLabelName},
+		{regexp.MustCompile(`^undefined receiver `), LintUndefinedReceiver},
+		{regexp.MustCompile(`^notification config name .* is not unique$`), LintDuplicateReceiver},
+		{regexp.MustCompile(`^receiver .* has no notification integrations configured$`), LintMissingIntegrations},
+		{regexp.MustCompile(`^undefined time interval `), LintUndefinedTimeInterval},
+		{regexp.MustCompile(`cannot unmarshal`), LintInvalidType},
+	}
+	patQuoted = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+)
+
+// lintCode returns the code of the lint issue for the error.
+func lintCode(ce *ConfigError) string {
+	msg := ce.Err.Error()
+	for _, c := range lintCodes {
+		if c.re.MatchString(msg) {
+			return c.code
+		}
+	}
+	if ce.Line > 0 {
+		return LintSyntax
+	}
+	return LintInvalid
+}
+
+// fixLintIssue removes the cause of the error issue from the decoded
+// configuration doc. It returns false if it could not be removed.
+func fixLintIssue(doc *yaml.MapSlice, issue LintIssue) bool {
+	if issue.Path == "" {
+		return false
+	}
+	path := parseLintPath(issue.Path)
+
+	switch issue.Code {
+	case LintUnknownField:
+		fields := unknownFields(issue.Message)
+		return editMapAt(doc, path, func(m yaml.MapSlice) yaml.MapSlice {
+			for _, f := range fields {
+				m = deleteMapValue(m, f)
+			}
+			return m
+		})
+
+	case LintInvalidLabelName:
+		if name, ok := lastQuoted(issue.Message); ok {
+			removed := false
+			editMapAt(doc, path, func(m yaml.MapSlice) yaml.MapSlice {
+				if mapValue(m, name) != nil {
+					removed = true
+				}
+				return deleteMapValue(m, name)
+			})
+			if removed {
+				return true
+			}
+		}
+
+	case LintUndefinedReceiver:
+		// The root route needs a receiver, so the first defined one is
+		// used instead.
+		first := firstReceiverName(*doc)
+		return editMapAt(doc, path, func(m yaml.MapSlice) yaml.MapSlice {
+			if len(path) == 1 && first != "" {
+				setMapValue(&m, "receiver", first)
+				return m
+			}
+			return deleteMapValue(m, "receiver")
+		})
+
+	case LintDuplicateReceiver:
+		name, _ := lastQuoted(issue.Message)
+		return editNodeAt(doc, path, func(n interface{}) (interface{}, bool) {
+			rcvs, _ := n.([]interface{})
+			for i := len(rcvs) - 1; i >= 0; i-- {
+				if m, ok := rcvs[i].(yaml.MapSlice); ok && mapValue(m, "name") == name {
+					return append(rcvs[:i:i], rcvs[i+1:]...), true
+				}
+			}
+			return n, true
+		})
+
+	case LintMissingIntegrations:
+		return editMapAt(doc, path, func(m yaml.MapSlice) yaml.MapSlice {
+			setMapValue(&m, "blackhole", true)
+			return m
+		})
+	}
+
+	// Otherwise the innermost invalid list element is removed, such as a
+	// notifier configuration or an inhibit rule. Routes and receivers
+	// are kept as removing them causes further errors, only their
+	// invalid field is removed.
+	for i := len(path) - 1; i > 0; i-- {
+		if _, ok := path[i].(int); !ok {
+			continue
+		}
+		if k := path[i-1]; k == "routes" || (i == 1 && k == "receivers") {
+			break
+		}
+		path = path[:i+1]
+		break
+	}
+	if len(path) == 1 && path[0] == "route" {
+		return false
+	}
+	if !editNodeAt(doc, path, func(interface{}) (interface{}, bool) { return nil, false }) {
+		return false
+	}
+	// Receivers left without notifier configurations are turned into
+	// blackhole receivers.
+	if len(path) > 2 && path[0] == "receivers" {
+		editMapAt(doc, path[:2], func(m yaml.MapSlice) yaml.MapSlice {
+			for _, item := range m {
+				if k, ok := item.Key.(string); ok && strings.HasSuffix(k, "_configs") {
+					if l, ok := item.Value.([]interface{}); ok && len(l) > 0 {
+						return m
+					}
+				}
+			}
+			setMapValue(&m, "blackhole", true)
+			return m
+		})
+	}
+	return true
+}
+
+var patUnknownFields = regexp.MustCompile(`^unknown fields in [^:]*: (.*)$`)
+
+// unknownFields returns the names of the fields listed in an error about
+// unknown fields.
+func unknownFields(msg string) []string {
+	m := patUnknownFields.FindStringSubmatch(msg)
+	if m == nil {
+		return nil
+	}
+	var fields []string
+	for _, f := range strings.Split(m[1], ", ") {
+		// Suggestions of similar names follow the field.
+		fields = append(fields, strings.SplitN(f, " ", 2)[0])
+	}
+	return fields
+}
+
+// parseLintPath splits a path like route.routes[2].match into its map
+// keys and list indexes.
+func parseLintPath(p string) []interface{} {
+	var path []interface{}
+	for _, part := range strings.Split(p, ".") {
+		key := part
+		var idxs []interface{}
+		for strings.HasSuffix(key, "]") {
+			open := strings.LastIndex(key, "[")
+			if open < 0 {
+				break
+			}
+			i, err := strconv.Atoi(key[open+1 : len(key)-1])
+			if err != nil {
+				break
+			}
+			idxs = append([]interface{}{i}, idxs...)
+			key = key[:open]
+		}
+		if key != "" {
+			path = append(path, key)
+		}
+		path = append(path, idxs...)
+	}
+	return path
+}
+
+// editMapAt replaces the map at path in the decoded YAML document doc
+// with the result of edit. It returns false if there is no map at path.
+func editMapAt(doc *yaml.MapSlice, path []interface{}, edit func(yaml.MapSlice) yaml.MapSlice) bool {
+	found := false
+	editNodeAt(doc, path, func(n interface{}) (interface{}, bool) {
+		m, ok := n.(yaml.MapSlice)
+		if !ok {
+			return n, true
+		}
+		found = true
+		return edit(m), true
+	})
+	return found
+}
+
+// editNodeAt replaces the node at path in the decoded YAML document doc
+// with the result of edit, which returns false to remove the node. It
+// returns false if there is no node at path.
+func editNodeAt(doc *yaml.MapSlice, path []interface{}, edit func(interface{}) (interface{}, bool)) bool {
+	n, ok := editNode(*doc, path, edit)
+	if ok {
+		*doc = n.(yaml.MapSlice)
+	}
+	return ok
+}
+
+func editNode(node interface{}, path []interface{}, edit func(interface{}) (interface{}, bool)) (interface{}, bool) {
+	if len(path) == 0 {
+		return node, false
+	}
+	switch n := node.(type) {
+	case yaml.MapSlice:
+		key, ok := path[0].(string)
+		if !ok {
+			return node, false
+		}
+		for i, item := range n {
+			if item.Key != key {
+				continue
+			}
+			if len(path) > 1 {
+				c, ok := editNode(item.Value, path[1:], edit)
+				n[i].Value = c
+				return n, ok
+			}
+			v, keep := edit(item.Value)
+			if !keep {
+				return deleteMapValue(n, key), true
+			}
+			n[i].Value = v
+			return n, true
+		}
+	case []interface{}:
+		i, ok := path[0].(int)
+		if !ok || i < 0 || i >= len(n) {
+			return node, false
+		}
+		if len(path) > 1 {
+			c, ok := editNode(n[i], path[1:], edit)
+			n[i] = c
+			return n, ok
+		}
+		v, keep := edit(n[i])
+		if !keep {
+			return append(n[:i:i], n[i+1:]...), true
+		}
+		n[i] = v
+		return n, true
+	}
+	return node, false
+}
+
+// deleteMapValue returns m without the value stored under key.
+func deleteMapValue(m yaml.MapSlice, key string) yaml.MapSlice {
+	res := m[:0:0]
+	for _, item := range m {
+		if item.Key != key {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// lastQuoted returns the content of the last quoted string in msg.
+func lastQuoted(msg string) (string, bool) {
+	ms := patQuoted.FindAllStringSubmatch(msg, -1)
+	if len(ms) == 0 {
+		return "", false
+	}
+	s, err := strconv.Unquote(`"` + ms[len(ms)-1][1] + `"`)
+	return s, err == nil
+}
+
+// firstReceiverName returns the name of the first receiver of the decoded
+// YAML document doc.
+func firstReceiverName(doc yaml.MapSlice) string {
+	rcvs, _ := mapValue(doc, "receivers").([]interface{})
+	for _, r := range rcvs {
+		if rm, ok := r.(yaml.MapSlice); ok {
+			if name, ok := mapValue(rm, "name").(string); ok && name != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,150 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	issues, err := Lint(`
+route:
+  receiver: default
+  group_wiat: 1m
+  routes:
+  - receiver: team-a
+    match:
+      "team name": a
+      service: api
+  - receiver: team-x
+    match:
+      team: x
+  - receiver: team-a
+    match:
+      service: api
+
+receivers:
+- name: default
+  blackhole: true
+- name: team-a
+  webhook_configs:
+  - url: http://team-a.example.com/
+  - url: team-a.example.com
+- name: team-b
+- name: default
+  blackhole: true
+
+inhibit_rules:
+- source_match:
+    severity: critical
+`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := []LintIssue{
+		{
+			Severity: LintSeverityError,
+			Code:     LintInvalidLabelName,
+			Path:     "route.routes[0].match",
+			Message:  `invalid label name "team name"`,
+		},
+		{
+			Severity: LintSeverityError,
+			Code:     LintUnknownField,
+			Path:     "route",
+			Message:  `unknown fields in route: group_wiat (did you mean "group_wait"?)`,
+		},
+		{
+			Severity: LintSeverityError,
+			Code:     LintInvalid,
+			Path:     "receivers[1].webhook_configs[1].url",
+			Message:  `invalid URL "team-a.example.com", must be an absolute http or https URL`,
+		},
+		{
+			Severity: LintSeverityError,
+			Code:     LintInvalid,
+			Path:     "inhibit_rules[0].source_match",
+			Message:  "inhibit rule must have source and target matchers",
+		},
+		{
+			Severity: LintSeverityError,
+			Code:     LintDuplicateReceiver,
+			Path:     "receivers",
+			Message:  `notification config name "default" is not unique`,
+		},
+		{
+			Severity: LintSeverityError,
+			Code:     LintMissingIntegrations,
+			Path:     "receivers[2]",
+			Message:  `receiver "team-b" has no notification integrations configured`,
+		},
+		{
+			Severity: LintSeverityError,
+			Code:     LintUndefinedReceiver,
+			Path:     "route.routes[1]",
+			Message:  `undefined receiver "team-x"`,
+		},
+		{
+			// The remaining matcher of the first route shadows the
+			// last one.
+			Severity: LintSeverityWarning,
+			Code:     LintUnreachableRoute,
+			Path:     "route.routes[2]",
+			Message:  "route is unreachable, all alerts it matches are matched by route.routes[0] before, which does not continue",
+		},
+		{
+			Severity: LintSeverityWarning,
+			Code:     LintUnusedReceiver,
+			Path:     "receivers[2]",
+			Message:  `receiver "team-b" is not used by any route`,
+		},
+	}
+	if !reflect.DeepEqual(issues, expected) {
+		t.Errorf("Unexpected issues:\n%v\nexpected:\n%v", issues, expected)
+	}
+
+	issues, err = Lint(`
+route:
+  receiver: nope
+receivers:
+- name: default
+  blackhole: true
+`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(issues) != 1 || issues[0].Code != LintUndefinedReceiver || issues[0].Path != "route" {
+		t.Errorf("Expected only an undefined receiver of the root route, got %v", issues)
+	}
+
+	issues, err = Lint("route:\n  receiver: default\n  routes: [\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(issues) != 1 || issues[0].Code != LintSyntax || issues[0].Line == 0 {
+		t.Errorf("Expected a syntax error with its line, got %v", issues)
+	}
+
+	issues, err = Lint(`
+route:
+  receiver: default
+receivers:
+- name: default
+  blackhole: true
+`)
+	if err != nil || len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v (%v)", issues, err)
+	}
+}
@@ -0,0 +1,559 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp/syntax"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/common/model"
+)
+
+// The codes of lint warnings. They identify the kind of problem and do
+// not change, so that tools can rely on them.
+const (
+	LintUnreachableRoute = "unreachable-route"
+	LintContinueOverlap  = "continue-overlap"
+	LintSelfInhibition   = "self-inhibition"
+	LintGroupByTypo      = "group-by-typo"
+	LintDeprecatedField  = "deprecated-field"
+	LintUnusedReceiver   = "unused-receiver"
+	LintEmptyRegexp      = "empty-regexp"
+	LintNoMatchRegexp    = "no-match-regexp"
+	LintDuplicateTarget  = "duplicate-target"
+)
+
+// LintWarning describes a part of a configuration that is valid but
+// likely does not work as intended.
+type LintWarning struct {
+	// Code identifies the kind of problem, such as unreachable-route.
+	Code string
+	// Path is the path of the section the warning refers to, such as
+	// route.routes[2].
+	Path string
+	// Message describes the problem.
+	Message string
+}
+
+func (w LintWarning) String() string {
+	return fmt.Sprintf("%s: %s", w.Path, w.Message)
+}
+
+// Lint returns warnings about the configuration. Unlike errors found while
+// loading, they do not prevent the configuration from being used.
+func (c *Config) Lint() []LintWarning {
+	if c.Route == nil {
+		return nil
+	}
+	ws := append(lintRoutes(c.Route, "route", nil), c.lintGroupBy()...)
+	ws = append(ws, c.lintInhibitRules()...)
+	ws = append(ws, c.lintUnusedReceivers()...)
+	ws = append(ws, lintRegexps(c.Route, "route")...)
+	ws = append(ws, c.lintDuplicateTargets()...)
+	for _, d := range c.DeprecatedFields() {
+		ws = append(ws, LintWarning{Code: LintDeprecatedField, Path: d.Path, Message: d.message()})
+	}
+	return ws
+}
+
+// lintRoutes returns warnings about the children of the route r, which
+// is only reached by alerts that fulfill the matchers of its parents.
+func lintRoutes(r *Route, path string, parents []*Matcher) []LintWarning {
+	if r.IsLeaf() {
+		return nil
+	}
+	var (
+		warnings []LintWarning
+		scope    = append(append([]*Matcher{}, parents...), r.allMatchers()...)
+	)
+	for i, cr := range r.Routes {
+		cpath := fmt.Sprintf("%s.routes[%d]", path, i)
+		own := cr.allMatchers()
+
+		if m, pm := contradiction(own, scope); m != nil {
+			warnings = append(warnings, LintWarning{
+				Code:    LintUnreachableRoute,
+				Path:    cpath,
+				Message: fmt.Sprintf("route is unreachable, its matcher %s contradicts the matcher %s of a parent route", m, pm),
+			})
+			continue
+		}
+		if j := shadowingSibling(r.Routes[:i], append(append([]*Matcher{}, scope...), own...)); j >= 0 {
+			warnings = append(warnings, LintWarning{
+				Code:    LintUnreachableRoute,
+				Path:    cpath,
+				Message: fmt.Sprintf("route is unreachable, all alerts it matches are matched by %s.routes[%d] before, which does not continue", path, j),
+			})
+			continue
+		}
+		if cr.Continue {
+			if ps := overlappingSiblings(r.Routes[i+1:], i+1, path, append(append([]*Matcher{}, scope...), own...)); len(ps) > 0 {
+				warnings = append(warnings, LintWarning{
+					Code:    LintContinueOverlap,
+					Path:    cpath,
+					Message: fmt.Sprintf("route continues, alerts it matches may also be sent to %s", strings.Join(ps, ", ")),
+				})
+			}
+		}
+		// The children of unreachable routes are not checked as they are
+		// unreachable as well.
+		warnings = append(warnings, lintRoutes(cr, cpath, scope)...)
+	}
+	return warnings
+}
+
+// overlappingSiblings returns the paths of the given following siblings
+// of a route, starting at index offset, that may match alerts fulfilling
+// the constraints. Siblings are only excluded if their matchers
+// contradict the constraints, so the result is approximate. A sibling
+// that does not continue and matches all such alerts ends the search, as
+// none of them get past it.
+func overlappingSiblings(siblings []*Route, offset int, path string, constraints []*Matcher) []string {
+	var paths []string
+	for i, sr := range siblings {
+		if m, _ := contradiction(sr.allMatchers(), constraints); m != nil {
+			continue
+		}
+		paths = append(paths, fmt.Sprintf("%s.routes[%d]", path, offset+i))
+		if !sr.Continue && !sr.hasUnanalyzedMatchers() && implies(constraints, sr.allMatchers()) {
+			break
+		}
+	}
+	return paths
+}
+
+// shadowingSibling returns the index of the first of the given previous
+// siblings of a route that matches all alerts fulfilling the constraints
+// and does not continue, or -1 if there is none.
+func shadowingSibling(siblings []*Route, constraints []*Matcher) int {
+	for i, sr := range siblings {
+		// Routes with matchers not taken into account by the analysis
+		// never shadow others.
+		if !sr.Continue && !sr.hasUnanalyzedMatchers() && implies(constraints, sr.allMatchers()) {
+			return i
+		}
+	}
+	return -1
+}
+
+// hasUnanalyzedMatchers returns true if the route has CIDR or annotation
+// matchers, which are not part of allMatchers, or active time intervals,
+// outside of which alerts fall through to the next sibling.
+func (r *Route) hasUnanalyzedMatchers() bool {
+	return len(r.MatchCIDR) > 0 || len(r.AnnotationMatch) > 0 || len(r.AnnotationMatchRE) > 0 ||
+		len(r.ActiveTimeIntervals) > 0
+}
+
+// allMatchers returns the matchers of the route itself, regardless of how
+// they are configured.
+func (r *Route) allMatchers() []*Matcher {
+	var in, notIn map[string]Regexp
+	for ln, vs := range r.MatchIn {
+		if in == nil {
+			in = map[string]Regexp{}
+		}
+		in[ln] = NewValuesRegexp(vs)
+	}
+	for ln, vs := range r.MatchNotIn {
+		if notIn == nil {
+			notIn = map[string]Regexp{}
+		}
+		notIn[ln] = NewValuesRegexp(vs)
+	}
+	ms := append(labelMatchers(r.Match, r.MatchRE, r.MatchNot, r.MatchNotRE), labelMatchers(nil, in, nil, notIn)...)
+	return append(ms, r.Matchers...)
+}
+
+// labelMatchers returns the matchers for the given maps of label values
+// and regular expressions, sorted by label name and type.
+func labelMatchers(eq map[string]string, eqRE map[string]Regexp, neq map[string]string, neqRE map[string]Regexp) []*Matcher {
+	var ms []*Matcher
+	for ln, v := range eq {
+		ms = append(ms, &Matcher{Name: ln, Type: MatchEqual, Value: v})
+	}
+	for ln, re := range eqRE {
+		ms = append(ms, &Matcher{Name: ln, Type: MatchRegexp, Value: re.source(), Regexp: re})
+	}
+	for ln, v := range neq {
+		ms = append(ms, &Matcher{Name: ln, Type: MatchNotEqual, Value: v})
+	}
+	for ln, re := range neqRE {
+		ms = append(ms, &Matcher{Name: ln, Type: MatchNotRegexp, Value: re.source(), Regexp: re})
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		if ms[i].Name != ms[j].Name {
+			return ms[i].Name < ms[j].Name
+		}
+		return ms[i].Type < ms[j].Type
+	})
+	return ms
+}
+
+// lintInhibitRules returns warnings about inhibit rules whose target
+// alerts all match the source matchers as well. Such alerts inhibit
+// themselves, as the equal labels of an alert always equal its own.
+func (c *Config) lintInhibitRules() []LintWarning {
+	var warnings []LintWarning
+	for i, ir := range c.InhibitRules {
+		source := labelMatchers(ir.SourceMatch, ir.SourceMatchRE, ir.SourceMatchNot, ir.SourceMatchNotRE)
+		target := labelMatchers(ir.TargetMatch, ir.TargetMatchRE, ir.TargetMatchNot, ir.TargetMatchNotRE)
+		if !implies(target, source) {
+			continue
+		}
+		msg := "all target alerts match the source matchers, so they inhibit themselves"
+		if implies(source, target) {
+			msg = "source and target matchers are equal, so all matching alerts inhibit themselves"
+		}
+		warnings = append(warnings, LintWarning{Code: LintSelfInhibition, Path: fmt.Sprintf("inhibit_rules[%d]", i), Message: msg})
+	}
+	return warnings
+}
+
+// contradiction returns a matcher of ms and a matcher of others that no
+// label set can fulfill both of.
+func contradiction(ms, others []*Matcher) (*Matcher, *Matcher) {
+	for _, m := range ms {
+		for _, o := range others {
+			if m.Name != o.Name {
+				continue
+			}
+			if m.Type == MatchEqual && !o.Matches(m.Value) || o.Type == MatchEqual && !m.Matches(o.Value) {
+				return m, o
+			}
+		}
+	}
+	return nil, nil
+}
+
+// implies returns true if all label sets that fulfill the constraints
+// fulfill the matchers ms as well. Only trivial cases are detected.
+func implies(constraints, ms []*Matcher) bool {
+	for _, m := range ms {
+		implied := false
+		for _, c := range constraints {
+			if c.Name != m.Name {
+				continue
+			}
+			if sameMatcher(c, m) || c.Type == MatchEqual && m.Matches(c.Value) {
+				implied = true
+				break
+			}
+		}
+		if !implied {
+			return false
+		}
+	}
+	return true
+}
+
+// sameMatcher returns true if both matchers match the same label values.
+// Regular expressions are compared by their compiled form, which includes
+// their options.
+func sameMatcher(a, b *Matcher) bool {
+	if a.Name != b.Name || a.Type != b.Type {
+		return false
+	}
+	if a.Type.IsRegex() {
+		return a.Regexp.String() == b.Regexp.String()
+	}
+	return a.Value == b.Value
+}
+
+// lintGroupBy returns warnings about group_by labels that are likely
+// misspelled. Such labels are not used by any matcher of the configuration
+// while a label with a similar name is.
+func (c *Config) lintGroupBy() []LintWarning {
+	used := map[string]struct{}{string(model.AlertNameLabel): {}}
+	var addRoute func(r *Route)
+	addRoute = func(r *Route) {
+		for _, m := range r.allMatchers() {
+			used[m.Name] = struct{}{}
+		}
+		for _, cr := range r.Routes {
+			addRoute(cr)
+		}
+	}
+	addRoute(c.Route)
+	for _, ir := range c.InhibitRules {
+		for _, ln := range ir.labelNames() {
+			used[ln] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(used))
+	for ln := range used {
+		names = append(names, ln)
+	}
+	sort.Strings(names)
+
+	var (
+		warnings []LintWarning
+		walk     func(r *Route, path string)
+	)
+	walk = func(r *Route, path string) {
+		for _, ln := range r.GroupBy {
+			if _, ok := used[string(ln)]; ok {
+				continue
+			}
+			if similar := similarName(string(ln), names); similar != "" {
+				warnings = append(warnings, LintWarning{
+					Code:    LintGroupByTypo,
+					Path:    path,
+					Message: fmt.Sprintf("group_by label %q is not used by any matcher, did you mean %q?", ln, similar),
+				})
+			}
+		}
+		for i, cr := range r.Routes {
+			walk(cr, fmt.Sprintf("%s.routes[%d]", path, i))
+		}
+	}
+	walk(c.Route, "route")
+	return warnings
+}
+
+// ReceiverReferences returns the number of routes that notify each
+// receiver, either because they name it or because they inherit it from
+// their parent. Receivers that no route notifies are not included.
+func (c *Config) ReceiverReferences() map[string]int {
+	refs := map[string]int{}
+	if c.Route == nil {
+		return refs
+	}
+	var walk func(r *Route, receiver string)
+	walk = func(r *Route, receiver string) {
+		if r.Receiver != "" {
+			receiver = r.Receiver
+		}
+		if receiver != "" {
+			refs[receiver]++
+		}
+		// All children are visited, also those only reached through
+		// siblings that continue.
+		for _, cr := range r.Routes {
+			walk(cr, receiver)
+		}
+	}
+	walk(c.Route, "")
+	return refs
+}
+
+// lintUnusedReceivers returns warnings about receivers no route notifies.
+func (c *Config) lintUnusedReceivers() []LintWarning {
+	refs := c.ReceiverReferences()
+	var warnings []LintWarning
+	for i, name := range c.ReceiverNames() {
+		if refs[name] > 0 {
+			continue
+		}
+		warnings = append(warnings, LintWarning{
+			Code:    LintUnusedReceiver,
+			Path:    fmt.Sprintf("receivers[%d]", i),
+			Message: fmt.Sprintf("receiver %q is not used by any route", name),
+		})
+	}
+	return warnings
+}
+
+// lintDuplicateTargets returns warnings about notifier configurations of a
+// receiver that send notifications to the same target as another one of
+// the same type, which results in duplicate notifications. This happens
+// when receivers with duplicate names are merged.
+func (c *Config) lintDuplicateTargets() []LintWarning {
+	var warnings []LintWarning
+	for i, rcv := range c.Receivers {
+		v := reflect.ValueOf(rcv).Elem()
+		for j := 0; j < v.NumField(); j++ {
+			key := strings.Split(v.Type().Field(j).Tag.Get("yaml"), ",")[0]
+			if !strings.HasSuffix(key, "_configs") {
+				continue
+			}
+			seen := map[string]int{}
+			for k := 0; k < v.Field(j).Len(); k++ {
+				target := notifierTarget(v.Field(j).Index(k).Interface())
+				if prev, ok := seen[target]; ok {
+					warnings = append(warnings, LintWarning{
+						Code:    LintDuplicateTarget,
+						Path:    fmt.Sprintf("receivers[%d].%s[%d]", i, key, k),
+						Message: fmt.Sprintf("notifications are sent to the same target as %s[%d]", key, prev),
+					})
+					continue
+				}
+				seen[target] = k
+			}
+		}
+	}
+	return warnings
+}
+
+// notifierTarget returns a string identifying where the notifier
+// configuration nc sends notifications to. For notifiers without a
+// simple notion of a target, all of the configuration is compared.
+func notifierTarget(nc interface{}) string {
+	switch nc := nc.(type) {
+	case *WebhookConfig:
+		if nc.URL != nil && nc.URL.URL != nil {
+			return nc.URL.String()
+		}
+	case *EmailConfig:
+		return nc.To
+	case *SlackConfig:
+		if nc.APIURL != nil && nc.APIURL.URL != nil {
+			return nc.APIURL.URL.String() + " " + nc.Channel
+		}
+	}
+	b, err := marshalYAML(nc, true)
+	if err != nil {
+		return fmt.Sprintf("%p", nc)
+	}
+	return string(b)
+}
+
+// lintRegexps returns warnings about the match_re entries of the route r
+// and its children that match no value or only the empty one.
+func lintRegexps(r *Route, path string) []LintWarning {
+	var (
+		warnings []LintWarning
+		names    = make([]string, 0, len(r.MatchRE))
+	)
+	for ln := range r.MatchRE {
+		names = append(names, ln)
+	}
+	sort.Strings(names)
+	for _, ln := range names {
+		re := r.MatchRE[ln]
+		switch {
+		case re.Regexp == nil:
+		case re.pattern == "" && !re.unanchored:
+			warnings = append(warnings, LintWarning{
+				Code:    LintEmptyRegexp,
+				Path:    path + ".match_re",
+				Message: fmt.Sprintf("regexp of label %q is empty and only matches alerts without the label", ln),
+			})
+		case neverMatches(re.Regexp.String()):
+			warnings = append(warnings, LintWarning{
+				Code:    LintNoMatchRegexp,
+				Path:    path + ".match_re",
+				Message: fmt.Sprintf("regexp %q of label %q cannot match any value", re.source(), ln),
+			})
+		}
+	}
+	for i, cr := range r.Routes {
+		warnings = append(warnings, lintRegexps(cr, fmt.Sprintf("%s.routes[%d]", path, i))...)
+	}
+	return warnings
+}
+
+// neverMatches returns true if the regular expression cannot match any
+// input. Only trivial cases, such as empty character classes that have
+// to be matched, are detected.
+func neverMatches(expr string) bool {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return false
+	}
+	var never func(re *syntax.Regexp) bool
+	never = func(re *syntax.Regexp) bool {
+		switch re.Op {
+		case syntax.OpNoMatch:
+			return true
+		case syntax.OpCharClass:
+			return len(re.Rune) == 0
+		case syntax.OpCapture, syntax.OpPlus:
+			return never(re.Sub[0])
+		case syntax.OpRepeat:
+			return re.Min > 0 && never(re.Sub[0])
+		case syntax.OpConcat:
+			for _, sub := range re.Sub {
+				if never(sub) {
+					return true
+				}
+			}
+		case syntax.OpAlternate:
+			for _, sub := range re.Sub {
+				if !never(sub) {
+					return false
+				}
+			}
+			return true
+		}
+		return false
+	}
+	return never(re.Simplify())
+}
+
+// labelNames returns the names of all labels the inhibit rule refers to.
+func (r *InhibitRule) labelNames() []string {
+	var names []string
+	for _, m := range []map[string]string{r.SourceMatch, r.TargetMatch, r.SourceMatchNot, r.TargetMatchNot} {
+		for ln := range m {
+			names = append(names, ln)
+		}
+	}
+	for _, m := range []map[string]Regexp{r.SourceMatchRE, r.TargetMatchRE, r.SourceMatchNotRE, r.TargetMatchNotRE} {
+		for ln := range m {
+			names = append(names, ln)
+		}
+	}
+	for _, ln := range r.Equal {
+		names = append(names, string(ln))
+	}
+	return names
+}
+
+// similarName returns the first of the candidates that name is likely a
+// misspelling of, or the empty string if there is none. Names are similar
+// if they only differ in case and separators or by a single edit.
+func similarName(name string, candidates []string) string {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(s))
+	}
+	for _, c := range candidates {
+		if c == name {
+			continue
+		}
+		if normalize(c) == normalize(name) || editDistance(c, name) <= 1 {
+			return c
+		}
+	}
+	return ""
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			d := prev[j-1]
+			if a[i-1] != b[j-1] {
+				d++
+			}
+			if prev[j]+1 < d {
+				d = prev[j] + 1
+			}
+			if cur[j-1]+1 < d {
+				d = cur[j-1] + 1
+			}
+			cur[j] = d
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,527 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLintUnreachableRoutes(t *testing.T) {
+	cases := []struct {
+		routes   string
+		warnings []LintWarning
+	}{
+		{
+			// Distinct matchers do not shadow each other.
+			routes: `
+  - match:
+      team: a
+  - match:
+      team: b
+  - match_re:
+      team: c|d
+`,
+		},
+		{
+			routes: `
+  - receiver: default
+  - match:
+      team: a
+`,
+			warnings: []LintWarning{{
+				Code:    LintUnreachableRoute,
+				Path:    "route.routes[1]",
+				Message: "route is unreachable, all alerts it matches are matched by route.routes[0] before, which does not continue",
+			}},
+		},
+		{
+			routes: `
+  - match_in:
+      team: [a, b]
+  - match:
+      team: c
+  - match:
+      team: a
+`,
+			warnings: []LintWarning{{
+				Code:    LintUnreachableRoute,
+				Path:    "route.routes[2]",
+				Message: "route is unreachable, all alerts it matches are matched by route.routes[0] before, which does not continue",
+			}},
+		},
+		{
+			// Continuing siblings do not shadow later ones.
+			routes: `
+  - match_re:
+      team: a|b
+    continue: true
+  - match:
+      team: a
+`,
+			warnings: []LintWarning{{
+				Code:    LintContinueOverlap,
+				Path:    "route.routes[0]",
+				Message: "route continues, alerts it matches may also be sent to route.routes[1]",
+			}},
+		},
+		{
+			routes: `
+  - match_re:
+      team: a|b
+  - match:
+      team: a
+      severity: critical
+  - matchers:
+    - team=~"a|b"
+    - severity="warning"
+`,
+			warnings: []LintWarning{
+				{
+					Code:    LintUnreachableRoute,
+					Path:    "route.routes[1]",
+					Message: "route is unreachable, all alerts it matches are matched by route.routes[0] before, which does not continue",
+				},
+				{
+					Code:    LintUnreachableRoute,
+					Path:    "route.routes[2]",
+					Message: "route is unreachable, all alerts it matches are matched by route.routes[0] before, which does not continue",
+				},
+			},
+		},
+		{
+			// Routes with active time intervals do not shadow later
+			// ones, alerts fall through outside of the intervals.
+			routes: `
+  - match:
+      team: a
+    active_time_intervals: [weekends]
+  - match:
+      team: a
+`,
+		},
+		{
+			routes: `
+  - match:
+      team: a
+    routes:
+    - match:
+        team: b
+    - match_re:
+        team: a|c
+    - match_not:
+        team: a
+    - matchers:
+      - team=~"b.*"
+`,
+			warnings: []LintWarning{
+				{
+					Code:    LintUnreachableRoute,
+					Path:    "route.routes[0].routes[0]",
+					Message: `route is unreachable, its matcher team="b" contradicts the matcher team="a" of a parent route`,
+				},
+				{
+					Code:    LintUnreachableRoute,
+					Path:    "route.routes[0].routes[2]",
+					Message: `route is unreachable, its matcher team!="a" contradicts the matcher team="a" of a parent route`,
+				},
+				{
+					Code:    LintUnreachableRoute,
+					Path:    "route.routes[0].routes[3]",
+					Message: `route is unreachable, its matcher team=~"b.*" contradicts the matcher team="a" of a parent route`,
+				},
+			},
+		},
+	}
+
+	for i, c := range cases {
+		cfg, err := Load(`
+route:
+  receiver: default
+  routes:` + c.routes + `
+receivers:
+- name: default
+  blackhole: true
+time_intervals:
+- name: weekends
+  time_intervals:
+  - weekdays: [saturday, sunday]
+`)
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %s", i, err)
+		}
+		if got := cfg.Lint(); !reflect.DeepEqual(got, c.warnings) {
+			t.Errorf("%d: expected warnings %v, got %v", i, c.warnings, got)
+		}
+	}
+}
+
+func TestLintContinue(t *testing.T) {
+	cfg, err := Load(`
+route:
+  receiver: default
+  routes:
+  - match:
+      team: a
+    continue: true
+    routes:
+    - match:
+        severity: critical
+      continue: true
+    - match:
+        severity: warning
+    - match_re:
+        severity: critical|warning
+  - match:
+      team: b
+  - match_re:
+      team: a|c
+    continue: true
+  - receiver: default
+  - match:
+      service: api
+
+receivers:
+- name: default
+  blackhole: true
+`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := []LintWarning{
+		{
+			// Routes with contradicting matchers are left out.
+			Code:    LintContinueOverlap,
+			Path:    "route.routes[0]",
+			Message: "route continues, alerts it matches may also be sent to route.routes[2], route.routes[3]",
+		},
+		{
+			Code:    LintContinueOverlap,
+			Path:    "route.routes[0].routes[0]",
+			Message: "route continues, alerts it matches may also be sent to route.routes[0].routes[2]",
+		},
+		{
+			// The catch-all route ends the search.
+			Code:    LintContinueOverlap,
+			Path:    "route.routes[2]",
+			Message: "route continues, alerts it matches may also be sent to route.routes[3]",
+		},
+		{
+			Code:    LintUnreachableRoute,
+			Path:    "route.routes[4]",
+			Message: "route is unreachable, all alerts it matches are matched by route.routes[3] before, which does not continue",
+		},
+	}
+	if got := cfg.Lint(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected warnings\n%v\ngot\n%v", expected, got)
+	}
+}
+
+func TestLintGroupByTypos(t *testing.T) {
+	cfg, err := Load(`
+route:
+  receiver: default
+  group_by: [alert_name, cluster]
+  routes:
+  - match:
+      service: api
+    receiver: default
+    group_by: [servce, instance]
+  - match_re:
+      Cluster: eu-.*
+    receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+
+inhibit_rules:
+- source_match:
+    severity: critical
+  target_match:
+    severity: warning
+  equal: [instance]
+`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := []LintWarning{
+		{
+			Code:    LintGroupByTypo,
+			Path:    "route",
+			Message: `group_by label "alert_name" is not used by any matcher, did you mean "alertname"?`,
+		},
+		{
+			Code:    LintGroupByTypo,
+			Path:    "route",
+			Message: `group_by label "cluster" is not used by any matcher, did you mean "Cluster"?`,
+		},
+		{
+			Code:    LintGroupByTypo,
+			Path:    "route.routes[0]",
+			Message: `group_by label "servce" is not used by any matcher, did you mean "service"?`,
+		},
+	}
+	if got := cfg.Lint(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected warnings %v, got %v", expected, got)
+	}
+}
+
+func TestLintSelfInhibition(t *testing.T) {
+	cases := []struct {
+		rules    string
+		warnings []LintWarning
+	}{
+		{
+			rules: `
+- source_match:
+    alertname: NodeDown
+  target_match:
+    alertname: NodeDown
+  equal: [instance]
+`,
+			warnings: []LintWarning{{
+				Code:    LintSelfInhibition,
+				Path:    "inhibit_rules[0]",
+				Message: "source and target matchers are equal, so all matching alerts inhibit themselves",
+			}},
+		},
+		{
+			rules: `
+- source_match:
+    alertname: NodeDown
+  target_match:
+    alertname: NodeDown
+    severity: warning
+  equal: [instance]
+`,
+			warnings: []LintWarning{{
+				Code:    LintSelfInhibition,
+				Path:    "inhibit_rules[0]",
+				Message: "all target alerts match the source matchers, so they inhibit themselves",
+			}},
+		},
+		{
+			// The matchers refer to the same labels, but no alert
+			// matches both of them.
+			rules: `
+- source_match:
+    alertname: NodeDown
+    severity: critical
+  target_match:
+    alertname: NodeDown
+    severity: warning
+  equal: [instance]
+`,
+		},
+		{
+			// Source alerts only partially overlap with the targets.
+			rules: `
+- source_match:
+    severity: critical
+  target_match_re:
+    alertname: Node.*
+  equal: [instance]
+`,
+		},
+	}
+
+	for i, c := range cases {
+		cfg, err := Load(`
+route:
+  receiver: default
+receivers:
+- name: default
+  blackhole: true
+inhibit_rules:` + c.rules)
+		if err != nil {
+			t.Fatalf("Case %d: unexpected error: %s", i, err)
+		}
+		if got := cfg.Lint(); !reflect.DeepEqual(got, c.warnings) {
+			t.Errorf("Case %d: expected warnings %v, got %v", i, c.warnings, got)
+		}
+	}
+}
+
+func TestLintUnusedReceivers(t *testing.T) {
+	cfg, err := Load(`
+route:
+  receiver: default
+  routes:
+  - receiver: team-a
+    match:
+      team: a
+    continue: true
+  - receiver: team-b
+    match:
+      team: a
+    routes:
+    - match:
+        severity: critical
+
+receivers:
+- name: default
+  blackhole: true
+- name: team-a
+  blackhole: true
+- name: team-b
+  blackhole: true
+- name: team-c
+  blackhole: true
+`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// team-b is only reached after the continuing team-a route.
+	expectedRefs := map[string]int{"default": 1, "team-a": 1, "team-b": 2}
+	if got := cfg.ReceiverReferences(); !reflect.DeepEqual(got, expectedRefs) {
+		t.Errorf("Expected references %v, got %v", expectedRefs, got)
+	}
+	expected := []LintWarning{
+		{
+			Code:    LintContinueOverlap,
+			Path:    "route.routes[0]",
+			Message: "route continues, alerts it matches may also be sent to route.routes[1]",
+		},
+		{
+			Code:    LintUnusedReceiver,
+			Path:    "receivers[3]",
+			Message: `receiver "team-c" is not used by any route`,
+		},
+	}
+	if got := cfg.Lint(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected warnings %v, got %v", expected, got)
+	}
+}
+
+func TestLintRegexps(t *testing.T) {
+	cfg, err := Load(`
+route:
+  receiver: default
+  routes:
+  - match_re:
+      team: ""
+      service: api|db
+  - match:
+      team: a
+    routes:
+    - match_re:
+        env: '[^\x00-\x{10FFFF}]'
+        instance: 'web-[^\x00-\x{10FFFF}]+|db-.*'
+        job: '(?:[^\x00-\x{10FFFF}]){1,3}'
+
+receivers:
+- name: default
+  blackhole: true
+`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := []LintWarning{
+		{
+			Code:    LintEmptyRegexp,
+			Path:    "route.routes[0].match_re",
+			Message: `regexp of label "team" is empty and only matches alerts without the label`,
+		},
+		{
+			Code:    LintNoMatchRegexp,
+			Path:    "route.routes[1].routes[0].match_re",
+			Message: `regexp "[^\\x00-\\x{10FFFF}]" of label "env" cannot match any value`,
+		},
+		{
+			Code:    LintNoMatchRegexp,
+			Path:    "route.routes[1].routes[0].match_re",
+			Message: `regexp "(?:[^\\x00-\\x{10FFFF}]){1,3}" of label "job" cannot match any value`,
+		},
+	}
+	if got := cfg.Lint(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected warnings %v, got %v", expected, got)
+	}
+}
+
+func TestGroupByReservedLabel(t *testing.T) {
+	expectLoadError(t, `
+route:
+  receiver: default
+  group_by: [alertname, __name__]
+
+receivers:
+- name: default
+  blackhole: true
+`, `cannot group by reserved label "__name__", labels starting with "__" are internal`)
+}
+
+func TestRouteIsLeaf(t *testing.T) {
+	r := &Route{Routes: []*Route{{}}}
+	if r.IsLeaf() {
+		t.Errorf("Expected route with children not to be a leaf")
+	}
+	if !r.Routes[0].IsLeaf() {
+		t.Errorf("Expected route without children to be a leaf")
+	}
+}
+
+func TestDeprecatedFields(t *testing.T) {
+	in := `
+route:
+  receiver: default
+  routes:
+  - match:
+      team: a
+    receiver: pager
+
+receivers:
+- name: default
+  blackhole: true
+- name: pager
+  pagerduty_configs:
+  - routing_key: key
+  - service_key: key
+`
+	cfg, err := Load(in)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := []Deprecation{{
+		Path:        "receivers[1].pagerduty_configs[1]",
+		Field:       "service_key",
+		Replacement: "routing_key",
+	}}
+	if got := cfg.DeprecatedFields(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected deprecations %v, got %v", expected, got)
+	}
+	warnings := []LintWarning{{
+		Code:    LintDeprecatedField,
+		Path:    "receivers[1].pagerduty_configs[1]",
+		Message: "service_key is deprecated, use routing_key instead",
+	}}
+	if got := cfg.Lint(); !reflect.DeepEqual(got, warnings) {
+		t.Errorf("Expected warnings %v, got %v", warnings, got)
+	}
+
+	_, err = LoadWithOptions(in, LoadOptions{StrictMode: true})
+	if err == nil {
+		t.Fatalf("Expected strict mode to reject deprecated fields")
+	}
+	if msg := "receivers[1].pagerduty_configs[1].service_key: service_key is deprecated, use routing_key instead"; err.Error() != msg {
+		t.Errorf("Expected error %q, got %q", msg, err)
+	}
+
+	in = strings.Replace(in, "service_key", "routing_key", 1)
+	if _, err := LoadWithOptions(in, LoadOptions{StrictMode: true}); err != nil {
+		t.Errorf("Unexpected error in strict mode: %s", err)
+	}
+}
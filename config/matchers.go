@@ -0,0 +1,161 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/common/model"
+)
+
+// MatchType is the comparison operator of a Matcher.
+type MatchType int
+
+// The supported match types.
+const (
+	MatchEqual MatchType = iota
+	MatchNotEqual
+	MatchRegexp
+	MatchNotRegexp
+)
+
+func (t MatchType) String() string {
+	switch t {
+	case MatchEqual:
+		return "="
+	case MatchNotEqual:
+		return "!="
+	case MatchRegexp:
+		return "=~"
+	case MatchNotRegexp:
+		return "!~"
+	}
+	return fmt.Sprintf("<unknown match type %d>", int(t))
+}
+
+// IsRegex returns true if the match type compares against a regular
+// expression.
+func (t MatchType) IsRegex() bool {
+	return t == MatchRegexp || t == MatchNotRegexp
+}
+
+// IsNegative returns true if the match type is fulfilled by label values
+// that do not match.
+func (t MatchType) IsNegative() bool {
+	return t == MatchNotEqual || t == MatchNotRegexp
+}
+
+// Operators are ordered so that no operator is matched by a prefix of
+// a later one.
+var matchOperators = []MatchType{MatchRegexp, MatchNotRegexp, MatchNotEqual, MatchEqual}
+
+// Matcher is a label matcher written as a single expression such as
+// `severity="critical"` or `instance=~"db.*"`.
+type Matcher struct {
+	Name  string
+	Type  MatchType
+	Value string
+
+	// Regexp holds the compiled value of regular expression matchers.
+	Regexp Regexp
+}
+
+// ParseMatcher parses a matcher expression of the form name<op>"value",
+// where op is one of =, !=, =~ and !~. The value may be left unquoted if
+// it does not contain any quotes and does not start with =, ! or ~.
+func ParseMatcher(s string) (*Matcher, error) {
+	i := strings.IndexAny(s, "=!")
+	if i < 0 {
+		return nil, fmt.Errorf("invalid matcher %q: missing operator", s)
+	}
+	name := strings.TrimSpace(s[:i])
+	if !model.LabelNameRE.MatchString(name) {
+		return nil, fmt.Errorf("invalid matcher %q: invalid label name %q", s, name)
+	}
+
+	m := &Matcher{Name: name, Type: -1}
+	rest := s[i:]
+	for _, t := range matchOperators {
+		if strings.HasPrefix(rest, t.String()) {
+			m.Type = t
+			rest = rest[len(t.String()):]
+			break
+		}
+	}
+	if m.Type < 0 {
+		return nil, fmt.Errorf("invalid matcher %q: invalid operator", s)
+	}
+
+	value := strings.TrimSpace(rest)
+	if strings.Contains(value, `"`) {
+		uq, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher %q: invalid quoted value %s", s, value)
+		}
+		value = uq
+	} else if value != "" && strings.ContainsAny(value[:1], "=!~") {
+		// Most likely a mistyped operator like in a==b.
+		return nil, fmt.Errorf("invalid matcher %q: unquoted value must not start with %q", s, value[:1])
+	}
+	m.Value = value
+
+	if m.Type.IsRegex() {
+		// Matcher regexps are anchored implicitly like all others.
+		if hasAnchors(value) {
+			return nil, fmt.Errorf("invalid matcher %q: regexp must not start with \"^\" or end with \"$\" as it is anchored implicitly", s)
+		}
+		re, err := NewRegexp(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher %q: %s", s, err)
+		}
+		m.Regexp = re
+	}
+	return m, nil
+}
+
+// Matches returns true if the given label value fulfills the matcher.
+func (m *Matcher) Matches(v string) bool {
+	var matches bool
+	if m.Type.IsRegex() {
+		matches = m.Regexp.MatchString(v)
+	} else {
+		matches = v == m.Value
+	}
+	return matches != m.Type.IsNegative()
+}
+
+func (m *Matcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.Name, m.Type, m.Value)
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (m *Matcher) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	pm, err := ParseMatcher(s)
+	if err != nil {
+		return err
+	}
+	*m = *pm
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (m *Matcher) MarshalYAML() (interface{}, error) {
+	return m.String(), nil
+}
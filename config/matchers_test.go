@@ -0,0 +1,99 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+)
+
+func TestParseMatcher(t *testing.T) {
+	cases := []struct {
+		in    string
+		name  string
+		typ   MatchType
+		value string
+		err   string
+	}{
+		{in: `severity="critical"`, name: "severity", typ: MatchEqual, value: "critical"},
+		{in: `env != "test"`, name: "env", typ: MatchNotEqual, value: "test"},
+		{in: `instance=~"db.*"`, name: "instance", typ: MatchRegexp, value: "db.*"},
+		{in: `instance!~db.*`, name: "instance", typ: MatchNotRegexp, value: "db.*"},
+		{in: `job="a \"quoted\" job"`, name: "job", typ: MatchEqual, value: `a "quoted" job`},
+		{in: `job=""`, name: "job", typ: MatchEqual, value: ""},
+		{in: `severity`, err: `invalid matcher "severity": missing operator`},
+		{in: `1abc="x"`, err: `invalid matcher "1abc=\"x\"": invalid label name "1abc"`},
+		{in: `severity!"x"`, err: `invalid matcher "severity!\"x\"": invalid operator`},
+		{in: `job="unterminated`, err: `invalid matcher "job=\"unterminated": invalid quoted value "unterminated`},
+		{in: `instance=~"^db.*"`, err: `invalid matcher "instance=~\"^db.*\"": regexp must not start with "^" or end with "$" as it is anchored implicitly`},
+		{in: `instance!~db.*$`, err: `invalid matcher "instance!~db.*$": regexp must not start with "^" or end with "$" as it is anchored implicitly`},
+		{in: `job="=b"`, name: "job", typ: MatchEqual, value: "=b"},
+		{in: `job==b`, err: `invalid matcher "job==b": unquoted value must not start with "="`},
+		{in: `job!=~b`, err: `invalid matcher "job!=~b": unquoted value must not start with "~"`},
+		{in: `job=~!b`, err: `invalid matcher "job=~!b": unquoted value must not start with "!"`},
+		{in: `job=~"(a"`, err: "invalid matcher \"job=~\\\"(a\\\"\": error parsing regexp: missing closing ): `^(?:(a)$`"},
+	}
+
+	for _, c := range cases {
+		m, err := ParseMatcher(c.in)
+		if c.err != "" {
+			if err == nil || err.Error() != c.err {
+				t.Errorf("Expected error %q parsing %q, got %v", c.err, c.in, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error parsing %q: %s", c.in, err)
+			continue
+		}
+		if m.Name != c.name || m.Type != c.typ || m.Value != c.value {
+			t.Errorf("Unexpected matcher parsing %q: %s", c.in, m)
+		}
+		if c.typ.IsRegex() && (m.Regexp.Regexp == nil || !m.Regexp.MatchString(c.value)) {
+			t.Errorf("Expected compiled regexp for %q", c.in)
+		}
+	}
+}
+
+func TestRouteMatchers(t *testing.T) {
+	cfg, err := Load(`
+route:
+  receiver: default
+  routes:
+  - receiver: default
+    matchers: ['severity="critical"', 'instance=~"db.*"']
+    match:
+      team: a
+
+receivers:
+- name: default
+  blackhole: true
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	ms := cfg.Route.Routes[0].Matchers
+	if len(ms) != 2 || ms[0].String() != `severity="critical"` || ms[1].String() != `instance=~"db.*"` {
+		t.Errorf("Unexpected matchers %v", ms)
+	}
+
+	expectLoadError(t, `
+route:
+  receiver: default
+  matchers: ['severity=="critical"']
+
+receivers:
+- name: default
+  blackhole: true
+`, `invalid matcher "severity==\"critical\"": invalid quoted value ="critical"`)
+}
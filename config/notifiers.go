@@ -15,23 +15,71 @@ package config
 
 import (
 	"fmt"
+	"net/mail"
+	"net/textproto"
+	"net/url"
+	"regexp"
 	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
 )
 
+// DefaultWebhookVersion is the version of the payload sent to webhooks,
+// which is the only one supported so far.
+const DefaultWebhookVersion = "2"
+
+// sendResolvedDefaults defines whether each type of notifier sends
+// notifications about resolved alerts unless send_resolved is configured.
+// It is keyed by the configuration key of the type in receivers. The
+// default configurations below take their options from here, so that the
+// defaults of all types can be compared in one place.
+var sendResolvedDefaults = map[string]bool{
+	"discord_configs":   true,
+	"email_configs":     false,
+	"hipchat_configs":   false,
+	"kafka_configs":     true,
+	"msteams_configs":   true,
+	"opsgenie_configs":  true,
+	"pagerduty_configs": true,
+	"slack_configs":     false,
+	"sns_configs":       true,
+	"telegram_configs":  true,
+	"victorops_configs": true,
+	"webhook_configs":   true,
+	"wechat_configs":    false,
+}
+
+// defaultNotifierConfig returns the default common options of the notifier
+// type with the given configuration key.
+func defaultNotifierConfig(key string) NotifierConfig {
+	sendResolved, ok := sendResolvedDefaults[key]
+	if !ok {
+		panic(fmt.Sprintf("no send_resolved default for %s", key))
+	}
+	return NotifierConfig{VSendResolved: sendResolved}
+}
+
 var (
 	// DefaultWebhookConfig defines default values for Webhook configurations.
 	DefaultWebhookConfig = WebhookConfig{
-		NotifierConfig: NotifierConfig{
-			VSendResolved: true,
-		},
+		NotifierConfig: defaultNotifierConfig("webhook_configs"),
+		Version:        DefaultWebhookVersion,
+		Retry:          DefaultRetryConfig,
+	}
+
+	// DefaultRetryConfig defines the default retry behavior, which retries
+	// until the notification times out.
+	DefaultRetryConfig = RetryConfig{
+		InitialInterval: Duration(500 * time.Millisecond),
+		MaxInterval:     Duration(time.Minute),
+		Multiplier:      1.5,
 	}
 
 	// DefaultEmailConfig defines default values for Email configurations.
 	DefaultEmailConfig = EmailConfig{
-		NotifierConfig: NotifierConfig{
-			VSendResolved: false,
-		},
-		HTML: `{{ template "email.default.html" . }}`,
+		NotifierConfig: defaultNotifierConfig("email_configs"),
+		HTML:           `{{ template "email.default.html" . }}`,
 	}
 
 	// DefaultEmailSubject defines the default Subject header of an Email.
@@ -39,12 +87,11 @@ var (
 
 	// DefaultPagerdutyConfig defines default values for PagerDuty configurations.
 	DefaultPagerdutyConfig = PagerdutyConfig{
-		NotifierConfig: NotifierConfig{
-			VSendResolved: true,
-		},
-		Description: `{{ template "pagerduty.default.description" .}}`,
-		Client:      `{{ template "pagerduty.default.client" . }}`,
-		ClientURL:   `{{ template "pagerduty.default.clientURL" . }}`,
+		NotifierConfig: defaultNotifierConfig("pagerduty_configs"),
+		Description:    `{{ template "pagerduty.default.description" .}}`,
+		Severity:       "error",
+		Client:         `{{ template "pagerduty.default.client" . }}`,
+		ClientURL:      `{{ template "pagerduty.default.clientURL" . }}`,
 		Details: map[string]string{
 			"firing":       `{{ template "pagerduty.default.instances" .Alerts.Firing }}`,
 			"resolved":     `{{ template "pagerduty.default.instances" .Alerts.Resolved }}`,
@@ -55,63 +102,175 @@ var (
 
 	// DefaultSlackConfig defines default values for Slack configurations.
 	DefaultSlackConfig = SlackConfig{
-		NotifierConfig: NotifierConfig{
-			VSendResolved: false,
-		},
-		Color:     `{{ if eq .Status "firing" }}danger{{ else }}good{{ end }}`,
-		Username:  `{{ template "slack.default.username" . }}`,
-		Title:     `{{ template "slack.default.title" . }}`,
-		TitleLink: `{{ template "slack.default.titlelink" . }}`,
-		Pretext:   `{{ template "slack.default.pretext" . }}`,
-		Text:      `{{ template "slack.default.text" . }}`,
-		Fallback:  `{{ template "slack.default.fallback" . }}`,
+		NotifierConfig: defaultNotifierConfig("slack_configs"),
+		Color:          `{{ if eq .Status "firing" }}danger{{ else }}good{{ end }}`,
+		Username:       `{{ template "slack.default.username" . }}`,
+		Title:          `{{ template "slack.default.title" . }}`,
+		TitleLink:      `{{ template "slack.default.titlelink" . }}`,
+		Pretext:        `{{ template "slack.default.pretext" . }}`,
+		Text:           `{{ template "slack.default.text" . }}`,
+		Fallback:       `{{ template "slack.default.fallback" . }}`,
 	}
 
 	// DefaultHipchatConfig defines default values for Hipchat configurations.
 	DefaultHipchatConfig = HipchatConfig{
-		NotifierConfig: NotifierConfig{
-			VSendResolved: false,
-		},
-		Color:         `{{ if eq .Status "firing" }}red{{ else }}green{{ end }}`,
-		From:          `{{ template "hipchat.default.from" . }}`,
-		Notify:        false,
-		Message:       `{{ template "hipchat.default.message" . }}`,
-		MessageFormat: `text`,
+		NotifierConfig: defaultNotifierConfig("hipchat_configs"),
+		Color:          `{{ if eq .Status "firing" }}red{{ else }}green{{ end }}`,
+		From:           `{{ template "hipchat.default.from" . }}`,
+		Notify:         false,
+		Message:        `{{ template "hipchat.default.message" . }}`,
+		MessageFormat:  `text`,
 	}
 
 	// DefaultOpsGenieConfig defines default values for OpsGenie configurations.
 	DefaultOpsGenieConfig = OpsGenieConfig{
-		NotifierConfig: NotifierConfig{
-			VSendResolved: true,
-		},
-		Description: `{{ template "opsgenie.default.description" . }}`,
-		Source:      `{{ template "opsgenie.default.source" . }}`,
+		NotifierConfig: defaultNotifierConfig("opsgenie_configs"),
+		Description:    `{{ template "opsgenie.default.description" . }}`,
+		Source:         `{{ template "opsgenie.default.source" . }}`,
 		// TODO: Add a details field with all the alerts.
 	}
+
+	// DefaultVictorOpsConfig defines default values for VictorOps configurations.
+	DefaultVictorOpsConfig = VictorOpsConfig{
+		NotifierConfig:    defaultNotifierConfig("victorops_configs"),
+		MessageType:       `CRITICAL`,
+		StateMessage:      `{{ template "__text_alert_list" .Alerts.Firing }}`,
+		EntityDisplayName: `{{ template "__subject" . }}`,
+	}
+
+	// DefaultWechatConfig defines default values for WeChat configurations.
+	DefaultWechatConfig = WechatConfig{
+		NotifierConfig: defaultNotifierConfig("wechat_configs"),
+		Message:        `{{ template "__subject" . }}`,
+	}
+
+	// DefaultMSTeamsConfig defines default values for Microsoft Teams configurations.
+	DefaultMSTeamsConfig = MSTeamsConfig{
+		NotifierConfig: defaultNotifierConfig("msteams_configs"),
+		Title:          `{{ template "__subject" . }}`,
+		Summary:        `{{ template "__subject" . }}`,
+		Text:           `{{ template "__text_alert_list" .Alerts.Firing }}`,
+	}
+
+	// DefaultDiscordConfig defines default values for Discord configurations.
+	DefaultDiscordConfig = DiscordConfig{
+		NotifierConfig: defaultNotifierConfig("discord_configs"),
+		Title:          `{{ template "__subject" . }}`,
+		Message:        `{{ template "__text_alert_list" .Alerts.Firing }}`,
+	}
+
+	// DefaultKafkaConfig defines default values for Kafka configurations.
+	DefaultKafkaConfig = KafkaConfig{
+		NotifierConfig: defaultNotifierConfig("kafka_configs"),
+	}
+
+	// DefaultSNSConfig defines default values for Amazon SNS configurations.
+	DefaultSNSConfig = SNSConfig{
+		NotifierConfig: defaultNotifierConfig("sns_configs"),
+		Subject:        `{{ template "__subject" . }}`,
+		Message:        `{{ template "__text_alert_list" .Alerts.Firing }}`,
+	}
+
+	// DefaultTelegramConfig defines default values for Telegram configurations.
+	DefaultTelegramConfig = TelegramConfig{
+		NotifierConfig: defaultNotifierConfig("telegram_configs"),
+		Message:        `{{ template "__subject" . }}`,
+	}
 )
 
 // NotifierConfig contains base options common across all notifier configurations.
 type NotifierConfig struct {
 	VSendResolved bool `yaml:"send_resolved"`
+	// The resolve timeout of the notifier, which overrides the global
+	// one if set.
+	VResolveTimeout *Duration `yaml:"resolve_timeout,omitempty"`
+	// Throttle limits the rate of notifications sent by the notifier.
+	Throttle ThrottleConfig `yaml:"throttle,omitempty"`
+	// The maximum number of alerts included in one notification. Further
+	// alerts are omitted from it, which the default templates mention.
+	// 0 means unlimited.
+	MaxAlerts int `yaml:"max_alerts,omitempty"`
+}
+
+// ThrottleConfig limits the rate of notifications. The zero value does not
+// limit it.
+type ThrottleConfig struct {
+	// MaxPerMinute is the number of notifications per minute the rate is
+	// limited to. 0 means unlimited.
+	MaxPerMinute int `yaml:"max_per_minute,omitempty"`
+	// Burst is the number of notifications that may be sent at once
+	// before the rate limit applies.
+	Burst int `yaml:"burst,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *ThrottleConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain ThrottleConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.MaxPerMinute < 0 {
+		return fmt.Errorf("max_per_minute must not be negative in throttle config")
+	}
+	if c.Burst < 0 {
+		return fmt.Errorf("burst must not be negative in throttle config")
+	}
+	return checkOverflow(c.XXX, "throttle config", c)
 }
 
 func (nc *NotifierConfig) SendResolved() bool {
 	return nc.VSendResolved
 }
 
+// ResolveTimeout returns the resolve timeout of the notifier. It is only
+// set after the configuration it is part of was loaded.
+func (nc *NotifierConfig) ResolveTimeout() time.Duration {
+	if nc.VResolveTimeout == nil {
+		return 0
+	}
+	return time.Duration(*nc.VResolveTimeout)
+}
+
 // EmailConfig configures notifications via mail.
 type EmailConfig struct {
 	NotifierConfig `yaml:",inline"`
 
-	// Email address to notify.
+	// Comma-separated email addresses to notify. Each of them may be a
+	// template.
 	To        string            `yaml:"to"`
 	From      string            `yaml:"from"`
-	Smarthost string            `yaml:"smarthost,omitempty"`
+	Smarthost HostPort          `yaml:"smarthost,omitempty"`
 	Headers   map[string]string `yaml:"headers"`
 	HTML      string            `yaml:"html"`
+	Text      string            `yaml:"text,omitempty"`
+	// The hostname sent in the SMTP HELO/EHLO command. The hostname of
+	// the machine is used if it is empty.
+	Hello string `yaml:"hello,omitempty"`
+
+	// SMTP authentication information.
+	AuthUsername string `yaml:"auth_username"`
+	AuthPassword Secret `yaml:"auth_password"`
+	AuthSecret   Secret `yaml:"auth_secret"`
+	AuthIdentity string `yaml:"auth_identity"`
+	// Whether STARTTLS must be used when connecting to the smarthost.
+	RequireTLS *bool `yaml:"require_tls,omitempty"`
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline"`
+
+	to *InlineTemplate
+}
+
+// ToTemplate returns the parsed template of the recipients. It returns
+// nil if To was changed after the configuration was loaded.
+func (c *EmailConfig) ToTemplate() *InlineTemplate {
+	if c.to == nil || c.to.Text != c.To {
+		return nil
+	}
+	return c.to
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -124,10 +283,22 @@ func (c *EmailConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if c.To == "" {
 		return fmt.Errorf("missing to address in email config")
 	}
+	for _, rcpt := range splitRecipients(c.To) {
+		if strings.Contains(rcpt, "{{") {
+			continue
+		}
+		if _, err := mail.ParseAddress(rcpt); err != nil {
+			return fmt.Errorf("invalid to address %q in email config: %s", strings.TrimSpace(rcpt), err)
+		}
+	}
+	c.to = &InlineTemplate{Text: c.To}
+	if err := c.to.parse(); err != nil {
+		return fmt.Errorf("invalid to template in email config: %s", err)
+	}
 	// Header names are case-insensitive, check for collisions.
 	normalizedHeaders := map[string]string{}
 	for h, v := range c.Headers {
-		normalized := strings.ToTitle(h)
+		normalized := textproto.CanonicalMIMEHeaderKey(h)
 		if _, ok := normalizedHeaders[normalized]; ok {
 			return fmt.Errorf("duplicate header %q in email config", normalized)
 		}
@@ -135,19 +306,65 @@ func (c *EmailConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	}
 	c.Headers = normalizedHeaders
 
-	return checkOverflow(c.XXX, "email config")
+	// Never send an empty body.
+	if c.HTML == "" && c.Text == "" {
+		c.HTML = DefaultEmailConfig.HTML
+	}
+
+	return checkOverflow(c.XXX, "email config", c)
+}
+
+// splitRecipients splits a list of recipients at the commas outside of
+// template actions.
+func splitRecipients(s string) []string {
+	var (
+		rcpts []string
+		depth int
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "{{"):
+			depth++
+			i++
+		case strings.HasPrefix(s[i:], "}}") && depth > 0:
+			depth--
+			i++
+		case s[i] == ',' && depth == 0:
+			rcpts = append(rcpts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(rcpts, s[start:])
 }
 
 // PagerdutyConfig configures notifications via PagerDuty.
 type PagerdutyConfig struct {
 	NotifierConfig `yaml:",inline"`
 
-	ServiceKey  Secret            `yaml:"service_key"`
-	URL         string            `yaml:"url"`
+	// Exactly one of ServiceKey for the Events API v1 and RoutingKey for
+	// the Events API v2 must be set.
+	ServiceKey  Secret            `yaml:"service_key,omitempty"`
+	RoutingKey  Secret            `yaml:"routing_key,omitempty"`
+	URL         *URL              `yaml:"url"`
 	Client      string            `yaml:"client"`
 	ClientURL   string            `yaml:"client_url"`
 	Description string            `yaml:"description"`
 	Details     map[string]string `yaml:"details"`
+	// An inline template of the description, which takes precedence over
+	// description.
+	TextTemplate *InlineTemplate `yaml:"text_template,omitempty"`
+
+	// Fields only supported by the Events API v2.
+	Severity  string           `yaml:"severity,omitempty"`
+	Class     string           `yaml:"class,omitempty"`
+	Component string           `yaml:"component,omitempty"`
+	Group     string           `yaml:"group,omitempty"`
+	Links     []PagerdutyLink  `yaml:"links,omitempty"`
+	Images    []PagerdutyImage `yaml:"images,omitempty"`
+
+	// The HTTP client's configuration.
+	HTTPConfig *HTTPClientConfig `yaml:"http_config,omitempty"`
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline"`
@@ -160,22 +377,68 @@ func (c *PagerdutyConfig) UnmarshalYAML(unmarshal func(interface{}) error) error
 	if err := unmarshal((*plain)(c)); err != nil {
 		return err
 	}
-	if c.ServiceKey == "" {
-		return fmt.Errorf("missing service key in PagerDuty config")
+	if c.ServiceKey == "" && c.RoutingKey == "" {
+		return fmt.Errorf("missing service or routing key in PagerDuty config")
+	}
+	if c.ServiceKey != "" && c.RoutingKey != "" {
+		return fmt.Errorf("at most one of service_key and routing_key must be configured")
+	}
+	return checkOverflow(c.XXX, "pagerduty config", c)
+}
+
+// PagerdutyLink is a link attached to a PagerDuty incident.
+type PagerdutyLink struct {
+	Href string `yaml:"href"`
+	Text string `yaml:"text,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (l *PagerdutyLink) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain PagerdutyLink
+	if err := unmarshal((*plain)(l)); err != nil {
+		return err
+	}
+	if l.Href == "" {
+		return fmt.Errorf("missing href in PagerDuty link")
+	}
+	return checkOverflow(l.XXX, "pagerduty link", l)
+}
+
+// PagerdutyImage is an image attached to a PagerDuty incident.
+type PagerdutyImage struct {
+	Src  string `yaml:"src"`
+	Alt  string `yaml:"alt,omitempty"`
+	Href string `yaml:"href,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (i *PagerdutyImage) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain PagerdutyImage
+	if err := unmarshal((*plain)(i)); err != nil {
+		return err
+	}
+	if i.Src == "" {
+		return fmt.Errorf("missing src in PagerDuty image")
 	}
-	return checkOverflow(c.XXX, "pagerduty config")
+	return checkOverflow(i.XXX, "pagerduty image", i)
 }
 
 // SlackConfig configures notifications via Slack.
 type SlackConfig struct {
 	NotifierConfig `yaml:",inline"`
 
-	APIURL Secret `yaml:"api_url"`
+	APIURL *SecretURL `yaml:"api_url"`
 
 	// Slack channel override, (like #other-channel or @username).
-	Channel  string `yaml:"channel"`
-	Username string `yaml:"username"`
-	Color    string `yaml:"color"`
+	Channel  string          `yaml:"channel"`
+	Username string          `yaml:"username"`
+	Color    ColorOrTemplate `yaml:"color"`
 
 	Title     string `yaml:"title"`
 	TitleLink string `yaml:"title_link"`
@@ -183,10 +446,49 @@ type SlackConfig struct {
 	Text      string `yaml:"text"`
 	Fallback  string `yaml:"fallback"`
 
+	// Inline templates of the title and text, which take precedence over
+	// title and text.
+	TitleTemplate *InlineTemplate `yaml:"title_template,omitempty"`
+	TextTemplate  *InlineTemplate `yaml:"text_template,omitempty"`
+
+	Fields  []*SlackField  `yaml:"fields,omitempty"`
+	Actions []*SlackAction `yaml:"actions,omitempty"`
+
+	// Blocks are Block Kit layout blocks sent instead of the text. They
+	// are kept as written, in order, with templates in all string values.
+	Blocks []yaml.MapSlice `yaml:"blocks,omitempty"`
+
+	// The HTTP client's configuration.
+	HTTPConfig *HTTPClientConfig `yaml:"http_config,omitempty"`
+
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline"`
 }
 
+var slackColorRE = regexp.MustCompile(`^(good|warning|danger|#[0-9a-fA-F]{6})$`)
+
+// ColorOrTemplate is the color of a Slack attachment. It is either one of
+// good, warning and danger, a hex color code like #439FE0 or a template
+// that is only executed when notifying.
+type ColorOrTemplate string
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *ColorOrTemplate) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	if strings.Contains(s, "{{") {
+		if _, err := parseTemplate("", s); err != nil {
+			return fmt.Errorf("invalid color template %q in Slack config: %s", s, err)
+		}
+	} else if s != "" && !slackColorRE.MatchString(s) {
+		return fmt.Errorf("invalid color %q in Slack config, must be good, warning, danger, a hex color code #rrggbb or a template", s)
+	}
+	*c = ColorOrTemplate(s)
+	return nil
+}
+
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
 func (c *SlackConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	*c = DefaultSlackConfig
@@ -197,14 +499,84 @@ func (c *SlackConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if c.Channel == "" {
 		return fmt.Errorf("missing channel in Slack config")
 	}
-	return checkOverflow(c.XXX, "slack config")
+	if c.Blocks != nil {
+		if len(c.Blocks) == 0 {
+			return fmt.Errorf("empty blocks in Slack config")
+		}
+		// The default text is replaced by the blocks.
+		if (c.Text != "" && c.Text != DefaultSlackConfig.Text) || c.TextTemplate != nil {
+			return fmt.Errorf("at most one of text, text_template and blocks must be configured in Slack config")
+		}
+		c.Text = ""
+		for i, b := range c.Blocks {
+			if t, _ := mapValue(b, "type").(string); t == "" {
+				return fmt.Errorf("missing type of block %d in Slack config", i)
+			}
+		}
+	}
+	return checkOverflow(c.XXX, "slack config", c)
+}
+
+// SlackField is displayed in a table inside the message attachment.
+type SlackField struct {
+	Title string `yaml:"title"`
+	Value string `yaml:"value"`
+	Short bool   `yaml:"short,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (f *SlackField) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain SlackField
+	if err := unmarshal((*plain)(f)); err != nil {
+		return err
+	}
+	if f.Title == "" {
+		return fmt.Errorf("missing title in Slack field")
+	}
+	if f.Value == "" {
+		return fmt.Errorf("missing value in Slack field")
+	}
+	return checkOverflow(f.XXX, "slack field", f)
+}
+
+// SlackAction is an interactive element, such as a button, attached to a
+// Slack message.
+type SlackAction struct {
+	Type  string `yaml:"type"`
+	Text  string `yaml:"text"`
+	URL   string `yaml:"url,omitempty"`
+	Style string `yaml:"style,omitempty"`
+	Name  string `yaml:"name,omitempty"`
+	Value string `yaml:"value,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (a *SlackAction) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*a = SlackAction{Type: "button"}
+	type plain SlackAction
+	if err := unmarshal((*plain)(a)); err != nil {
+		return err
+	}
+	if a.Text == "" {
+		return fmt.Errorf("missing text in Slack action")
+	}
+	if a.URL == "" && a.Name == "" {
+		return fmt.Errorf("missing url or name in Slack action")
+	}
+	return checkOverflow(a.XXX, "slack action", a)
 }
 
 // HipchatConfig configures notifications via Hipchat.
 type HipchatConfig struct {
 	NotifierConfig `yaml:",inline"`
 
-	APIURL        string `yaml:"api_url"`
+	APIURL        *URL   `yaml:"api_url"`
 	AuthToken     Secret `yaml:"auth_token"`
 	RoomID        string `yaml:"room_id"`
 	From          string `yaml:"from"`
@@ -213,6 +585,9 @@ type HipchatConfig struct {
 	MessageFormat string `yaml:"message_format"`
 	Color         string `yaml:"color"`
 
+	// The HTTP client's configuration.
+	HTTPConfig *HTTPClientConfig `yaml:"http_config,omitempty"`
+
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline"`
 }
@@ -228,7 +603,7 @@ func (c *HipchatConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return fmt.Errorf("missing room id in Hipchat config")
 	}
 
-	return checkOverflow(c.XXX, "hipchat config")
+	return checkOverflow(c.XXX, "hipchat config", c)
 }
 
 // WebhookConfig configures notifications via a generic webhook.
@@ -236,7 +611,17 @@ type WebhookConfig struct {
 	NotifierConfig `yaml:",inline"`
 
 	// URL to send POST request to.
-	URL string `yaml:"url"`
+	URL *URL `yaml:"url"`
+	// The version of the payload sent to the URL.
+	Version string `yaml:"version,omitempty"`
+	// How failed requests are retried.
+	Retry RetryConfig `yaml:"retry"`
+	// An inline template of a message sent as the text field of the
+	// payload.
+	TextTemplate *InlineTemplate `yaml:"text_template,omitempty"`
+
+	// The HTTP client's configuration.
+	HTTPConfig *HTTPClientConfig `yaml:"http_config,omitempty"`
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline"`
@@ -249,26 +634,83 @@ func (c *WebhookConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if err := unmarshal((*plain)(c)); err != nil {
 		return err
 	}
-	if c.URL == "" {
+	if c.URL == nil {
 		return fmt.Errorf("missing URL in webhook config")
 	}
-	return checkOverflow(c.XXX, "slack config")
+	if c.Version != DefaultWebhookVersion {
+		return fmt.Errorf("unknown version %q in webhook config, must be %q", c.Version, DefaultWebhookVersion)
+	}
+	return checkOverflow(c.XXX, "webhook config", c)
+}
+
+// RetryConfig configures the exponential backoff between attempts to send
+// a notification. The interval starts at InitialInterval and is multiplied
+// by Multiplier after each attempt, up to MaxInterval.
+type RetryConfig struct {
+	// The maximum number of retries. 0 means retrying until the
+	// notification times out.
+	MaxRetries      int      `yaml:"max_retries"`
+	InitialInterval Duration `yaml:"initial_interval"`
+	MaxInterval     Duration `yaml:"max_interval"`
+	Multiplier      float64  `yaml:"multiplier"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *RetryConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultRetryConfig
+	type plain RetryConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.MaxRetries < 0 {
+		return fmt.Errorf("max_retries must not be negative in retry config")
+	}
+	if c.InitialInterval <= 0 {
+		return fmt.Errorf("initial_interval must be positive in retry config")
+	}
+	if c.MaxInterval < c.InitialInterval {
+		return fmt.Errorf("max_interval must not be less than initial_interval in retry config")
+	}
+	if c.Multiplier < 1 {
+		return fmt.Errorf("multiplier must be at least 1 in retry config")
+	}
+	return checkOverflow(c.XXX, "retry config", c)
 }
 
 // OpsGenieConfig configures notifications via OpsGenie.
 type OpsGenieConfig struct {
 	NotifierConfig `yaml:",inline"`
 
-	APIKey      Secret            `yaml:"api_key"`
-	APIHost     string            `yaml:"api_host"`
-	Description string            `yaml:"description"`
-	Source      string            `yaml:"source"`
-	Details     map[string]string `yaml:"details"`
+	APIKey      Secret                    `yaml:"api_key"`
+	APIHost     *URL                      `yaml:"api_host"`
+	Description string                    `yaml:"description"`
+	Source      string                    `yaml:"source"`
+	Details     map[string]string         `yaml:"details"`
+	Priority    string                    `yaml:"priority,omitempty"`
+	Tags        CommaSeparatedList        `yaml:"tags,omitempty"`
+	Note        string                    `yaml:"note,omitempty"`
+	Responders  []OpsGenieConfigResponder `yaml:"responders,omitempty"`
+	Entity      string                    `yaml:"entity,omitempty"`
+	Actions     CommaSeparatedList        `yaml:"actions,omitempty"`
+	// Alias is the key OpsGenie deduplicates alerts by. It defaults to the
+	// group key, so that each group is a single OpsGenie alert.
+	Alias string `yaml:"alias,omitempty"`
+
+	// The HTTP client's configuration.
+	HTTPConfig *HTTPClientConfig `yaml:"http_config,omitempty"`
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline"`
 }
 
+var (
+	opsGeniePriorityRE     = regexp.MustCompile(`^P[1-5]$`)
+	opsGenieResponderTypes = map[string]struct{}{"team": {}, "user": {}, "escalation": {}, "schedule": {}}
+)
+
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
 func (c *OpsGenieConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	*c = DefaultOpsGenieConfig
@@ -279,5 +721,370 @@ func (c *OpsGenieConfig) UnmarshalYAML(unmarshal func(interface{}) error) error
 	if c.APIKey == "" {
 		return fmt.Errorf("missing API key in OpsGenie config")
 	}
-	return checkOverflow(c.XXX, "opsgenie config")
+	// Templated priorities can only be checked when sending notifications.
+	if c.Priority != "" && !strings.Contains(c.Priority, "{{") && !opsGeniePriorityRE.MatchString(c.Priority) {
+		return fmt.Errorf("invalid priority %q in OpsGenie config, must be one of P1 to P5", c.Priority)
+	}
+	for k := range c.Details {
+		if strings.TrimSpace(k) == "" {
+			return fmt.Errorf("empty details key in OpsGenie config")
+		}
+	}
+	for _, r := range c.Responders {
+		if _, ok := opsGenieResponderTypes[r.Type]; !ok {
+			return fmt.Errorf("invalid responder type %q in OpsGenie config, must be one of team, user, escalation or schedule", r.Type)
+		}
+		n := 0
+		for _, v := range []string{r.ID, r.Name, r.Username} {
+			if v != "" {
+				n++
+			}
+		}
+		if n != 1 {
+			return fmt.Errorf("exactly one of id, name and username must be configured for OpsGenie responder")
+		}
+	}
+	return checkOverflow(c.XXX, "opsgenie config", c)
+}
+
+// OpsGenieConfigResponder is a team, user, escalation or schedule that
+// is responsible for an OpsGenie alert.
+type OpsGenieConfigResponder struct {
+	// Exactly one of ID, Name and Username must be set.
+	ID       string `yaml:"id,omitempty"`
+	Name     string `yaml:"name,omitempty"`
+	Username string `yaml:"username,omitempty"`
+
+	// One of team, user, escalation or schedule.
+	Type string `yaml:"type"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (r *OpsGenieConfigResponder) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain OpsGenieConfigResponder
+	if err := unmarshal((*plain)(r)); err != nil {
+		return err
+	}
+	return checkOverflow(r.XXX, "opsgenie responder config", r)
+}
+
+// CommaSeparatedList is a list of strings that can be written either as
+// a YAML list or as a single comma-separated string.
+type CommaSeparatedList []string
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (l *CommaSeparatedList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		*l = list
+		return nil
+	}
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	*l = nil
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			*l = append(*l, e)
+		}
+	}
+	return nil
+}
+
+// VictorOpsConfig configures notifications via VictorOps.
+type VictorOpsConfig struct {
+	NotifierConfig `yaml:",inline"`
+
+	APIKey            Secret `yaml:"api_key"`
+	APIURL            *URL   `yaml:"api_url"`
+	RoutingKey        string `yaml:"routing_key"`
+	MessageType       string `yaml:"message_type"`
+	StateMessage      string `yaml:"state_message"`
+	EntityDisplayName string `yaml:"entity_display_name"`
+
+	// The HTTP client's configuration.
+	HTTPConfig *HTTPClientConfig `yaml:"http_config,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *VictorOpsConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultVictorOpsConfig
+	type plain VictorOpsConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.RoutingKey == "" {
+		return fmt.Errorf("missing routing key in VictorOps config")
+	}
+	return checkOverflow(c.XXX, "victorops config", c)
+}
+
+// WechatConfig configures notifications via WeChat Work.
+type WechatConfig struct {
+	NotifierConfig `yaml:",inline"`
+
+	APISecret Secret `yaml:"api_secret"`
+	CorpID    string `yaml:"corp_id"`
+	APIURL    *URL   `yaml:"api_url"`
+
+	// At least one of the recipient fields must be set.
+	ToUser  string `yaml:"to_user"`
+	ToParty string `yaml:"to_party"`
+	ToTag   string `yaml:"to_tag"`
+
+	AgentID string `yaml:"agent_id"`
+	Message string `yaml:"message"`
+
+	// The HTTP client's configuration.
+	HTTPConfig *HTTPClientConfig `yaml:"http_config,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *WechatConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultWechatConfig
+	type plain WechatConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.ToUser == "" && c.ToParty == "" && c.ToTag == "" {
+		return fmt.Errorf("missing recipient in WeChat config, one of to_user, to_party or to_tag must be set")
+	}
+	return checkOverflow(c.XXX, "wechat config", c)
+}
+
+// telegramParseModes are the formatting options of Telegram messages. An
+// empty parse mode sends the message as plain text.
+var telegramParseModes = map[string]struct{}{
+	"":           {},
+	"MarkdownV2": {},
+	"HTML":       {},
+}
+
+// TelegramConfig configures notifications via a Telegram bot.
+type TelegramConfig struct {
+	NotifierConfig `yaml:",inline"`
+
+	BotToken Secret `yaml:"bot_token"`
+	ChatID   int64  `yaml:"chat_id"`
+	APIURL   *URL   `yaml:"api_url"`
+
+	Message   string `yaml:"message"`
+	ParseMode string `yaml:"parse_mode,omitempty"`
+
+	// DisableNotifications delivers messages silently, without a sound
+	// on the devices of the chat members.
+	DisableNotifications bool `yaml:"disable_notifications,omitempty"`
+
+	// The HTTP client's configuration.
+	HTTPConfig *HTTPClientConfig `yaml:"http_config,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *TelegramConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultTelegramConfig
+	type plain TelegramConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.BotToken == "" {
+		return fmt.Errorf("missing bot_token in Telegram config")
+	}
+	if c.ChatID == 0 {
+		return fmt.Errorf("missing chat_id in Telegram config")
+	}
+	if _, ok := telegramParseModes[c.ParseMode]; !ok {
+		return fmt.Errorf("unknown parse_mode %q in Telegram config, must be MarkdownV2, HTML or empty", c.ParseMode)
+	}
+	return checkOverflow(c.XXX, "telegram config", c)
+}
+
+// MSTeamsConfig configures notifications via a Microsoft Teams incoming
+// webhook.
+type MSTeamsConfig struct {
+	NotifierConfig `yaml:",inline"`
+
+	// WebhookURL contains a token authorizing posts to the channel and
+	// is therefore handled as a secret.
+	WebhookURL Secret `yaml:"webhook_url"`
+
+	Title   string `yaml:"title"`
+	Summary string `yaml:"summary"`
+	Text    string `yaml:"text"`
+
+	// The HTTP client's configuration.
+	HTTPConfig *HTTPClientConfig `yaml:"http_config,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *MSTeamsConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultMSTeamsConfig
+	type plain MSTeamsConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.WebhookURL == "" {
+		return fmt.Errorf("missing webhook_url in MS Teams config")
+	}
+	// The URL must not be part of the error as it is a secret.
+	u, err := url.Parse(string(c.WebhookURL))
+	if err != nil || !u.IsAbs() || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid webhook_url in MS Teams config, must be an absolute https URL")
+	}
+	return checkOverflow(c.XXX, "msteams config", c)
+}
+
+// DiscordConfig configures notifications via a Discord webhook.
+type DiscordConfig struct {
+	NotifierConfig `yaml:",inline"`
+
+	// WebhookURL contains a token authorizing posts to the channel and
+	// is therefore handled as a secret.
+	WebhookURL Secret `yaml:"webhook_url"`
+
+	Title   string `yaml:"title"`
+	Message string `yaml:"message"`
+
+	// Username and AvatarURL override the defaults of the webhook.
+	Username  string `yaml:"username,omitempty"`
+	AvatarURL string `yaml:"avatar_url,omitempty"`
+
+	// The HTTP client's configuration.
+	HTTPConfig *HTTPClientConfig `yaml:"http_config,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// discordHosts are the hosts serving Discord webhooks. Their subdomains,
+// such as canary.discord.com, serve them as well.
+var discordHosts = []string{"discord.com", "discordapp.com"}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *DiscordConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultDiscordConfig
+	type plain DiscordConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.WebhookURL == "" {
+		return fmt.Errorf("missing webhook_url in Discord config")
+	}
+	// The URL must not be part of the error as it is a secret.
+	u, err := url.Parse(string(c.WebhookURL))
+	if err != nil || !u.IsAbs() || u.Scheme != "https" || !isDiscordHost(u.Hostname()) {
+		return fmt.Errorf("invalid webhook_url in Discord config, must be an absolute https URL of %s", strings.Join(discordHosts, " or "))
+	}
+	if c.Message == "" {
+		return fmt.Errorf("missing message in Discord config")
+	}
+	return checkOverflow(c.XXX, "discord config", c)
+}
+
+func isDiscordHost(host string) bool {
+	for _, h := range discordHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// KafkaConfig configures notifications posted to a Kafka topic through
+// an HTTP REST proxy. The payload is the same as the one of webhooks.
+type KafkaConfig struct {
+	NotifierConfig `yaml:",inline"`
+
+	// RESTProxyURL is the base URL of the REST proxy.
+	RESTProxyURL *URL `yaml:"rest_proxy_url"`
+	// Topic is the topic the records are produced to.
+	Topic string `yaml:"topic"`
+	// KeyTemplate is an inline template of the key of the records. The
+	// records have no key if it is not set.
+	KeyTemplate *InlineTemplate `yaml:"key_template,omitempty"`
+
+	// The HTTP client's configuration.
+	HTTPConfig *HTTPClientConfig `yaml:"http_config,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// kafkaTopicRE matches the names Kafka accepts for topics.
+var kafkaTopicRE = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,249}$`)
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *KafkaConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultKafkaConfig
+	type plain KafkaConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.RESTProxyURL == nil {
+		return fmt.Errorf("missing rest_proxy_url in Kafka config")
+	}
+	if c.Topic == "" {
+		return fmt.Errorf("missing topic in Kafka config")
+	}
+	if !kafkaTopicRE.MatchString(c.Topic) || c.Topic == "." || c.Topic == ".." {
+		return fmt.Errorf("invalid topic %q in Kafka config, must be at most 249 letters, digits, '.', '_' or '-'", c.Topic)
+	}
+	return checkOverflow(c.XXX, "kafka config", c)
+}
+
+// SNSConfig configures notifications via Amazon SNS.
+type SNSConfig struct {
+	NotifierConfig `yaml:",inline"`
+
+	// Exactly one of the destinations must be set.
+	TopicARN    string `yaml:"topic_arn,omitempty"`
+	PhoneNumber string `yaml:"phone_number,omitempty"`
+	TargetARN   string `yaml:"target_arn,omitempty"`
+
+	Subject    string            `yaml:"subject"`
+	Message    string            `yaml:"message"`
+	Attributes map[string]string `yaml:"attributes,omitempty"`
+
+	// SigV4 configures the signing of requests to the SNS API.
+	SigV4 *SigV4Config `yaml:"sigv4,omitempty"`
+
+	// The HTTP client's configuration.
+	HTTPConfig *HTTPClientConfig `yaml:"http_config,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *SNSConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultSNSConfig
+	type plain SNSConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	n := 0
+	for _, dest := range []string{c.TopicARN, c.PhoneNumber, c.TargetARN} {
+		if dest != "" {
+			n++
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("exactly one of topic_arn, phone_number and target_arn must be set in SNS config")
+	}
+	return checkOverflow(c.XXX, "sns config", c)
 }
@@ -0,0 +1,1319 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// loadReceiver loads a config consisting of the given global section and a
+// single receiver named "default" and returns the receiver.
+func loadReceiver(t *testing.T, global, receiver string) *Receiver {
+	cfg, err := Load(configWithReceiver(global, receiver))
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	return cfg.Receivers[0]
+}
+
+// configWithReceiver returns a config consisting of the given global
+// section and a single receiver named "default".
+func configWithReceiver(global, receiver string) string {
+	return "global:\n" + global + `
+route:
+  receiver: default
+
+receivers:
+- name: default
+` + receiver
+}
+
+// expectLoadError fails the test if the config does not fail to load with
+// an error containing msg.
+func expectLoadError(t *testing.T, in, msg string) {
+	_, err := Load(in)
+	if err == nil {
+		t.Errorf("Expected error containing %q, got none", msg)
+		return
+	}
+	if !strings.Contains(err.Error(), msg) {
+		t.Errorf("Expected error containing %q, got %q", msg, err)
+	}
+}
+
+func TestSendResolvedDefaults(t *testing.T) {
+	// A minimal valid configuration for each type of notifier.
+	notifiers := map[string]string{
+		"discord_configs":   "webhook_url: https://discord.com/api/webhooks/1/token",
+		"email_configs":     "to: ops@example.com",
+		"hipchat_configs":   "room_id: 1",
+		"kafka_configs":     "rest_proxy_url: http://kafka-rest.example.com:8082/\n    topic: alerts",
+		"msteams_configs":   "webhook_url: https://example.webhook.office.com/webhookb2/token",
+		"opsgenie_configs":  "api_key: key",
+		"pagerduty_configs": "service_key: key",
+		"slack_configs":     "channel: '#ops'",
+		"sns_configs":       "topic_arn: 'arn:aws:sns:eu-west-1:123456789012:alerts'",
+		"telegram_configs":  "bot_token: token\n    chat_id: 1",
+		"victorops_configs": "routing_key: ops",
+		"webhook_configs":   "url: http://example.com/",
+		"wechat_configs":    "to_user: ops",
+	}
+	global := `
+  smtp_smarthost: localhost:25
+  smtp_from: alertmanager@example.com
+  slack_api_url: http://slack.example.com/
+  hipchat_auth_token: token
+  victorops_api_key: key
+  wechat_api_secret: secret
+  wechat_corp_id: corp
+`
+
+	rt := reflect.TypeOf(Receiver{})
+	for i := 0; i < rt.NumField(); i++ {
+		key := strings.Split(rt.Field(i).Tag.Get("yaml"), ",")[0]
+		if !strings.HasSuffix(key, "_configs") {
+			continue
+		}
+		def, ok := sendResolvedDefaults[key]
+		if !ok {
+			t.Errorf("No send_resolved default for %s", key)
+			continue
+		}
+		conf, ok := notifiers[key]
+		if !ok {
+			t.Errorf("No test configuration for %s", key)
+			continue
+		}
+
+		for _, c := range []struct {
+			in   string
+			want bool
+		}{
+			{in: conf, want: def},
+			{in: fmt.Sprintf("%s\n    send_resolved: %t", conf, !def), want: !def},
+		} {
+			rcv := loadReceiver(t, global, "  "+key+":\n  - "+c.in+"\n")
+			ncs := rcv.notifierConfigs()
+			if len(ncs) != 1 {
+				t.Fatalf("Expected a single notifier for %s, got %d", key, len(ncs))
+			}
+			if got := ncs[0].SendResolved(); got != c.want {
+				t.Errorf("Expected send_resolved %t for %s config %q, got %t", c.want, key, c.in, got)
+			}
+		}
+	}
+}
+
+func TestVictorOpsConfig(t *testing.T) {
+	rcv := loadReceiver(t, `
+  victorops_api_key: secret-key
+`, `
+  victorops_configs:
+  - routing_key: team-a
+  - routing_key: team-b
+    api_key: own-key
+    api_url: http://victorops.example.com/alert
+`)
+
+	voc := rcv.VictorOpsConfigs[0]
+	if voc.APIKey != "secret-key" {
+		t.Errorf("Expected global API key, got %q", voc.APIKey)
+	}
+	if voc.APIURL != DefaultGlobalConfig.VictorOpsAPIURL {
+		t.Errorf("Expected global API URL, got %q", voc.APIURL)
+	}
+	if voc.MessageType != "CRITICAL" {
+		t.Errorf("Expected default message type, got %q", voc.MessageType)
+	}
+
+	voc = rcv.VictorOpsConfigs[1]
+	if voc.APIKey != "own-key" {
+		t.Errorf("Expected receiver API key, got %q", voc.APIKey)
+	}
+	if voc.APIURL.String() != "http://victorops.example.com/alert/" {
+		t.Errorf("Expected normalized receiver API URL, got %q", voc.APIURL.String())
+	}
+
+	expectLoadError(t, configWithReceiver("", `
+  victorops_configs:
+  - api_key: secret-key
+`), "missing routing key in VictorOps config")
+
+	expectLoadError(t, configWithReceiver("", `
+  victorops_configs:
+  - routing_key: team-a
+`), "no global VictorOps API Key set")
+}
+
+func TestWechatConfig(t *testing.T) {
+	rcv := loadReceiver(t, `
+  wechat_api_secret: secret
+  wechat_corp_id: corp
+`, `
+  wechat_configs:
+  - to_party: ops
+  - to_user: alice
+    corp_id: other-corp
+`)
+
+	wcc := rcv.WechatConfigs[0]
+	if wcc.APISecret != "secret" || wcc.CorpID != "corp" {
+		t.Errorf("Expected global API secret and corp ID, got %q and %q", wcc.APISecret, wcc.CorpID)
+	}
+	if wcc.APIURL != DefaultGlobalConfig.WechatAPIURL {
+		t.Errorf("Expected global API URL, got %q", wcc.APIURL)
+	}
+	if rcv.WechatConfigs[1].CorpID != "other-corp" {
+		t.Errorf("Expected receiver corp ID, got %q", rcv.WechatConfigs[1].CorpID)
+	}
+
+	expectLoadError(t, configWithReceiver(`
+  wechat_api_secret: secret
+  wechat_corp_id: corp
+`, `
+  wechat_configs:
+  - agent_id: "1"
+`), "missing recipient in WeChat config")
+
+	expectLoadError(t, configWithReceiver(`
+  wechat_corp_id: corp
+`, `
+  wechat_configs:
+  - to_tag: ops
+`), "no global WeChat API Secret set")
+}
+
+func TestTelegramConfig(t *testing.T) {
+	rcv := loadReceiver(t, "", `
+  telegram_configs:
+  - bot_token: secret
+    chat_id: -1001234567890
+  - bot_token: secret
+    chat_id: 42
+    api_url: http://telegram.example.com
+    parse_mode: MarkdownV2
+    disable_notifications: true
+`)
+
+	tc := rcv.TelegramConfigs[0]
+	if tc.APIURL != DefaultGlobalConfig.TelegramAPIURL {
+		t.Errorf("Expected global API URL, got %q", tc.APIURL)
+	}
+	if tc.ChatID != -1001234567890 {
+		t.Errorf("Expected group chat ID, got %d", tc.ChatID)
+	}
+	if !tc.SendResolved() || tc.ParseMode != "" || tc.DisableNotifications {
+		t.Errorf("Expected default options, got %+v", tc)
+	}
+
+	tc = rcv.TelegramConfigs[1]
+	if tc.APIURL.String() != "http://telegram.example.com/" {
+		t.Errorf("Expected normalized receiver API URL, got %q", tc.APIURL.String())
+	}
+	if tc.ParseMode != "MarkdownV2" || !tc.DisableNotifications {
+		t.Errorf("Expected receiver options, got %+v", tc)
+	}
+
+	cfg, err := Load(configWithReceiver(`
+  telegram_api_url: http://telegram.internal/
+`, `
+  telegram_configs:
+  - bot_token: secret
+    chat_id: 42
+`))
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	if got := cfg.Receivers[0].TelegramConfigs[0].APIURL.String(); got != "http://telegram.internal/" {
+		t.Errorf("Expected configured global API URL, got %q", got)
+	}
+
+	for conf, err := range map[string]string{
+		"chat_id: 42":                                                   "missing bot_token in Telegram config",
+		"bot_token: secret":                                             "missing chat_id in Telegram config",
+		"bot_token: secret\n    chat_id: 0":                             "missing chat_id in Telegram config",
+		"bot_token: secret\n    chat_id: abc":                           "cannot unmarshal",
+		"{bot_token: secret, chat_id: 1, parse_mode: Markdown}":         `unknown parse_mode "Markdown" in Telegram config`,
+		"{bot_token: secret, chat_id: 1, api_url: 'telegram.internal'}": "invalid URL",
+	} {
+		expectLoadError(t, configWithReceiver("", `
+  telegram_configs:
+  - `+conf+`
+`), err)
+	}
+}
+
+func TestMSTeamsConfig(t *testing.T) {
+	const webhookURL = "https://example.webhook.office.com/webhookb2/token-1234"
+
+	cfg, err := Load(configWithReceiver("", `
+  msteams_configs:
+  - webhook_url: `+webhookURL+`
+  - webhook_url: `+webhookURL+`
+    title: '{{ .CommonLabels.alertname }}'
+    send_resolved: false
+`))
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	mtc := cfg.Receivers[0].MSTeamsConfigs[0]
+	if mtc.WebhookURL != webhookURL {
+		t.Errorf("Expected webhook URL %q, got %q", webhookURL, mtc.WebhookURL)
+	}
+	if mtc.Title != DefaultMSTeamsConfig.Title || mtc.Text != DefaultMSTeamsConfig.Text || !mtc.SendResolved() {
+		t.Errorf("Expected default options, got %+v", mtc)
+	}
+	mtc = cfg.Receivers[0].MSTeamsConfigs[1]
+	if mtc.Title != "{{ .CommonLabels.alertname }}" || mtc.SendResolved() {
+		t.Errorf("Expected receiver options, got %+v", mtc)
+	}
+
+	if s := cfg.String(); strings.Contains(s, "token-1234") {
+		t.Errorf("Config string contains webhook URL:\n%s", s)
+	}
+	cfg.original = ""
+	if s := cfg.String(); strings.Contains(s, "token-1234") {
+		t.Errorf("Marshaled config string contains webhook URL:\n%s", s)
+	}
+
+	for webhookURL, err := range map[string]string{
+		`""`: "missing webhook_url in MS Teams config",
+		"http://example.webhook.office.com/webhookb2/token-1234": "must be an absolute https URL",
+		"/webhookb2/token-1234":                                  "must be an absolute https URL",
+		"https:///webhookb2/token-1234":                          "must be an absolute https URL",
+		"https://exa mple.com/token-1234":                        "must be an absolute https URL",
+	} {
+		in := configWithReceiver("", `
+  msteams_configs:
+  - webhook_url: `+webhookURL+`
+`)
+		expectLoadError(t, in, err)
+		if _, lerr := Load(in); lerr != nil && strings.Contains(lerr.Error(), "token-1234") {
+			t.Errorf("Error reveals webhook URL: %s", lerr)
+		}
+	}
+}
+
+func TestDiscordConfig(t *testing.T) {
+	const webhookURL = "https://discord.com/api/webhooks/1234/token-1234"
+
+	cfg, err := Load(configWithReceiver("", `
+  discord_configs:
+  - webhook_url: `+webhookURL+`
+  - webhook_url: https://canary.discord.com/api/webhooks/1234/token-1234
+    message: '{{ .CommonLabels.alertname }}'
+    username: alertmanager
+    avatar_url: https://example.com/avatar.png
+    send_resolved: false
+`))
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	dc := cfg.Receivers[0].DiscordConfigs[0]
+	if dc.WebhookURL != webhookURL {
+		t.Errorf("Expected webhook URL %q, got %q", webhookURL, dc.WebhookURL)
+	}
+	if dc.Title != DefaultDiscordConfig.Title || dc.Message != DefaultDiscordConfig.Message || !dc.SendResolved() {
+		t.Errorf("Expected default options, got %+v", dc)
+	}
+	dc = cfg.Receivers[0].DiscordConfigs[1]
+	if dc.Message != "{{ .CommonLabels.alertname }}" || dc.Username != "alertmanager" || dc.AvatarURL != "https://example.com/avatar.png" || dc.SendResolved() {
+		t.Errorf("Expected receiver options, got %+v", dc)
+	}
+
+	if s := cfg.String(); strings.Contains(s, "token-1234") {
+		t.Errorf("Config string contains webhook URL:\n%s", s)
+	}
+	cfg.original = ""
+	if s := cfg.String(); strings.Contains(s, "token-1234") {
+		t.Errorf("Marshaled config string contains webhook URL:\n%s", s)
+	}
+
+	for in, err := range map[string]string{
+		`webhook_url: ""`: "missing webhook_url in Discord config",
+		"webhook_url: http://discord.com/api/webhooks/1234/token-1234":                "must be an absolute https URL of discord.com or discordapp.com",
+		"webhook_url: https://example.com/api/webhooks/1234/token-1234":               "must be an absolute https URL of discord.com or discordapp.com",
+		"webhook_url: https://discord.com.evil/api/webhooks/1/token-1234":             "must be an absolute https URL of discord.com or discordapp.com",
+		"webhook_url: /api/webhooks/1234/token-1234":                                  "must be an absolute https URL of discord.com or discordapp.com",
+		"webhook_url: https://discord.com/api/webhooks/1/token-1234\n    message: ''": "missing message in Discord config",
+	} {
+		in := configWithReceiver("", `
+  discord_configs:
+  - `+in+`
+`)
+		expectLoadError(t, in, err)
+		if _, lerr := Load(in); lerr != nil && strings.Contains(lerr.Error(), "token-1234") {
+			t.Errorf("Error reveals webhook URL: %s", lerr)
+		}
+	}
+}
+
+func TestKafkaConfig(t *testing.T) {
+	cfg, err := Load(configWithReceiver(`
+  http_config:
+    proxy_url: http://proxy.example.com:3128
+`, `
+  kafka_configs:
+  - rest_proxy_url: http://kafka-rest.example.com:8082/
+    topic: alerts.prod
+    key_template: '{{ .GroupKey }}'
+  - rest_proxy_url: http://kafka-rest.example.com:8082/
+    topic: alerts
+    send_resolved: false
+`))
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	kc := cfg.Receivers[0].KafkaConfigs[0]
+	if kc.RESTProxyURL.String() != "http://kafka-rest.example.com:8082/" || kc.Topic != "alerts.prod" || !kc.SendResolved() {
+		t.Errorf("Unexpected Kafka config %+v", kc)
+	}
+	if kc.KeyTemplate == nil || kc.KeyTemplate.Template() == nil {
+		t.Errorf("Expected parsed key template, got %+v", kc.KeyTemplate)
+	}
+	if kc.HTTPConfig == nil || kc.HTTPConfig.ProxyURL != "http://proxy.example.com:3128" {
+		t.Errorf("Expected global HTTP config, got %+v", kc.HTTPConfig)
+	}
+	if kc = cfg.Receivers[0].KafkaConfigs[1]; kc.KeyTemplate != nil || kc.SendResolved() {
+		t.Errorf("Unexpected Kafka config %+v", kc)
+	}
+
+	for in, err := range map[string]string{
+		"topic: alerts": "missing rest_proxy_url in Kafka config",
+		"rest_proxy_url: http://kafka-rest.example.com:8082/":                                                      "missing topic in Kafka config",
+		"rest_proxy_url: http://kafka-rest.example.com:8082/\n    topic: 'alerts/prod'":                            `invalid topic "alerts/prod" in Kafka config`,
+		"rest_proxy_url: http://kafka-rest.example.com:8082/\n    topic: '..'":                                     `invalid topic ".." in Kafka config`,
+		"rest_proxy_url: kafka-rest.example.com\n    topic: alerts":                                                "must be an absolute http or https URL",
+		"rest_proxy_url: http://kafka-rest.example.com:8082/\n    topic: alerts\n    key: x":                       "unknown fields in kafka config: key",
+		"rest_proxy_url: http://kafka-rest.example.com:8082/\n    topic: alerts\n    key_template: '{{ .GroupKey'": `invalid kafka_configs[0].key_template in receiver "default"`,
+	} {
+		expectLoadError(t, configWithReceiver("", `
+  kafka_configs:
+  - `+in+`
+`), err)
+	}
+}
+
+func TestSNSConfig(t *testing.T) {
+	cfg, err := Load(configWithReceiver("", `
+  sns_configs:
+  - topic_arn: arn:aws:sns:eu-west-1:123456789012:alerts
+    attributes:
+      team: ops
+    sigv4:
+      region: eu-west-1
+      access_key: access-1234
+      secret_key: secret-1234
+  - phone_number: "+15555550100"
+    send_resolved: false
+`))
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	snc := cfg.Receivers[0].SNSConfigs[0]
+	if snc.Subject != DefaultSNSConfig.Subject || !snc.SendResolved() {
+		t.Errorf("Expected default options, got %+v", snc)
+	}
+	if snc.Attributes["team"] != "ops" {
+		t.Errorf("Expected attributes, got %v", snc.Attributes)
+	}
+	if snc.SigV4 == nil || snc.SigV4.Region != "eu-west-1" || snc.SigV4.SecretKey != "secret-1234" {
+		t.Errorf("Expected sigv4 config, got %+v", snc.SigV4)
+	}
+	if snc = cfg.Receivers[0].SNSConfigs[1]; snc.PhoneNumber != "+15555550100" || snc.SigV4 != nil {
+		t.Errorf("Expected phone number without sigv4 config, got %+v", snc)
+	}
+
+	for _, s := range []string{cfg.String(), Config{Global: cfg.Global, Receivers: cfg.Receivers}.String()} {
+		if strings.Contains(s, "access-1234") || strings.Contains(s, "secret-1234") {
+			t.Errorf("Config string contains sigv4 credentials:\n%s", s)
+		}
+	}
+
+	for conf, err := range map[string]string{
+		"subject: test": "exactly one of topic_arn, phone_number and target_arn must be set in SNS config",
+		"{topic_arn: 'arn:aws:sns:eu-west-1:123456789012:alerts', target_arn: 'arn:aws:sns:eu-west-1:123456789012:endpoint'}": "exactly one of topic_arn, phone_number and target_arn must be set in SNS config",
+		"{topic_arn: 'arn:aws:sns:eu-west-1:123456789012:alerts', sigv4: {access_key: access-1234}}":                          "access_key and secret_key must be set together in sigv4 config",
+		"{topic_arn: 'arn:aws:sns:eu-west-1:123456789012:alerts', sigv4: {region: eu-west-1, key: value}}":                    "unknown fields in sigv4 config: key",
+	} {
+		expectLoadError(t, configWithReceiver("", `
+  sns_configs:
+  - `+conf+`
+`), err)
+	}
+}
+
+func TestWebhookHTTPConfig(t *testing.T) {
+	rcv := loadReceiver(t, "", `
+  webhook_configs:
+  - url: https://example.com/
+    http_config:
+      bearer_token: secret
+      proxy_url: http://proxy.example.com:3128
+      tls_config:
+        ca_file: /etc/ssl/ca.pem
+`)
+	hc := rcv.WebhookConfigs[0].HTTPConfig
+	if hc == nil || hc.BearerToken != "secret" || hc.TLSConfig.CAFile != "/etc/ssl/ca.pem" {
+		t.Errorf("Unexpected HTTP config %+v", hc)
+	}
+
+	expectLoadError(t, configWithReceiver("", `
+  webhook_configs:
+  - url: https://example.com/
+    http_config:
+      bearer_token: secret
+      basic_auth:
+        username: user
+        password: pass
+`), "at most one of basic_auth and bearer_token must be configured")
+
+	expectLoadError(t, configWithReceiver("", `
+  webhook_configs:
+  - url: https://example.com/
+    http_config:
+      tls_config:
+        cert_file: client.pem
+`), "cert_file and key_file must be configured together")
+}
+
+func TestWebhookHTTPConfigHeaders(t *testing.T) {
+	rcv := loadReceiver(t, "", `
+  webhook_configs:
+  - url: https://example.com/
+    http_config:
+      headers:
+        x-tenant-id: '{{ .CommonLabels.tenant }}'
+        X-SOURCE: alertmanager
+`)
+	hc := rcv.WebhookConfigs[0].HTTPConfig
+	expected := map[string]string{
+		"X-Tenant-Id": "{{ .CommonLabels.tenant }}",
+		"X-Source":    "alertmanager",
+	}
+	if !reflect.DeepEqual(hc.Headers, expected) {
+		t.Errorf("Expected headers %v, got %v", expected, hc.Headers)
+	}
+	if hc.HeaderTemplate("X-Tenant-Id") == nil {
+		t.Errorf("Expected templated header value to be parsed")
+	}
+	if hc.HeaderTemplate("X-Source") != nil {
+		t.Errorf("Expected literal header value not to be a template")
+	}
+
+	for _, c := range []struct {
+		headers string
+		err     string
+	}{
+		{"{x-tenant: a, X-Tenant: b}", `duplicate header "X-Tenant" in http config`},
+		{"{content-length: '10'}", `header "Content-Length" cannot be set in http config`},
+		{"{Host: example.org}", `header "Host" cannot be set in http config`},
+		{"{X-Tenant: '{{ .CommonLabels.tenant }'}", `invalid template of header "X-Tenant" in http config`},
+	} {
+		expectLoadError(t, configWithReceiver("", `
+  webhook_configs:
+  - url: https://example.com/
+    http_config:
+      headers: `+c.headers), c.err)
+	}
+	expectLoadError(t, configWithReceiver("", `
+  webhook_configs:
+  - url: https://example.com/
+    http_config:
+      bearer_token: secret
+      headers:
+        authorization: Basic abc
+`), `header "Authorization" cannot be set in http config together with basic_auth or bearer_token`)
+}
+
+func TestEmailConfigTo(t *testing.T) {
+	rcv := loadReceiver(t, `
+  smtp_smarthost: localhost:25
+  smtp_from: alertmanager@example.org
+`, `
+  email_configs:
+  - to: team-a@example.org, "Team B" <team-b@example.org>
+  - to: '{{ .CommonLabels.team }}@example.org, {{ join ", " .CommonLabels.cc }}, ops@example.org'
+`)
+
+	ec := rcv.EmailConfigs[0]
+	if got := splitRecipients(ec.To); len(got) != 2 {
+		t.Errorf("Expected 2 recipients, got %q", got)
+	}
+	if ec.ToTemplate() == nil {
+		t.Fatalf("Expected to address to be parsed")
+	}
+
+	ec = rcv.EmailConfigs[1]
+	if got := splitRecipients(ec.To); len(got) != 3 {
+		t.Errorf("Expected 3 recipients, got %q", got)
+	}
+	var buf bytes.Buffer
+	data := map[string]interface{}{
+		"CommonLabels": map[string]interface{}{"team": "team-a", "cc": []string{"a@example.org", "b@example.org"}},
+	}
+	if err := ec.ToTemplate().Template().Execute(&buf, data); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if want := "team-a@example.org, a@example.org, b@example.org, ops@example.org"; buf.String() != want {
+		t.Errorf("Expected recipients %q, got %q", want, buf.String())
+	}
+
+	// The parsed template is not used once the address was changed.
+	ec.To = "ops@example.org"
+	if ec.ToTemplate() != nil {
+		t.Errorf("Expected no template for a changed to address")
+	}
+
+	for _, c := range []struct {
+		to  string
+		err string
+	}{
+		{`""`, "missing to address in email config"},
+		{"team-a@example.org, team-b", `invalid to address "team-b" in email config`},
+		{"team-a@example.org,", `invalid to address "" in email config`},
+		{"'{{ .CommonLabels.team }@example.org'", "invalid to template in email config"},
+	} {
+		expectLoadError(t, configWithReceiver(`
+  smtp_smarthost: localhost:25
+  smtp_from: alertmanager@example.org
+`, `
+  email_configs:
+  - to: `+c.to), c.err)
+	}
+}
+
+func TestEmailConfigAuth(t *testing.T) {
+	rcv := loadReceiver(t, `
+  smtp_smarthost: localhost:25
+  smtp_from: alertmanager@example.org
+  smtp_auth_username: alertmanager
+  smtp_auth_password: global-password
+  smtp_require_tls: true
+`, `
+  email_configs:
+  - to: team-a@example.org
+  - to: team-b@example.org
+    auth_username: team-b
+    auth_password: team-b-password
+    require_tls: false
+`)
+
+	ec := rcv.EmailConfigs[0]
+	if ec.AuthUsername != "alertmanager" || ec.AuthPassword != "global-password" {
+		t.Errorf("Expected global SMTP auth, got %q and %q", ec.AuthUsername, ec.AuthPassword)
+	}
+	if ec.RequireTLS == nil || !*ec.RequireTLS {
+		t.Errorf("Expected global require_tls to be applied")
+	}
+	ec = rcv.EmailConfigs[1]
+	if ec.AuthUsername != "team-b" || ec.AuthPassword != "team-b-password" {
+		t.Errorf("Expected receiver SMTP auth, got %q and %q", ec.AuthUsername, ec.AuthPassword)
+	}
+	if ec.RequireTLS == nil || *ec.RequireTLS {
+		t.Errorf("Expected receiver require_tls to override the global one")
+	}
+
+	expectLoadError(t, configWithReceiver(`
+  smtp_smarthost: localhost:25
+  smtp_from: alertmanager@example.org
+`, `
+  email_configs:
+  - to: team-a@example.org
+    auth_password: secret
+`), "missing auth username for SMTP auth password in email config")
+}
+
+func TestEmailConfigSmarthostAndHello(t *testing.T) {
+	rcv := loadReceiver(t, `
+  smtp_smarthost: mail.example.org:587
+  smtp_from: alertmanager@example.org
+  smtp_hello: alertmanager.example.org
+`, `
+  email_configs:
+  - to: team-a@example.org
+  - to: team-b@example.org
+    smarthost: '[::1]:25'
+    hello: team-b.example.org
+`)
+
+	ec := rcv.EmailConfigs[0]
+	if ec.Smarthost != (HostPort{Host: "mail.example.org", Port: "587"}) {
+		t.Errorf("Expected global smarthost, got %+v", ec.Smarthost)
+	}
+	if ec.Hello != "alertmanager.example.org" {
+		t.Errorf("Expected global hello, got %q", ec.Hello)
+	}
+	ec = rcv.EmailConfigs[1]
+	if ec.Smarthost != (HostPort{Host: "::1", Port: "25"}) || ec.Smarthost.String() != "[::1]:25" {
+		t.Errorf("Expected receiver smarthost, got %+v", ec.Smarthost)
+	}
+	if ec.Hello != "team-b.example.org" {
+		t.Errorf("Expected receiver hello, got %q", ec.Hello)
+	}
+
+	for smarthost, want := range map[string]HostPort{
+		"[2001:db8::1]:587":      {Host: "2001:db8::1", Port: "587"},
+		"[2001:0DB8:0:0::1]:587": {Host: "2001:db8::1", Port: "587"},
+		"[fe80::1%eth0]:25":      {Host: "fe80::1%eth0", Port: "25"},
+		"Mail.Example.ORG:25":    {Host: "mail.example.org", Port: "25"},
+		"[::ffff:10.0.0.1]:25":   {Host: "::ffff:10.0.0.1", Port: "25"},
+	} {
+		rcv := loadReceiver(t, `
+  smtp_smarthost: '`+smarthost+`'
+  smtp_from: alertmanager@example.org
+`, `
+  email_configs:
+  - to: team-a@example.org
+`)
+		if got := rcv.EmailConfigs[0].Smarthost; got != want {
+			t.Errorf("Expected smarthost %+v for %q, got %+v", want, smarthost, got)
+		}
+	}
+
+	for smarthost, err := range map[string]string{
+		"mail:abc":           `invalid smarthost "mail:abc": port must be numeric`,
+		"mail:70000":         `invalid smarthost "mail:70000": port must be numeric`,
+		"mail":               `invalid smarthost "mail": missing port`,
+		":25":                `invalid smarthost ":25": missing host`,
+		"2001:db8::1":        `invalid smarthost "2001:db8::1": missing port, IPv6 addresses must be enclosed in brackets like [2001:db8::1]:25`,
+		"[2001:db8::1]":      `invalid smarthost "[2001:db8::1]": missing port`,
+		"2001:db8::1:587":    `invalid smarthost "2001:db8::1:587": missing port, IPv6 addresses must be enclosed in brackets`,
+		"[2001:db8::zz]:587": `invalid smarthost "[2001:db8::zz]:587": invalid IPv6 address "2001:db8::zz"`,
+		"[10.0.0.1]:25":      `invalid smarthost "[10.0.0.1]:25": invalid IPv6 address "10.0.0.1"`,
+		"[2001:db8::1:587":   `invalid smarthost "[2001:db8::1:587": missing ']' in address`,
+	} {
+		expectLoadError(t, configWithReceiver(`
+  smtp_smarthost: '`+smarthost+`'
+  smtp_from: alertmanager@example.org
+`, `
+  email_configs:
+  - to: team-a@example.org
+`), err)
+	}
+}
+
+func TestEmailConfigHeadersAndBody(t *testing.T) {
+	global := `
+  smtp_smarthost: localhost:25
+  smtp_from: alertmanager@example.org
+`
+	rcv := loadReceiver(t, global, `
+  email_configs:
+  - to: team-a@example.org
+    headers:
+      subject: custom subject
+      reply-to: ops@example.org
+    text: '{{ template "__subject" . }}'
+  - to: team-b@example.org
+    html: ''
+`)
+
+	ec := rcv.EmailConfigs[0]
+	if ec.Headers["Subject"] != "custom subject" || ec.Headers["Reply-To"] != "ops@example.org" {
+		t.Errorf("Expected canonicalized headers, got %v", ec.Headers)
+	}
+	if ec.Text == "" || ec.HTML != DefaultEmailConfig.HTML {
+		t.Errorf("Expected text body and default HTML body, got %q and %q", ec.Text, ec.HTML)
+	}
+	if rcv.EmailConfigs[1].HTML != DefaultEmailConfig.HTML {
+		t.Errorf("Expected default HTML body for empty bodies, got %q", rcv.EmailConfigs[1].HTML)
+	}
+
+	expectLoadError(t, configWithReceiver(global, `
+  email_configs:
+  - to: team-a@example.org
+    headers:
+      Subject: a
+      SUBJECT: b
+`), `duplicate header "Subject" in email config`)
+}
+
+func TestOpsGenieConfig(t *testing.T) {
+	rcv := loadReceiver(t, "", `
+  opsgenie_configs:
+  - api_key: key
+    api_host: https://opsgenie.example.com
+    priority: P2
+    tags: 'a, b,,c'
+    note: some note
+    responders:
+    - type: team
+      name: ops
+    - type: user
+      username: alice@example.com
+    entity: '{{ .CommonLabels.instance }}'
+    alias: '{{ .CommonLabels.alertname }}'
+    actions: 'restart, ping'
+    details:
+      runbook: https://runbooks.example.com/
+  - api_key: key
+    priority: '{{ .CommonLabels.priority }}'
+    tags: [a, b]
+`)
+
+	ogc := rcv.OpsGenieConfigs[0]
+	if ogc.APIHost.String() != "https://opsgenie.example.com/" {
+		t.Errorf("Expected normalized API host, got %q", ogc.APIHost)
+	}
+	if strings.Join(ogc.Tags, "|") != "a|b|c" {
+		t.Errorf("Expected comma-separated tags to be split, got %q", ogc.Tags)
+	}
+	if len(ogc.Responders) != 2 || ogc.Responders[1].Username != "alice@example.com" {
+		t.Errorf("Unexpected responders %+v", ogc.Responders)
+	}
+	if strings.Join(rcv.OpsGenieConfigs[1].Tags, "|") != "a|b" {
+		t.Errorf("Expected tag list, got %q", rcv.OpsGenieConfigs[1].Tags)
+	}
+	if ogc.Entity != "{{ .CommonLabels.instance }}" || ogc.Alias != "{{ .CommonLabels.alertname }}" {
+		t.Errorf("Unexpected entity %q and alias %q", ogc.Entity, ogc.Alias)
+	}
+	if strings.Join(ogc.Actions, "|") != "restart|ping" {
+		t.Errorf("Expected comma-separated actions to be split, got %q", ogc.Actions)
+	}
+	if ogc.Details["runbook"] != "https://runbooks.example.com/" {
+		t.Errorf("Unexpected details %v", ogc.Details)
+	}
+
+	cases := []struct {
+		config string
+		err    string
+	}{
+		{
+			config: `
+    priority: P6
+`,
+			err: `invalid priority "P6" in OpsGenie config`,
+		},
+		{
+			config: `
+    responders:
+    - type: group
+      name: ops
+`,
+			err: `invalid responder type "group" in OpsGenie config`,
+		},
+		{
+			config: `
+    details:
+      '': value
+`,
+			err: "empty details key in OpsGenie config",
+		},
+		{
+			config: `
+    responders:
+    - type: team
+      name: ops
+      id: 4513b7ea
+`,
+			err: "exactly one of id, name and username must be configured for OpsGenie responder",
+		},
+		{
+			config: `
+    responders:
+    - type: schedule
+`,
+			err: "exactly one of id, name and username must be configured for OpsGenie responder",
+		},
+	}
+	for _, c := range cases {
+		expectLoadError(t, configWithReceiver("", `
+  opsgenie_configs:
+  - api_key: key`+c.config), c.err)
+	}
+}
+
+func TestPagerdutyConfig(t *testing.T) {
+	rcv := loadReceiver(t, "", `
+  pagerduty_configs:
+  - service_key: v1-key
+  - routing_key: v2-key
+    severity: critical
+    links:
+    - href: https://runbooks.example.com/
+      text: Runbook
+    images:
+    - src: https://graphs.example.com/graph.png
+  - routing_key: v2-key
+    url: https://pagerduty.example.com/enqueue
+`)
+
+	if url := rcv.PagerdutyConfigs[0].URL.String(); url != DefaultGlobalConfig.PagerdutyURL.String() {
+		t.Errorf("Expected v1 URL for service key, got %q", url)
+	}
+	pdc := rcv.PagerdutyConfigs[1]
+	if pdc.URL.String() != DefaultPagerdutyV2URL {
+		t.Errorf("Expected v2 URL for routing key, got %q", pdc.URL)
+	}
+	if pdc.Severity != "critical" || len(pdc.Links) != 1 || len(pdc.Images) != 1 {
+		t.Errorf("Unexpected v2 fields %+v", pdc)
+	}
+	if url := rcv.PagerdutyConfigs[2].URL.String(); url != "https://pagerduty.example.com/enqueue" {
+		t.Errorf("Expected receiver URL, got %q", url)
+	}
+
+	// A custom global URL is used for both API versions.
+	rcv = loadReceiver(t, `
+  pagerduty_url: https://pagerduty.example.com/
+`, `
+  pagerduty_configs:
+  - routing_key: v2-key
+`)
+	if url := rcv.PagerdutyConfigs[0].URL.String(); url != "https://pagerduty.example.com/" {
+		t.Errorf("Expected global URL, got %q", url)
+	}
+
+	expectLoadError(t, configWithReceiver("", `
+  pagerduty_configs:
+  - description: foo
+`), "missing service or routing key in PagerDuty config")
+	expectLoadError(t, configWithReceiver("", `
+  pagerduty_configs:
+  - service_key: v1-key
+    routing_key: v2-key
+`), "at most one of service_key and routing_key must be configured")
+	expectLoadError(t, configWithReceiver("", `
+  pagerduty_configs:
+  - routing_key: v2-key
+    links:
+    - text: Runbook
+`), "missing href in PagerDuty link")
+}
+
+func TestSlackConfig(t *testing.T) {
+	rcv := loadReceiver(t, `
+  slack_api_url: https://hooks.slack.com/services/secret
+`, `
+  slack_configs:
+  - channel: '#alerts'
+    color: warning
+    fields:
+    - title: Severity
+      value: '{{ .CommonLabels.severity }}'
+      short: true
+    actions:
+    - text: Runbook
+      url: https://runbooks.example.com/
+      style: primary
+  - channel: '#alerts'
+    color: '#439FE0'
+  - channel: '#alerts'
+    color: '{{ if eq .Status "firing" }}danger{{ else }}good{{ end }}'
+`)
+
+	sc := rcv.SlackConfigs[0]
+	if sc.APIURL.URL.String() != "https://hooks.slack.com/services/secret" {
+		t.Errorf("Expected global API URL, got %q", sc.APIURL.URL)
+	}
+	if len(sc.Fields) != 1 || !sc.Fields[0].Short {
+		t.Errorf("Unexpected fields %+v", sc.Fields)
+	}
+	if len(sc.Actions) != 1 || sc.Actions[0].Type != "button" {
+		t.Errorf("Expected action with default type, got %+v", sc.Actions)
+	}
+	for i, want := range []ColorOrTemplate{"warning", "#439FE0", `{{ if eq .Status "firing" }}danger{{ else }}good{{ end }}`} {
+		if got := rcv.SlackConfigs[i].Color; got != want {
+			t.Errorf("Expected color %q in Slack config %d, got %q", want, i, got)
+		}
+	}
+
+	cases := []struct {
+		config string
+		err    string
+	}{
+		{
+			config: `
+    color: purple
+`,
+			err: `invalid color "purple" in Slack config`,
+		},
+		{
+			config: `
+    color: '#439FE'
+`,
+			err: `invalid color "#439FE" in Slack config`,
+		},
+		{
+			config: `
+    color: '{{ if eq .Status "firing" }}danger'
+`,
+			err: `invalid color template "{{ if eq .Status \"firing\" }}danger" in Slack config`,
+		},
+		{
+			config: `
+    color: '{{ .Status | nosuchfunc }}'
+`,
+			err: `function "nosuchfunc" not defined`,
+		},
+		{
+			config: `
+    actions:
+    - url: https://runbooks.example.com/
+`,
+			err: "missing text in Slack action",
+		},
+		{
+			config: `
+    actions:
+    - text: Runbook
+`,
+			err: "missing url or name in Slack action",
+		},
+		{
+			config: `
+    fields:
+    - title: Severity
+`,
+			err: "missing value in Slack field",
+		},
+		{
+			config: `
+    blocks: []
+`,
+			err: "empty blocks in Slack config",
+		},
+		{
+			config: `
+    blocks:
+    - text: {type: mrkdwn, text: firing}
+`,
+			err: "missing type of block 0 in Slack config",
+		},
+		{
+			config: `
+    text: firing
+    blocks:
+    - type: divider
+`,
+			err: "at most one of text, text_template and blocks must be configured in Slack config",
+		},
+	}
+	for _, c := range cases {
+		expectLoadError(t, configWithReceiver(`
+  slack_api_url: https://hooks.slack.com/services/secret
+`, `
+  slack_configs:
+  - channel: '#alerts'`+c.config), c.err)
+	}
+}
+
+func TestSlackBlocksRoundTrip(t *testing.T) {
+	in := configWithReceiver(`
+  slack_api_url: https://hooks.slack.com/services/secret
+`, `
+  slack_configs:
+  - channel: '#alerts'
+    blocks:
+    - type: section
+      text: {type: mrkdwn, text: '*{{ .CommonLabels.alertname }}* is firing'}
+      fields:
+      - {type: mrkdwn, text: '*Severity*'}
+    - type: actions
+      elements:
+      - type: button
+        text: {type: plain_text, text: Runbook}
+        url: https://runbooks.example.com/
+        style: primary
+`)
+	cfg, err := Load(in)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	sc := cfg.Receivers[0].SlackConfigs[0]
+	if sc.Text != "" {
+		t.Errorf("Expected the default text to be replaced by the blocks, got %q", sc.Text)
+	}
+
+	out, err := cfg.MarshalWithSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := Load(string(out))
+	if err != nil {
+		t.Fatalf("Error loading marshaled config: %s\n%s", err, out)
+	}
+	if got := reloaded.Receivers[0].SlackConfigs[0].Blocks; !reflect.DeepEqual(got, sc.Blocks) {
+		t.Errorf("Expected blocks to survive a round trip unchanged\nexpected: %v\ngot: %v", sc.Blocks, got)
+	}
+
+	// Keys keep their order, including those of nested mappings.
+	var keys []string
+	for _, item := range sc.Blocks[1] {
+		keys = append(keys, item.Key.(string))
+	}
+	button := sc.Blocks[1][1].Value.([]interface{})[0].(yaml.MapSlice)
+	for _, item := range button {
+		keys = append(keys, item.Key.(string))
+	}
+	if got, want := strings.Join(keys, ","), "type,elements,type,text,url,style"; got != want {
+		t.Errorf("Expected keys %s, got %s", want, got)
+	}
+}
+
+func TestNotifierResolveTimeout(t *testing.T) {
+	rcv := loadReceiver(t, `
+  resolve_timeout: 10m
+  slack_api_url: https://hooks.slack.com/services/secret
+`, `
+  slack_configs:
+  - channel: '#alerts'
+    resolve_timeout: 1h
+  webhook_configs:
+  - url: https://example.com/
+`)
+
+	if rt := rcv.SlackConfigs[0].ResolveTimeout(); rt != time.Hour {
+		t.Errorf("Expected receiver resolve timeout, got %s", rt)
+	}
+	if rt := rcv.WebhookConfigs[0].ResolveTimeout(); rt != 10*time.Minute {
+		t.Errorf("Expected global resolve timeout, got %s", rt)
+	}
+
+	// Negative durations are already rejected by the duration parser.
+	for rt, err := range map[string]string{
+		"0s":  `invalid resolve_timeout in receiver "default": must be positive`,
+		"-5m": `invalid duration "-5m": must be an integer followed by a unit`,
+	} {
+		expectLoadError(t, configWithReceiver("", `
+  webhook_configs:
+  - url: https://example.com/
+    resolve_timeout: `+rt+`
+`), err)
+	}
+}
+
+func TestWebhookConfig(t *testing.T) {
+	rcv := loadReceiver(t, "", `
+  webhook_configs:
+  - url: https://example.com/a
+  - url: https://example.com/b
+    version: '2'
+    max_alerts: 10
+`)
+
+	if wh := rcv.WebhookConfigs[0]; wh.Version != DefaultWebhookVersion || wh.MaxAlerts != 0 {
+		t.Errorf("Expected default version and unlimited alerts, got %q and %d", wh.Version, wh.MaxAlerts)
+	}
+	if wh := rcv.WebhookConfigs[1]; wh.MaxAlerts != 10 {
+		t.Errorf("Expected max_alerts 10, got %d", wh.MaxAlerts)
+	}
+
+	expectLoadError(t, configWithReceiver("", `
+  webhook_configs:
+  - url: https://example.com/
+    version: '3'
+`), `unknown version "3" in webhook config, must be "2"`)
+	expectLoadError(t, configWithReceiver("", `
+  webhook_configs:
+  - url: https://example.com/
+    max_alerts: -1
+`), `invalid max_alerts in receiver "default": must not be negative`)
+}
+
+func TestMaxAlerts(t *testing.T) {
+	rcv := loadReceiver(t, `
+  smtp_smarthost: localhost:25
+  smtp_from: alertmanager@example.org
+`, `
+  email_configs:
+  - to: team-a@example.org
+    max_alerts: 5
+  slack_configs:
+  - api_url: https://hooks.slack.com/services/token
+    channel: '#alerts'
+`)
+
+	if n := rcv.EmailConfigs[0].MaxAlerts; n != 5 {
+		t.Errorf("Expected max_alerts 5, got %d", n)
+	}
+	if n := rcv.SlackConfigs[0].MaxAlerts; n != 0 {
+		t.Errorf("Expected unlimited alerts by default, got %d", n)
+	}
+
+	expectLoadError(t, configWithReceiver("", `
+  slack_configs:
+  - api_url: https://hooks.slack.com/services/token
+    channel: '#alerts'
+    max_alerts: -1
+`), `invalid max_alerts in receiver "default": must not be negative`)
+}
+
+func TestThrottleConfig(t *testing.T) {
+	rcv := loadReceiver(t, "", `
+  webhook_configs:
+  - url: https://example.com/
+  - url: https://example.com/
+    throttle:
+      max_per_minute: 10
+      burst: 5
+  email_configs:
+  - to: team@example.com
+    from: alertmanager@example.com
+    smarthost: smtp.example.com:25
+    throttle:
+      max_per_minute: 1
+`)
+
+	expected := []ThrottleConfig{
+		{},
+		{MaxPerMinute: 10, Burst: 5},
+		{MaxPerMinute: 1},
+	}
+	got := []ThrottleConfig{
+		rcv.WebhookConfigs[0].Throttle,
+		rcv.WebhookConfigs[1].Throttle,
+		rcv.EmailConfigs[0].Throttle,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected throttle configs %+v, got %+v", expected, got)
+	}
+
+	cases := []struct {
+		throttle string
+		err      string
+	}{
+		{throttle: "max_per_minute: -1", err: "max_per_minute must not be negative in throttle config"},
+		{throttle: "burst: -1", err: "burst must not be negative in throttle config"},
+		{throttle: "rate: 1", err: "unknown fields in throttle config: rate"},
+	}
+	for _, c := range cases {
+		expectLoadError(t, configWithReceiver("", `
+  webhook_configs:
+  - url: https://example.com/
+    throttle:
+      `+c.throttle+`
+`), c.err)
+	}
+}
+
+func TestWebhookRetryConfig(t *testing.T) {
+	rcv := loadReceiver(t, "", `
+  webhook_configs:
+  - url: https://example.com/a
+  - url: https://example.com/b
+    retry:
+      max_retries: 5
+      initial_interval: 2s
+  - url: https://example.com/c
+    retry:
+      max_interval: 10m
+      multiplier: 3
+`)
+
+	expected := []RetryConfig{
+		DefaultRetryConfig,
+		{MaxRetries: 5, InitialInterval: Duration(2 * time.Second), MaxInterval: Duration(time.Minute), Multiplier: 1.5},
+		{InitialInterval: Duration(500 * time.Millisecond), MaxInterval: Duration(10 * time.Minute), Multiplier: 3},
+	}
+	for i, wh := range rcv.WebhookConfigs {
+		if !reflect.DeepEqual(wh.Retry, expected[i]) {
+			t.Errorf("Webhook %d: expected retry config %+v, got %+v", i, expected[i], wh.Retry)
+		}
+	}
+
+	cases := []struct {
+		retry string
+		err   string
+	}{
+		{retry: "max_retries: -1", err: "max_retries must not be negative in retry config"},
+		{retry: "initial_interval: 0s", err: "initial_interval must be positive in retry config"},
+		{retry: "max_interval: 100ms", err: "max_interval must not be less than initial_interval in retry config"},
+		{retry: "multiplier: 0.5", err: "multiplier must be at least 1 in retry config"},
+		{retry: "jitter: 0.5", err: "unknown fields in retry config: jitter"},
+	}
+	for _, c := range cases {
+		expectLoadError(t, configWithReceiver("", `
+  webhook_configs:
+  - url: https://example.com/
+    retry:
+      `+c.retry+`
+`), c.err)
+	}
+}
+
+func TestInlineTemplates(t *testing.T) {
+	rcv := loadReceiver(t, "  slack_api_url: http://slack.example.com/\n", `
+  slack_configs:
+  - channel: '#ops'
+    title_template: '{{ .CommonLabels.alertname | toUpper }}'
+    text_template: '{{ template "__subject" . }}'
+  pagerduty_configs:
+  - routing_key: key
+    text_template: '{{ .CommonLabels.instance }} is down'
+  webhook_configs:
+  - url: http://example.com/
+    text_template: '{{ len .Alerts }} alerts'
+`)
+
+	for _, it := range []*InlineTemplate{
+		rcv.SlackConfigs[0].TitleTemplate,
+		rcv.SlackConfigs[0].TextTemplate,
+		rcv.PagerdutyConfigs[0].TextTemplate,
+		rcv.WebhookConfigs[0].TextTemplate,
+	} {
+		if it == nil || it.Template() == nil {
+			t.Fatalf("Expected parsed inline templates, got %+v", it)
+		}
+	}
+	if rcv.PagerdutyConfigs[0].TextTemplate.Text != "{{ .CommonLabels.instance }} is down" {
+		t.Errorf("Unexpected template text %q", rcv.PagerdutyConfigs[0].TextTemplate.Text)
+	}
+
+	cases := []struct {
+		receiver string
+		err      string
+	}{
+		{
+			receiver: `
+  slack_configs:
+  - api_url: http://slack.example.com/
+    channel: '#ops'
+    title_template: '{{ .CommonLabels.alertname'
+`,
+			err: `invalid slack_configs[0].title_template in receiver "default"`,
+		},
+		{
+			receiver: `
+  pagerduty_configs:
+  - routing_key: key
+  - routing_key: key
+    text_template: '{{ if .Alerts }}firing'
+`,
+			err: `invalid pagerduty_configs[1].text_template in receiver "default"`,
+		},
+		{
+			receiver: `
+  webhook_configs:
+  - url: http://example.com/
+    text_template: '{{ .Alerts | undefinedFunc }}'
+`,
+			err: `invalid webhook_configs[0].text_template in receiver "default"`,
+		},
+	}
+	for _, c := range cases {
+		expectLoadError(t, configWithReceiver("", c.receiver), c.err)
+	}
+}
@@ -0,0 +1,279 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"container/list"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Regexp encapsulates a regexp.Regexp and makes it YAML marshalable.
+// The pattern has to match whole values unless it is unanchored, in which
+// case it may match any part of them. The compiled regular expression is
+// anchored at both ends in either case.
+type Regexp struct {
+	*regexp.Regexp
+
+	// The pattern and options the regular expression was compiled from.
+	pattern         string
+	caseInsensitive bool
+	multiline       bool
+	allowAnchors    bool
+	unanchored      bool
+}
+
+// regexpObject is the object form of a Regexp in YAML, which allows to
+// set options.
+type regexpObject struct {
+	Pattern         string `yaml:"pattern"`
+	CaseInsensitive bool   `yaml:"case_insensitive,omitempty"`
+	Multiline       bool   `yaml:"multiline,omitempty"`
+	// AllowAnchors permits explicit anchors in the pattern, which are
+	// otherwise rejected as the pattern is anchored implicitly.
+	AllowAnchors bool `yaml:"allow_anchors,omitempty"`
+	// Anchored can be set to false to match the pattern against any part
+	// of the value rather than the whole value.
+	Anchored *bool `yaml:"anchored,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// NewRegexp returns a Regexp matching the given pattern.
+func NewRegexp(pattern string) (Regexp, error) {
+	return newRegexp(regexpObject{Pattern: pattern})
+}
+
+// NewValuesRegexp returns a Regexp matching exactly the given values.
+func NewValuesRegexp(values []string) Regexp {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, regexp.QuoteMeta(v))
+	}
+	sort.Strings(quoted)
+	re, err := NewRegexp(strings.Join(quoted, "|"))
+	if err != nil {
+		// Quoted values always form a valid pattern.
+		panic(err)
+	}
+	return re
+}
+
+func newRegexp(o regexpObject) (Regexp, error) {
+	var flags string
+	if o.CaseInsensitive {
+		flags += "i"
+	}
+	if o.Multiline {
+		flags += "m"
+	}
+	expr := o.Pattern
+	if flags != "" {
+		// The flags are set inside of the anchoring group, which keeps
+		// the anchors intact for users of the compiled expression.
+		expr = "(?" + flags + ")" + expr
+	}
+	unanchored := o.Anchored != nil && !*o.Anchored
+	if unanchored {
+		// Matching the pattern anywhere in the value is the same as
+		// matching it surrounded by arbitrary text.
+		expr = "(?s:.*?)(?:" + expr + ")(?s:.*?)"
+	}
+	regex, err := compileRegexp("^(?:" + expr + ")$")
+	if err != nil {
+		return Regexp{}, err
+	}
+	return Regexp{
+		Regexp:          regex,
+		pattern:         o.Pattern,
+		caseInsensitive: o.CaseInsensitive,
+		multiline:       o.Multiline,
+		allowAnchors:    o.AllowAnchors,
+		unanchored:      unanchored,
+	}, nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (re *Regexp) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var o regexpObject
+	if err := unmarshal(&o.Pattern); err != nil {
+		if err := unmarshal(&o); err != nil {
+			return err
+		}
+		if err := checkOverflow(o.XXX, "regexp", o); err != nil {
+			return err
+		}
+		if o.Pattern == "" {
+			return fmt.Errorf("missing pattern in regexp")
+		}
+		if strings.HasPrefix(o.Pattern, "(?") && (o.CaseInsensitive || o.Multiline) {
+			return fmt.Errorf("regexp %q sets flags itself, which cannot be combined with options", o.Pattern)
+		}
+	}
+	// Explicit anchors are meaningful in unanchored patterns.
+	unanchored := o.Anchored != nil && !*o.Anchored
+	if !o.AllowAnchors && !unanchored && hasAnchors(o.Pattern) {
+		return fmt.Errorf("regexp %q must not start with \"^\" or end with \"$\" as it is anchored implicitly, set allow_anchors to keep explicit anchors", o.Pattern)
+	}
+	r, err := newRegexp(o)
+	if err != nil {
+		return err
+	}
+	*re = r
+	return nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. The
+// YAML decoder uses it instead of UnmarshalYAML for empty values, which
+// must not reach the embedded regular expression.
+func (re *Regexp) UnmarshalText(text []byte) error {
+	return re.UnmarshalYAML(func(v interface{}) error {
+		s, ok := v.(*string)
+		if !ok {
+			return fmt.Errorf("regexp must be a string")
+		}
+		*s = string(text)
+		return nil
+	})
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (re Regexp) MarshalYAML() (interface{}, error) {
+	if re.Regexp == nil {
+		return nil, nil
+	}
+	if re.caseInsensitive || re.multiline || re.allowAnchors || re.unanchored {
+		o := regexpObject{
+			Pattern:         re.pattern,
+			CaseInsensitive: re.caseInsensitive,
+			Multiline:       re.multiline,
+			AllowAnchors:    re.allowAnchors,
+		}
+		if re.unanchored {
+			anchored := false
+			o.Anchored = &anchored
+		}
+		return o, nil
+	}
+	return re.source(), nil
+}
+
+// source returns the pattern the regular expression was compiled from.
+func (re Regexp) source() string {
+	if re.pattern != "" {
+		return re.pattern
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(re.String(), "^(?:"), ")$")
+}
+
+// hasAnchors returns true if the pattern starts with "^" or ends with an
+// unescaped "$".
+func hasAnchors(pattern string) bool {
+	if strings.HasPrefix(pattern, "^") {
+		return true
+	}
+	if !strings.HasSuffix(pattern, "$") {
+		return false
+	}
+	escapes := len(pattern) - 1 - len(strings.TrimRight(pattern[:len(pattern)-1], `\`))
+	return escapes%2 == 0
+}
+
+// regexpCacheSize is the maximum number of compiled regular expressions
+// kept by regexpCache.
+const regexpCacheSize = 4096
+
+// regexpCache holds recently compiled regular expressions, which are
+// shared by all configurations that use the same expression. Compiled
+// regular expressions are safe for concurrent use.
+var regexpCache = newRegexpLRU(regexpCacheSize)
+
+// compileRegexp returns the compiled expr, which is taken from the cache
+// if it was compiled before.
+func compileRegexp(expr string) (*regexp.Regexp, error) {
+	if re := regexpCache.get(expr); re != nil {
+		return re, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexpCache.add(expr, re)
+	return re, nil
+}
+
+// regexpLRU is a concurrency-safe cache of compiled regular expressions
+// by their expression, which evicts the least recently used one when it
+// is full.
+type regexpLRU struct {
+	mtx     sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	// The entries in order of their last use, most recent first.
+	order *list.List
+}
+
+type regexpLRUEntry struct {
+	expr string
+	re   *regexp.Regexp
+}
+
+func newRegexpLRU(size int) *regexpLRU {
+	return &regexpLRU{
+		size:    size,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+// get returns the regular expression cached for expr or nil.
+func (c *regexpLRU) get(expr string) *regexp.Regexp {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	e, ok := c.entries[expr]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*regexpLRUEntry).re
+}
+
+// add caches the regular expression re compiled from expr.
+func (c *regexpLRU) add(expr string, re *regexp.Regexp) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if e, ok := c.entries[expr]; ok {
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[expr] = c.order.PushFront(&regexpLRUEntry{expr: expr, re: re})
+	for c.order.Len() > c.size {
+		e := c.order.Back()
+		c.order.Remove(e)
+		delete(c.entries, e.Value.(*regexpLRUEntry).expr)
+	}
+}
+
+// len returns the number of cached regular expressions.
+func (c *regexpLRU) len() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,256 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestRegexpOptions(t *testing.T) {
+	cases := []struct {
+		in       string
+		expr     string
+		match    []string
+		notMatch []string
+		out      string
+		err      string
+	}{
+		{
+			in:       `foo.*`,
+			expr:     `^(?:foo.*)$`,
+			match:    []string{"foobar"},
+			notMatch: []string{"FOObar", "xfoo"},
+			out:      "foo.*\n",
+		},
+		{
+			in:       `{pattern: "foo.*", case_insensitive: true}`,
+			expr:     `^(?:(?i)foo.*)$`,
+			match:    []string{"foobar", "FOObar"},
+			notMatch: []string{"xfoo"},
+			out:      "pattern: foo.*\ncase_insensitive: true\n",
+		},
+		{
+			in:    `{pattern: "foo", multiline: true, case_insensitive: true}`,
+			expr:  `^(?:(?im)foo)$`,
+			match: []string{"Foo"},
+			out:   "pattern: foo\ncase_insensitive: true\nmultiline: true\n",
+		},
+		{
+			in:   `{pattern: "foo"}`,
+			expr: `^(?:foo)$`,
+			out:  "foo\n",
+		},
+		{
+			in:  `{case_insensitive: true}`,
+			err: "missing pattern in regexp",
+		},
+		{
+			in:  `{pattern: "(?i)foo", case_insensitive: true}`,
+			err: `regexp "(?i)foo" sets flags itself, which cannot be combined with options`,
+		},
+		{
+			in:  `{pattern: "foo", ignore_case: true}`,
+			err: "unknown fields in regexp: ignore_case",
+		},
+		{
+			in:    `'foo\$'`,
+			expr:  `^(?:foo\$)$`,
+			match: []string{"foo$"},
+			out:   "foo\\$\n",
+		},
+		{
+			in:    `{pattern: "^foo$", allow_anchors: true}`,
+			expr:  `^(?:^foo$)$`,
+			match: []string{"foo"},
+			out:   "pattern: ^foo$\nallow_anchors: true\n",
+		},
+		{
+			in:       `{pattern: "foo", anchored: false}`,
+			expr:     `^(?:(?s:.*?)(?:foo)(?s:.*?))$`,
+			match:    []string{"foo", "myfoobar", "a\nfoo"},
+			notMatch: []string{"fo", "FOO"},
+			out:      "pattern: foo\nanchored: false\n",
+		},
+		{
+			// The default does not match substrings.
+			in:       `{pattern: "foo", anchored: true}`,
+			expr:     `^(?:foo)$`,
+			match:    []string{"foo"},
+			notMatch: []string{"myfoobar"},
+			out:      "foo\n",
+		},
+		{
+			in:       `{pattern: "^foo", anchored: false, case_insensitive: true}`,
+			expr:     `^(?:(?s:.*?)(?:(?i)^foo)(?s:.*?))$`,
+			match:    []string{"FOObar"},
+			notMatch: []string{"myfoobar"},
+			out:      "pattern: ^foo\ncase_insensitive: true\nanchored: false\n",
+		},
+		{
+			in:  `^foo`,
+			err: `regexp "^foo" must not start with "^" or end with "$" as it is anchored implicitly, set allow_anchors to keep explicit anchors`,
+		},
+		{
+			in:  `'foo\\$'`,
+			err: `regexp "foo\\\\$" must not start with "^" or end with "$" as it is anchored implicitly, set allow_anchors to keep explicit anchors`,
+		},
+		{
+			in:  `{pattern: "foo$", case_insensitive: true}`,
+			err: `regexp "foo$" must not start with "^" or end with "$" as it is anchored implicitly, set allow_anchors to keep explicit anchors`,
+		},
+		{
+			in:  `{pattern: "(foo", case_insensitive: true}`,
+			err: "error parsing regexp: missing closing ): `^(?:(?i)(foo)$`",
+		},
+	}
+
+	for _, c := range cases {
+		var re Regexp
+		err := yaml.Unmarshal([]byte(c.in), &re)
+		if c.err != "" {
+			if err == nil || err.Error() != c.err {
+				t.Errorf("%s: expected error %q, got %v", c.in, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.in, err)
+			continue
+		}
+		if re.String() != c.expr {
+			t.Errorf("%s: expected expression %q, got %q", c.in, c.expr, re.String())
+		}
+		for _, s := range c.match {
+			if !re.MatchString(s) {
+				t.Errorf("%s: expected %q to match", c.in, s)
+			}
+		}
+		for _, s := range c.notMatch {
+			if re.MatchString(s) {
+				t.Errorf("%s: expected %q not to match", c.in, s)
+			}
+		}
+
+		out, err := yaml.Marshal(re)
+		if err != nil {
+			t.Errorf("%s: unexpected marshal error: %s", c.in, err)
+			continue
+		}
+		if string(out) != c.out {
+			t.Errorf("%s: expected marshaled form %q, got %q", c.in, c.out, out)
+		}
+	}
+}
+
+func TestRegexpOptionsInRoute(t *testing.T) {
+	const in = `
+route:
+  receiver: team-X
+  routes:
+  - receiver: team-X
+    match_re:
+      service:
+        pattern: "foo.*"
+        case_insensitive: true
+receivers:
+- name: team-X
+  blackhole: true
+`
+	cfg, err := Load(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	re := cfg.Route.Routes[0].MatchRE["service"]
+	if !re.MatchString("FOObar") {
+		t.Errorf("expected case-insensitive match of %q", "FOObar")
+	}
+
+	out, err := cfg.MarshalRedacted()
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %s", err)
+	}
+	if !strings.Contains(string(out), "case_insensitive: true") {
+		t.Errorf("expected options to be marshaled, got:\n%s", out)
+	}
+}
+
+func TestRegexpCache(t *testing.T) {
+	a, err := NewRegexp("db-[0-9]+")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewRegexp("db-[0-9]+")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Regexp != b.Regexp {
+		t.Errorf("Expected identical patterns to share the compiled regexp")
+	}
+	c, err := newRegexp(regexpObject{Pattern: "db-[0-9]+", CaseInsensitive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Regexp == a.Regexp || !c.MatchString("DB-1") {
+		t.Errorf("Expected options to be part of the cached expression")
+	}
+
+	cache := newRegexpLRU(2)
+	for _, expr := range []string{"a", "b", "a", "c"} {
+		cache.add(expr, regexp.MustCompile(expr))
+	}
+	if cache.len() != 2 {
+		t.Errorf("Expected 2 cached regexps, got %d", cache.len())
+	}
+	// b is the least recently used expression.
+	if cache.get("b") != nil || cache.get("a") == nil || cache.get("c") == nil {
+		t.Errorf("Expected the least recently used regexp to be evicted")
+	}
+}
+
+// BenchmarkLoadSharedRegexps loads a config with many routes sharing the
+// same patterns with an empty cache for every load or with the patterns
+// cached by previous loads.
+func BenchmarkLoadSharedRegexps(b *testing.B) {
+	var buf strings.Builder
+	buf.WriteString("route:\n  receiver: default\n  routes:\n")
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&buf, "  - receiver: default\n    match_re:\n      service: 'api-(eu|us)-[0-9]+|db-%d'\n      instance: '.*\\.example\\.com(:[0-9]+)?'\n", i%10)
+	}
+	buf.WriteString("receivers:\n- name: default\n  blackhole: true\n")
+	in := buf.String()
+
+	defer func(c *regexpLRU) { regexpCache = c }(regexpCache)
+	b.Run("cold", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			regexpCache = newRegexpLRU(regexpCacheSize)
+			if _, err := Load(in); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("warm", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Load(in); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,263 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// The routing options that apply where no route on the path to a matched
+// route sets them.
+var (
+	DefaultGroupBy        = []model.LabelName{model.AlertNameLabel}
+	DefaultGroupWait      = Duration(30 * time.Second)
+	DefaultGroupInterval  = Duration(5 * time.Minute)
+	DefaultRepeatInterval = Duration(4 * time.Hour)
+)
+
+// groupByAllToken is the group_by value to group alerts by all labels.
+const groupByAllToken = "..."
+
+// EffectiveConfig holds the routing options in effect for a route after
+// the options it does not set are inherited from its parents.
+type EffectiveConfig struct {
+	Receiver       string
+	GroupBy        []model.LabelName
+	GroupByAll     bool
+	GroupWait      Duration
+	GroupInterval  Duration
+	RepeatInterval Duration
+	InitialDelay   Duration
+	GroupLimit     int
+	MinGroupSize   int
+}
+
+// EffectiveConfigForPath returns the routing options in effect for the
+// last route of a path of routes starting at the root of a routing tree.
+func EffectiveConfigForPath(path []*Route) EffectiveConfig {
+	ec := EffectiveConfig{
+		GroupBy:        DefaultGroupBy,
+		GroupWait:      DefaultGroupWait,
+		GroupInterval:  DefaultGroupInterval,
+		RepeatInterval: DefaultRepeatInterval,
+	}
+	for _, r := range path {
+		ec = ec.inherit(r)
+	}
+	return ec
+}
+
+// inherit returns the options in effect for a child route r of the route
+// the options apply to.
+func (ec EffectiveConfig) inherit(r *Route) EffectiveConfig {
+	if r.Receiver != "" {
+		ec.Receiver = r.Receiver
+	}
+	// An empty, non-nil GroupBy overrides the grouping of the parent.
+	if r.GroupBy != nil || r.GroupByAll {
+		ec.GroupBy = r.GroupBy
+		ec.GroupByAll = r.GroupByAll
+	}
+	if r.GroupWait != nil {
+		ec.GroupWait = *r.GroupWait
+	}
+	if r.GroupInterval != nil {
+		ec.GroupInterval = *r.GroupInterval
+	}
+	if r.RepeatInterval != nil {
+		ec.RepeatInterval = *r.RepeatInterval
+	}
+	if r.InitialDelay != nil {
+		ec.InitialDelay = *r.InitialDelay
+	}
+	if r.GroupLimit != nil {
+		ec.GroupLimit = *r.GroupLimit
+	}
+	if r.MinGroupSize != nil {
+		ec.MinGroupSize = *r.MinGroupSize
+	}
+	return ec
+}
+
+// RouteMatch is a route matched by a label set together with the routing
+// options that are in effect for it.
+type RouteMatch struct {
+	Route *Route
+	EffectiveConfig
+	// Muted is true if the match falls into one of the mute time
+	// intervals of the route. It is only set by RoutesForLabelsAt.
+	Muted bool
+}
+
+// RoutesForLabels returns the routes of the routing tree that alerts
+// with the given labels are sent to, in the order in which they are
+// matched.
+func (c *Config) RoutesForLabels(lset model.LabelSet) []RouteMatch {
+	if c.Route == nil {
+		return nil
+	}
+	return c.Route.matchLabels(lset, EffectiveConfigForPath(nil), nil)
+}
+
+// RoutesForLabelsAt is like RoutesForLabels, but takes the time intervals
+// of the routes into account. Routes with active time intervals only
+// match if t falls into one of them. Matches during one of the mute time
+// intervals of their route are marked as muted.
+func (c *Config) RoutesForLabelsAt(lset model.LabelSet, t time.Time) []RouteMatch {
+	if c.Route == nil {
+		return nil
+	}
+	intervals := make(map[string]*TimeInterval, len(c.TimeIntervals))
+	for _, ti := range c.TimeIntervals {
+		intervals[ti.Name] = ti
+	}
+	inAny := func(names []string) bool {
+		for _, name := range names {
+			if ti, ok := intervals[name]; ok && ti.Contains(t) {
+				return true
+			}
+		}
+		return false
+	}
+
+	active := func(r *Route) bool {
+		return len(r.ActiveTimeIntervals) == 0 || inAny(r.ActiveTimeIntervals)
+	}
+	matches := c.Route.matchLabels(lset, EffectiveConfigForPath(nil), active)
+	for i := range matches {
+		matches[i].Muted = inAny(matches[i].Route.MuteTimeIntervals)
+	}
+	return matches
+}
+
+// MatchLabels does a depth-first left-to-right search through the route
+// tree and returns the matching routes. A route only matches if none of
+// its children match, unless all of them are skipped by Continue.
+func (r *Route) MatchLabels(lset model.LabelSet) []*Route {
+	var routes []*Route
+	for _, m := range r.matchLabels(lset, EffectiveConfig{}, nil) {
+		routes = append(routes, m.Route)
+	}
+	return routes
+}
+
+// IsLeaf returns true if the route has no child routes.
+func (r *Route) IsLeaf() bool {
+	return len(r.Routes) == 0
+}
+
+// Walk calls fn for the route and all of its descendants in depth-first
+// order, visiting each route before its children.
+func (r *Route) Walk(fn func(*Route)) {
+	fn(r)
+	for _, cr := range r.Routes {
+		cr.Walk(fn)
+	}
+}
+
+// matchLabels returns the matches in the subtree of r. If active is not
+// nil, routes for which it returns false are skipped.
+func (r *Route) matchLabels(lset model.LabelSet, parent EffectiveConfig, active func(*Route) bool) []RouteMatch {
+	if !r.matches(lset) || active != nil && !active(r) {
+		return nil
+	}
+
+	m := RouteMatch{Route: r, EffectiveConfig: parent.inherit(r)}
+
+	var all []RouteMatch
+	for _, cr := range r.Routes {
+		matches := cr.matchLabels(lset, m.EffectiveConfig, active)
+		all = append(all, matches...)
+
+		if matches != nil && !cr.Continue {
+			break
+		}
+	}
+
+	// If no child nodes were matches, the current node itself is
+	// a match.
+	if len(all) == 0 {
+		all = append(all, m)
+	}
+	return all
+}
+
+// matches returns true if the label set fulfills all matchers of the
+// route itself.
+func (r *Route) matches(lset model.LabelSet) bool {
+	for ln, v := range r.Match {
+		if string(lset[model.LabelName(ln)]) != v {
+			return false
+		}
+	}
+	for ln, re := range r.MatchRE {
+		if !re.MatchString(string(lset[model.LabelName(ln)])) {
+			return false
+		}
+	}
+	for ln, v := range r.MatchNot {
+		if string(lset[model.LabelName(ln)]) == v {
+			return false
+		}
+	}
+	for ln, re := range r.MatchNotRE {
+		if re.MatchString(string(lset[model.LabelName(ln)])) {
+			return false
+		}
+	}
+	for ln, vs := range r.MatchIn {
+		if !containsString(vs, string(lset[model.LabelName(ln)])) {
+			return false
+		}
+	}
+	for ln, vs := range r.MatchNotIn {
+		if containsString(vs, string(lset[model.LabelName(ln)])) {
+			return false
+		}
+	}
+	for ln, nets := range r.MatchCIDR {
+		if !InNetworks(string(lset[model.LabelName(ln)]), nets) {
+			return false
+		}
+	}
+	for _, m := range r.Matchers {
+		if !m.Matches(string(lset[model.LabelName(m.Name)])) {
+			return false
+		}
+	}
+	// Only labels are known here, so annotation matchers are evaluated
+	// as for alerts without annotations.
+	for _, v := range r.AnnotationMatch {
+		if v != "" {
+			return false
+		}
+	}
+	for _, re := range r.AnnotationMatchRE {
+		if !re.MatchString("") {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(ss []string, s string) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,429 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+func TestRoutesForLabels(t *testing.T) {
+	cfg, err := Load(`
+route:
+  receiver: notify-def
+  group_by: [alertname, cluster]
+  repeat_interval: 2h
+
+  routes:
+  - match:
+      owner: team-A
+    receiver: notify-A
+
+    routes:
+    - match:
+        env: testing
+      receiver: notify-testing
+      group_by: []
+
+    - match:
+        env: production
+      receiver: notify-productionA
+      group_wait: 1m
+      continue: true
+
+    - match_re:
+        env: produ.*
+      receiver: notify-productionB
+      group_interval: 10m
+      group_by: [job]
+
+  - match_re:
+      owner: team-(B|C)
+    receiver: notify-BC
+    group_wait: 2m
+
+receivers:
+- name: notify-def
+  blackhole: true
+- name: notify-A
+  blackhole: true
+- name: notify-testing
+  blackhole: true
+- name: notify-productionA
+  blackhole: true
+- name: notify-productionB
+  blackhole: true
+- name: notify-BC
+  blackhole: true
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	var (
+		defGroupBy = []model.LabelName{"alertname", "cluster"}
+		repeat     = Duration(2 * time.Hour)
+	)
+	cases := []struct {
+		lset    model.LabelSet
+		matches []RouteMatch
+	}{
+		{
+			lset: model.LabelSet{"owner": "team-X"},
+			matches: []RouteMatch{{
+				EffectiveConfig: EffectiveConfig{
+					Receiver:       "notify-def",
+					GroupBy:        defGroupBy,
+					GroupWait:      DefaultGroupWait,
+					GroupInterval:  DefaultGroupInterval,
+					RepeatInterval: repeat,
+				},
+			}},
+		},
+		{
+			lset: model.LabelSet{"owner": "team-A", "env": "unset"},
+			matches: []RouteMatch{{
+				EffectiveConfig: EffectiveConfig{
+					Receiver:       "notify-A",
+					GroupBy:        defGroupBy,
+					GroupWait:      DefaultGroupWait,
+					GroupInterval:  DefaultGroupInterval,
+					RepeatInterval: repeat,
+				},
+			}},
+		},
+		{
+			lset: model.LabelSet{"owner": "team-A", "env": "testing"},
+			matches: []RouteMatch{{
+				EffectiveConfig: EffectiveConfig{
+					Receiver:       "notify-testing",
+					GroupBy:        []model.LabelName{},
+					GroupWait:      DefaultGroupWait,
+					GroupInterval:  DefaultGroupInterval,
+					RepeatInterval: repeat,
+				},
+			}},
+		},
+		{
+			lset: model.LabelSet{"owner": "team-A", "env": "production"},
+			matches: []RouteMatch{
+				{
+					EffectiveConfig: EffectiveConfig{
+						Receiver:       "notify-productionA",
+						GroupBy:        defGroupBy,
+						GroupWait:      Duration(time.Minute),
+						GroupInterval:  DefaultGroupInterval,
+						RepeatInterval: repeat,
+					},
+				},
+				{
+					EffectiveConfig: EffectiveConfig{
+						Receiver:       "notify-productionB",
+						GroupBy:        []model.LabelName{"job"},
+						GroupWait:      DefaultGroupWait,
+						GroupInterval:  Duration(10 * time.Minute),
+						RepeatInterval: repeat,
+					},
+				},
+			},
+		},
+		{
+			lset: model.LabelSet{"owner": "team-C"},
+			matches: []RouteMatch{{
+				EffectiveConfig: EffectiveConfig{
+					Receiver:       "notify-BC",
+					GroupBy:        defGroupBy,
+					GroupWait:      Duration(2 * time.Minute),
+					GroupInterval:  DefaultGroupInterval,
+					RepeatInterval: repeat,
+				},
+			}},
+		},
+	}
+
+	for _, c := range cases {
+		matches := cfg.RoutesForLabels(c.lset)
+		routes := cfg.Route.MatchLabels(c.lset)
+		if len(routes) != len(matches) {
+			t.Errorf("Expected MatchLabels and RoutesForLabels to agree for %v", c.lset)
+		}
+		for i := range matches {
+			if routes[i] != matches[i].Route {
+				t.Errorf("Expected MatchLabels and RoutesForLabels to agree for %v", c.lset)
+			}
+			if matches[i].Route.Receiver != c.matches[i].Receiver {
+				t.Errorf("Unexpected route for %v: %+v", c.lset, matches[i].Route)
+			}
+			matches[i].Route = nil
+		}
+		if !reflect.DeepEqual(matches, c.matches) {
+			t.Errorf("Unexpected matches for %v:\nexpected %+v\ngot      %+v", c.lset, c.matches, matches)
+		}
+	}
+}
+
+func TestEffectiveConfigForPath(t *testing.T) {
+	cfg, err := Load(`
+route:
+  receiver: default
+  group_by: [alertname, cluster]
+  group_wait: 1m
+  group_interval: 10m
+  group_limit: 100
+
+  routes:
+  - match:
+      owner: team-A
+    receiver: team-A
+    group_limit: 0
+    min_group_size: 5
+    routes:
+    - match:
+        severity: critical
+      group_wait: 10s
+
+receivers:
+- name: default
+  blackhole: true
+- name: team-A
+  blackhole: true
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	var (
+		path  []*Route
+		paths = map[*Route][]*Route{}
+	)
+	cfg.Route.Walk(func(r *Route) {
+		// Routes are visited before their children, so the path to a
+		// route extends the path to the last visited route whose
+		// children it is part of.
+		for len(path) > 0 && !isChild(path[len(path)-1], r) {
+			path = path[:len(path)-1]
+		}
+		path = append(path, r)
+		paths[r] = append([]*Route(nil), path...)
+	})
+	if len(paths) != 3 {
+		t.Fatalf("Expected to walk 3 routes, walked %d", len(paths))
+	}
+
+	leaf := cfg.Route.Routes[0].Routes[0]
+	expected := EffectiveConfig{
+		Receiver:       "team-A",
+		GroupBy:        []model.LabelName{"alertname", "cluster"},
+		GroupWait:      Duration(10 * time.Second),
+		GroupInterval:  Duration(10 * time.Minute),
+		RepeatInterval: DefaultRepeatInterval,
+		MinGroupSize:   5,
+	}
+	if ec := EffectiveConfigForPath(paths[leaf]); !reflect.DeepEqual(ec, expected) {
+		t.Errorf("Unexpected effective config:\nexpected %+v\ngot      %+v", expected, ec)
+	}
+	if ec := EffectiveConfigForPath(paths[cfg.Route]); ec.GroupLimit != 100 || ec.MinGroupSize != 0 {
+		t.Errorf("Expected group limit 100 and no minimum group size for root route, got %d and %d", ec.GroupLimit, ec.MinGroupSize)
+	}
+}
+
+func TestGroupLimitValidation(t *testing.T) {
+	expectLoadError(t, `
+route:
+  receiver: default
+  routes:
+  - group_limit: -1
+
+receivers:
+- name: default
+  blackhole: true
+`, "route.routes[0].group_limit: group_limit must not be negative")
+}
+
+func TestMinGroupSizeValidation(t *testing.T) {
+	expectLoadError(t, `
+route:
+  receiver: default
+  routes:
+  - min_group_size: -1
+
+receivers:
+- name: default
+  blackhole: true
+`, "route.routes[0].min_group_size: min_group_size must not be negative")
+}
+
+func TestInitialDelay(t *testing.T) {
+	cfg, err := Load(`
+route:
+  receiver: default
+  group_wait: 30s
+  initial_delay: 2m
+  routes:
+  - match:
+      service: flappy
+    group_wait: 1m
+  - match:
+      service: stable
+    initial_delay: 10s
+
+receivers:
+- name: default
+  blackhole: true
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	for i, want := range []EffectiveConfig{
+		{GroupWait: Duration(time.Minute), InitialDelay: Duration(2 * time.Minute)},
+		{GroupWait: Duration(30 * time.Second), InitialDelay: Duration(10 * time.Second)},
+	} {
+		ec := EffectiveConfigForPath([]*Route{cfg.Route, cfg.Route.Routes[i]})
+		if ec.GroupWait != want.GroupWait || ec.InitialDelay != want.InitialDelay {
+			t.Errorf("Expected group_wait %s and initial_delay %s for route %d, got %s and %s", want.GroupWait, want.InitialDelay, i, ec.GroupWait, ec.InitialDelay)
+		}
+	}
+	if ec := EffectiveConfigForPath(nil); ec.InitialDelay != 0 {
+		t.Errorf("Expected no initial delay by default, got %s", ec.InitialDelay)
+	}
+
+	expectLoadError(t, `
+route:
+  receiver: default
+  routes:
+  - initial_delay: 0s
+
+receivers:
+- name: default
+  blackhole: true
+`, "route.routes[0].initial_delay: initial_delay must be positive")
+}
+
+func TestMatchInValidation(t *testing.T) {
+	cases := []struct {
+		route string
+		err   string
+	}{
+		{
+			route: `
+  - match_in:
+      severity: []
+`,
+			err: `match_in for label "severity" must list at least one value`,
+		},
+		{
+			route: `
+  - match_not_in:
+      env: []
+`,
+			err: `match_not_in for label "env" must list at least one value`,
+		},
+		{
+			route: `
+  - match_in:
+      "invalid-label": [a]
+`,
+			err: `invalid label name "invalid-label"`,
+		},
+		{
+			route: `
+  - match:
+      severity: critical
+    match_re:
+      severity: warn.*
+`,
+			err: `label "severity" must not be in both match and match_re`,
+		},
+		{
+			route: `
+  - annotation_match:
+      "": a
+`,
+			err: `invalid annotation name ""`,
+		},
+		{
+			route: `
+  - annotation_match_re:
+      runbook: "(wiki"
+`,
+			err: "error parsing regexp",
+		},
+		{
+			route: `
+  - annotation_match:
+      summary: a
+    annotation_match_re:
+      summary: a.*
+`,
+			err: `annotation "summary" must not be in both annotation_match and annotation_match_re`,
+		},
+	}
+
+	for _, c := range cases {
+		expectLoadError(t, `
+route:
+  receiver: default
+  routes:`+c.route+`
+receivers:
+- name: default
+  blackhole: true
+`, c.err)
+	}
+}
+
+func TestAnnotationMatchers(t *testing.T) {
+	cfg, err := Load(`
+route:
+  receiver: default
+  routes:
+  - annotation_match:
+      owning team: database
+    receiver: database
+  - receiver: default
+
+receivers:
+- name: default
+  blackhole: true
+- name: database
+  blackhole: true
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	r := cfg.Route.Routes[0]
+	if r.AnnotationMatch["owning team"] != "database" {
+		t.Errorf("Expected annotation matcher with a free-form name, got %v", r.AnnotationMatch)
+	}
+	// Routing by labels only does not know the annotations.
+	if ms := cfg.RoutesForLabels(model.LabelSet{"owning team": "database"}); len(ms) != 1 || ms[0].Receiver != "default" {
+		t.Errorf("Expected the default route to match, got %v", ms)
+	}
+	// The annotation matcher does not make the route shadow its sibling.
+	if ws := cfg.Lint(); len(ws) != 0 {
+		t.Errorf("Unexpected warnings %v", ws)
+	}
+}
+
+func isChild(parent, r *Route) bool {
+	for _, cr := range parent.Routes {
+		if cr == r {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import "fmt"
+
+// SigV4Config configures the AWS Signature Version 4 signing of requests.
+// Options left empty are taken from the environment of the Alertmanager
+// process as usual for AWS clients.
+type SigV4Config struct {
+	Region string `yaml:"region,omitempty"`
+
+	// AccessKey and SecretKey must either both be set or both be empty.
+	AccessKey Secret `yaml:"access_key,omitempty"`
+	SecretKey Secret `yaml:"secret_key,omitempty"`
+
+	// Profile is the name of the profile in the shared credentials file.
+	Profile string `yaml:"profile,omitempty"`
+	// RoleARN is the role to assume with the credentials.
+	RoleARN string `yaml:"role_arn,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *SigV4Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain SigV4Config
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if (c.AccessKey == "") != (c.SecretKey == "") {
+		return fmt.Errorf("access_key and secret_key must be set together in sigv4 config")
+	}
+	return checkOverflow(c.XXX, "sigv4 config", c)
+}
@@ -0,0 +1,430 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	weekdayNames = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+	monthNames   = []string{"january", "february", "march", "april", "may", "june", "july", "august", "september", "october", "november", "december"}
+)
+
+// TimeInterval is a named set of time ranges that routes can refer to,
+// e.g. to mute notifications outside of business hours.
+type TimeInterval struct {
+	Name          string             `yaml:"name"`
+	TimeIntervals []TimeIntervalSpec `yaml:"time_intervals"`
+	// Location is the time zone the ranges of the interval are evaluated
+	// in. It defaults to UTC.
+	Location *Location `yaml:"location,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (ti *TimeInterval) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain TimeInterval
+	if err := unmarshal((*plain)(ti)); err != nil {
+		return err
+	}
+	if ti.Name == "" {
+		return fmt.Errorf("missing name in time interval")
+	}
+	return checkOverflow(ti.XXX, "time interval", ti)
+}
+
+// TimeIntervalSpec describes a period of time. A point in time is part of
+// it if it matches all of the configured ranges. Ranges that are not
+// configured match any time.
+type TimeIntervalSpec struct {
+	Times       []TimeRange       `yaml:"times,omitempty"`
+	Weekdays    []WeekdayRange    `yaml:"weekdays,omitempty"`
+	DaysOfMonth []DayOfMonthRange `yaml:"days_of_month,omitempty"`
+	Months      []MonthRange      `yaml:"months,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *TimeIntervalSpec) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain TimeIntervalSpec
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	return checkOverflow(s.XXX, "time interval spec", s)
+}
+
+// Contains returns true if t falls into any of the periods of the time
+// interval, evaluated in its location.
+func (ti *TimeInterval) Contains(t time.Time) bool {
+	loc := time.UTC
+	if ti.Location != nil {
+		loc = ti.Location.Location
+	}
+	t = t.In(loc)
+	for _, spec := range ti.TimeIntervals {
+		if spec.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// MuteIntervalActive returns true if t falls into the time interval with
+// the given name, so that routes muted by it do not send notifications.
+func (c *Config) MuteIntervalActive(name string, t time.Time) (bool, error) {
+	for _, ti := range c.TimeIntervals {
+		if ti.Name == name {
+			return ti.Contains(t), nil
+		}
+	}
+	return false, fmt.Errorf("undefined time interval %q", name)
+}
+
+// contains returns true if the local time of t is part of the period.
+func (s *TimeIntervalSpec) contains(t time.Time) bool {
+	if len(s.Times) > 0 {
+		minute := t.Hour()*60 + t.Minute()
+		found := false
+		for _, tr := range s.Times {
+			if minute >= tr.StartMinute && minute < tr.EndMinute {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(s.Weekdays) > 0 && !inRanges(int(t.Weekday()), weekdayRanges(s.Weekdays)) {
+		return false
+	}
+	if len(s.DaysOfMonth) > 0 {
+		// Negative days count from the end of the month.
+		days := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		found := false
+		for _, r := range s.DaysOfMonth {
+			begin, end := r.Begin, r.End
+			if begin < 0 {
+				begin += days + 1
+			}
+			if end < 0 {
+				end += days + 1
+			}
+			if t.Day() >= begin && t.Day() <= end {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(s.Months) > 0 && !inRanges(int(t.Month()), monthRanges(s.Months)) {
+		return false
+	}
+	return true
+}
+
+func weekdayRanges(rs []WeekdayRange) []InclusiveRange {
+	res := make([]InclusiveRange, 0, len(rs))
+	for _, r := range rs {
+		res = append(res, r.InclusiveRange)
+	}
+	return res
+}
+
+func monthRanges(rs []MonthRange) []InclusiveRange {
+	res := make([]InclusiveRange, 0, len(rs))
+	for _, r := range rs {
+		res = append(res, r.InclusiveRange)
+	}
+	return res
+}
+
+// inRanges returns true if v is in any of the ranges.
+func inRanges(v int, rs []InclusiveRange) bool {
+	for _, r := range rs {
+		if v >= r.Begin && v <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Location is a time zone loaded from the IANA Time Zone database, such
+// as "Europe/Berlin".
+type Location struct {
+	*time.Location
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (l *Location) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return fmt.Errorf("invalid location %q: %s", s, err)
+	}
+	l.Location = loc
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (l *Location) MarshalYAML() (interface{}, error) {
+	if l == nil || l.Location == nil {
+		return nil, nil
+	}
+	return l.String(), nil
+}
+
+// TimeRange is a range of the day in minutes. StartMinute is inclusive,
+// EndMinute is exclusive.
+type TimeRange struct {
+	StartMinute int
+	EndMinute   int
+}
+
+type yamlTimeRange struct {
+	StartTime string `yaml:"start_time"`
+	EndTime   string `yaml:"end_time"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (tr *TimeRange) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var y yamlTimeRange
+	if err := unmarshal(&y); err != nil {
+		return err
+	}
+	if err := checkOverflow(y.XXX, "time range", y); err != nil {
+		return err
+	}
+	if y.StartTime == "" || y.EndTime == "" {
+		return fmt.Errorf("both start_time and end_time must be set in time range")
+	}
+	start, err := parseTimeOfDay(y.StartTime)
+	if err != nil {
+		return err
+	}
+	end, err := parseTimeOfDay(y.EndTime)
+	if err != nil {
+		return err
+	}
+	if start >= end {
+		return fmt.Errorf("invalid time range %s-%s: start time must be before end time", y.StartTime, y.EndTime)
+	}
+	tr.StartMinute, tr.EndMinute = start, end
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (tr TimeRange) MarshalYAML() (interface{}, error) {
+	return yamlTimeRange{
+		StartTime: fmt.Sprintf("%02d:%02d", tr.StartMinute/60, tr.StartMinute%60),
+		EndTime:   fmt.Sprintf("%02d:%02d", tr.EndMinute/60, tr.EndMinute%60),
+	}, nil
+}
+
+// parseTimeOfDay parses a time of day of the form HH:MM into minutes
+// since midnight. 24:00 is accepted to denote the end of the day.
+func parseTimeOfDay(s string) (int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 || len(parts[1]) != 2 {
+		return 0, fmt.Errorf("invalid time of day %q: must be of the form HH:MM", s)
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: must be of the form HH:MM", s)
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: must be of the form HH:MM", s)
+	}
+	if hours < 0 || hours > 24 {
+		return 0, fmt.Errorf("invalid time of day %q: hour must be between 0 and 24", s)
+	}
+	if minutes < 0 || minutes > 59 {
+		return 0, fmt.Errorf("invalid time of day %q: minute must be between 0 and 59", s)
+	}
+	if hours == 24 && minutes != 0 {
+		return 0, fmt.Errorf("invalid time of day %q: must not be after 24:00", s)
+	}
+	return hours*60 + minutes, nil
+}
+
+// InclusiveRange is a range of integers including both of its bounds.
+type InclusiveRange struct {
+	Begin int
+	End   int
+}
+
+// WeekdayRange is an inclusive range of days of the week, with Sunday
+// being 0. It is written as "monday:friday" or "saturday".
+type WeekdayRange struct {
+	InclusiveRange
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (r *WeekdayRange) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	begin, end, err := parseRange(s, func(v string) (int, error) {
+		return parseName(v, weekdayNames, "weekday")
+	})
+	if err != nil {
+		return fmt.Errorf("invalid weekday range %q: %s", s, err)
+	}
+	if begin > end {
+		return fmt.Errorf("invalid weekday range %q: start day must not be after end day", s)
+	}
+	r.Begin, r.End = begin, end
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (r WeekdayRange) MarshalYAML() (interface{}, error) {
+	return formatRange(weekdayNames[r.Begin], weekdayNames[r.End]), nil
+}
+
+// DayOfMonthRange is an inclusive range of days of the month, starting
+// at 1. Negative values count from the end of the month, -1 being its
+// last day. It is written as "1:15" or "-1".
+type DayOfMonthRange struct {
+	InclusiveRange
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (r *DayOfMonthRange) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	begin, end, err := parseRange(s, func(v string) (int, error) {
+		d, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid day %q", v)
+		}
+		if d == 0 || d < -31 || d > 31 {
+			return 0, fmt.Errorf("day %d must be between 1 and 31 or -31 and -1", d)
+		}
+		return d, nil
+	})
+	if err != nil {
+		return fmt.Errorf("invalid day of month range %q: %s", s, err)
+	}
+	// A positive start day and a negative end day cannot be compared
+	// without knowing the length of the month, the reverse always is
+	// out of order.
+	if (begin > 0) == (end > 0) && begin > end || begin < 0 && end > 0 {
+		return fmt.Errorf("invalid day of month range %q: start day must not be after end day", s)
+	}
+	r.Begin, r.End = begin, end
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (r DayOfMonthRange) MarshalYAML() (interface{}, error) {
+	return formatRange(strconv.Itoa(r.Begin), strconv.Itoa(r.End)), nil
+}
+
+// MonthRange is an inclusive range of months, starting at 1 for January.
+// It is written as "january:march", "1:3" or "december".
+type MonthRange struct {
+	InclusiveRange
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (r *MonthRange) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	begin, end, err := parseRange(s, func(v string) (int, error) {
+		if m, err := strconv.Atoi(v); err == nil {
+			if m < 1 || m > 12 {
+				return 0, fmt.Errorf("month %d must be between 1 and 12", m)
+			}
+			return m, nil
+		}
+		m, err := parseName(v, monthNames, "month")
+		return m + 1, err
+	})
+	if err != nil {
+		return fmt.Errorf("invalid month range %q: %s", s, err)
+	}
+	if begin > end {
+		return fmt.Errorf("invalid month range %q: start month must not be after end month", s)
+	}
+	r.Begin, r.End = begin, end
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (r MonthRange) MarshalYAML() (interface{}, error) {
+	return formatRange(monthNames[r.Begin-1], monthNames[r.End-1]), nil
+}
+
+// parseRange parses a range of the form "begin:end" or a single value,
+// using parse to convert the bounds.
+func parseRange(s string, parse func(string) (int, error)) (int, int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) > 2 {
+		return 0, 0, fmt.Errorf("must be of the form begin:end")
+	}
+	begin, err := parse(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(parts) == 1 {
+		return begin, begin, nil
+	}
+	end, err := parse(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return 0, 0, err
+	}
+	return begin, end, nil
+}
+
+// parseName returns the index of the given name in names. Names are
+// matched case-insensitively and may be abbreviated to three letters.
+func parseName(s string, names []string, kind string) (int, error) {
+	s = strings.ToLower(s)
+	for i, name := range names {
+		if s == name || (len(s) == 3 && strings.HasPrefix(name, s)) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown %s %q", kind, s)
+}
+
+func formatRange(begin, end string) string {
+	if begin == end {
+		return begin
+	}
+	return begin + ":" + end
+}
@@ -0,0 +1,402 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+)
+
+// configWithTimeIntervals returns a config with the given time intervals
+// section whose child route is muted during the interval "offhours".
+func configWithTimeIntervals(intervals string) string {
+	return `
+route:
+  receiver: default
+  routes:
+  - receiver: default
+    mute_time_intervals: [offhours]
+
+receivers:
+- name: default
+  blackhole: true
+
+time_intervals:
+` + intervals
+}
+
+func TestTimeIntervals(t *testing.T) {
+	cfg, err := Load(configWithTimeIntervals(`
+- name: offhours
+  time_intervals:
+  - times:
+    - start_time: "00:00"
+      end_time: "09:00"
+    - start_time: "17:00"
+      end_time: "24:00"
+    weekdays: ['mon:fri']
+  - weekdays: [saturday, Sunday]
+    days_of_month: ['1:7', '-1']
+    months: ['jan:3', december]
+`))
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	expected := []TimeIntervalSpec{
+		{
+			Times: []TimeRange{
+				{StartMinute: 0, EndMinute: 540},
+				{StartMinute: 1020, EndMinute: 1440},
+			},
+			Weekdays: []WeekdayRange{{InclusiveRange{1, 5}}},
+		},
+		{
+			Weekdays:    []WeekdayRange{{InclusiveRange{6, 6}}, {InclusiveRange{0, 0}}},
+			DaysOfMonth: []DayOfMonthRange{{InclusiveRange{1, 7}}, {InclusiveRange{-1, -1}}},
+			Months:      []MonthRange{{InclusiveRange{1, 3}}, {InclusiveRange{12, 12}}},
+		},
+	}
+	if got := cfg.TimeIntervals[0].TimeIntervals; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected time intervals %+v, got %+v", expected, got)
+	}
+
+	out, err := yaml.Marshal(cfg.TimeIntervals)
+	if err != nil {
+		t.Fatalf("Error marshaling time intervals: %s", err)
+	}
+	var again []*TimeInterval
+	if err := yaml.Unmarshal(out, &again); err != nil {
+		t.Fatalf("Error unmarshaling marshaled time intervals: %s", err)
+	}
+	if !reflect.DeepEqual(again, cfg.TimeIntervals) {
+		t.Errorf("Time intervals changed after marshaling:\n%s", out)
+	}
+}
+
+func TestTimeIntervalErrors(t *testing.T) {
+	cases := []struct {
+		intervals string
+		err       string
+	}{
+		{
+			intervals: `
+- name: offhours
+  time_intervals:
+  - times:
+    - start_time: "09:00"
+      end_time: "25:00"
+`,
+			err: `invalid time of day "25:00": hour must be between 0 and 24`,
+		},
+		{
+			intervals: `
+- name: offhours
+  time_intervals:
+  - times:
+    - start_time: "17:00"
+      end_time: "09:00"
+`,
+			err: "invalid time range 17:00-09:00: start time must be before end time",
+		},
+		{
+			intervals: `
+- name: offhours
+  time_intervals:
+  - weekdays: ['fri:mon']
+`,
+			err: `invalid weekday range "fri:mon": start day must not be after end day`,
+		},
+		{
+			intervals: `
+- name: offhours
+  time_intervals:
+  - weekdays: [funday]
+`,
+			err: `invalid weekday range "funday": unknown weekday "funday"`,
+		},
+		{
+			intervals: `
+- name: offhours
+  time_intervals:
+  - days_of_month: ['-1:5']
+`,
+			err: `invalid day of month range "-1:5": start day must not be after end day`,
+		},
+		{
+			intervals: `
+- name: offhours
+  time_intervals:
+  - months: ['13']
+`,
+			err: `invalid month range "13": month 13 must be between 1 and 12`,
+		},
+		{
+			intervals: `
+- name: offhours
+- name: offhours
+`,
+			err: `time interval name "offhours" is not unique`,
+		},
+		{
+			intervals: `
+- name: weekends
+`,
+			err: `route.routes[0].mute_time_intervals: undefined time interval "offhours"`,
+		},
+	}
+
+	for _, c := range cases {
+		expectLoadError(t, configWithTimeIntervals(c.intervals), c.err)
+	}
+}
+
+func TestActiveTimeIntervalErrors(t *testing.T) {
+	cases := []struct {
+		in  string
+		err string
+	}{
+		{
+			in: `
+route:
+  receiver: default
+  routes:
+  - receiver: default
+    active_time_intervals: [weekends]
+receivers:
+- name: default
+  blackhole: true
+time_intervals:
+- name: offhours
+`,
+			err: `route.routes[0].active_time_intervals: undefined time interval "weekends"`,
+		},
+		{
+			in: `
+route:
+  receiver: default
+  active_time_intervals: [weekends]
+receivers:
+- name: default
+  blackhole: true
+time_intervals:
+- name: weekends
+  time_intervals:
+  - weekdays: [saturday, sunday]
+`,
+			err: "root route must not have any active time intervals",
+		},
+	}
+
+	for _, c := range cases {
+		expectLoadError(t, c.in, c.err)
+	}
+}
+
+func TestRoutesForLabelsAt(t *testing.T) {
+	cfg, err := Load(`
+route:
+  receiver: default
+  routes:
+  - receiver: weekend-oncall
+    active_time_intervals: [weekends]
+    mute_time_intervals: [nights]
+  - receiver: oncall
+
+receivers:
+- name: default
+  blackhole: true
+- name: weekend-oncall
+  blackhole: true
+- name: oncall
+  blackhole: true
+
+time_intervals:
+- name: weekends
+  time_intervals:
+  - weekdays: [saturday, sunday]
+- name: nights
+  time_intervals:
+  - times:
+    - start_time: "00:00"
+      end_time: "06:00"
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	cases := []struct {
+		at       time.Time
+		receiver string
+		muted    bool
+	}{
+		{
+			// Wednesday noon, outside of the active interval.
+			at:       time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC),
+			receiver: "oncall",
+		},
+		{
+			// Saturday noon.
+			at:       time.Date(2017, 3, 4, 12, 0, 0, 0, time.UTC),
+			receiver: "weekend-oncall",
+		},
+		{
+			// Saturday night: the route matches, but the mute
+			// interval takes precedence.
+			at:       time.Date(2017, 3, 4, 3, 0, 0, 0, time.UTC),
+			receiver: "weekend-oncall",
+			muted:    true,
+		},
+		{
+			// Wednesday night: mute intervals of routes that do not
+			// match do not apply.
+			at:       time.Date(2017, 3, 1, 3, 0, 0, 0, time.UTC),
+			receiver: "oncall",
+		},
+	}
+
+	for _, c := range cases {
+		matches := cfg.RoutesForLabelsAt(model.LabelSet{"alertname": "test"}, c.at)
+		if len(matches) != 1 {
+			t.Fatalf("At %s: expected 1 match, got %d", c.at, len(matches))
+		}
+		if matches[0].Receiver != c.receiver || matches[0].Muted != c.muted {
+			t.Errorf("At %s: expected receiver %q muted %t, got %q muted %t", c.at, c.receiver, c.muted, matches[0].Receiver, matches[0].Muted)
+		}
+	}
+
+	// Without a time, active intervals are ignored.
+	if matches := cfg.RoutesForLabels(model.LabelSet{"alertname": "test"}); matches[0].Receiver != "weekend-oncall" {
+		t.Errorf("Expected receiver weekend-oncall, got %q", matches[0].Receiver)
+	}
+}
+
+func TestTimeIntervalContains(t *testing.T) {
+	spec := TimeIntervalSpec{
+		Times:       []TimeRange{{StartMinute: 540, EndMinute: 1020}},
+		Weekdays:    []WeekdayRange{{InclusiveRange{1, 5}}},
+		DaysOfMonth: []DayOfMonthRange{{InclusiveRange{-7, -1}}},
+		Months:      []MonthRange{{InclusiveRange{2, 2}}},
+	}
+	cases := []struct {
+		at       time.Time
+		expected bool
+	}{
+		// Tuesday in the last week of February.
+		{at: time.Date(2017, 2, 28, 9, 0, 0, 0, time.UTC), expected: true},
+		{at: time.Date(2017, 2, 28, 16, 59, 0, 0, time.UTC), expected: true},
+		{at: time.Date(2017, 2, 28, 17, 0, 0, 0, time.UTC), expected: false},
+		// Saturday.
+		{at: time.Date(2017, 2, 25, 12, 0, 0, 0, time.UTC), expected: false},
+		// Tuesday before the last week.
+		{at: time.Date(2017, 2, 21, 12, 0, 0, 0, time.UTC), expected: false},
+		// In a leap year, the 23rd is in the last week.
+		{at: time.Date(2016, 2, 23, 12, 0, 0, 0, time.UTC), expected: true},
+		// Times are compared in UTC.
+		{at: time.Date(2017, 2, 28, 12, 0, 0, 0, time.FixedZone("UTC+8", 8*3600)), expected: false},
+	}
+
+	ti := &TimeInterval{Name: "test", TimeIntervals: []TimeIntervalSpec{spec}}
+	for _, c := range cases {
+		if got := ti.Contains(c.at); got != c.expected {
+			t.Errorf("At %s: expected %t, got %t", c.at, c.expected, got)
+		}
+	}
+}
+
+func TestTimeIntervalLocation(t *testing.T) {
+	cfg, err := Load(configWithTimeIntervals(`
+- name: offhours
+  location: Europe/Berlin
+  time_intervals:
+  - times:
+    - start_time: "00:00"
+      end_time: "09:00"
+- name: maintenance
+  location: Europe/Berlin
+  time_intervals:
+  - times:
+    - start_time: "02:00"
+      end_time: "03:00"
+- name: utc
+  time_intervals:
+  - times:
+    - start_time: "00:00"
+      end_time: "09:00"
+`))
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	cases := []struct {
+		name     string
+		at       string
+		expected bool
+	}{
+		// Berlin is at UTC+1 before the switch to daylight saving time
+		// on 2017-03-26 and at UTC+2 after it.
+		{name: "offhours", at: "2017-03-25T07:30:00Z", expected: true},
+		{name: "offhours", at: "2017-03-25T08:00:00Z", expected: false},
+		{name: "offhours", at: "2017-03-27T06:30:00Z", expected: true},
+		{name: "offhours", at: "2017-03-27T07:00:00Z", expected: false},
+		{name: "utc", at: "2017-03-27T07:00:00Z", expected: true},
+		// The hour from 02:00 to 03:00 is skipped on 2017-03-26.
+		{name: "maintenance", at: "2017-03-26T00:59:00Z", expected: false},
+		{name: "maintenance", at: "2017-03-26T01:00:00Z", expected: false},
+		{name: "maintenance", at: "2017-03-25T01:30:00Z", expected: true},
+		// The hour from 02:00 to 03:00 occurs twice on 2017-10-29.
+		{name: "maintenance", at: "2017-10-29T00:30:00Z", expected: true},
+		{name: "maintenance", at: "2017-10-29T01:30:00Z", expected: true},
+		{name: "maintenance", at: "2017-10-29T02:30:00Z", expected: false},
+	}
+
+	for _, c := range cases {
+		at, err := time.Parse(time.RFC3339, c.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		active, err := cfg.MuteIntervalActive(c.name, at)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if active != c.expected {
+			t.Errorf("%s at %s: expected %t, got %t", c.name, c.at, c.expected, active)
+		}
+	}
+
+	if _, err := cfg.MuteIntervalActive("weekends", time.Now()); err == nil || err.Error() != `undefined time interval "weekends"` {
+		t.Errorf("Expected error for undefined time interval, got %v", err)
+	}
+
+	out, err := yaml.Marshal(cfg.TimeIntervals[0])
+	if err != nil {
+		t.Fatalf("Error marshaling time interval: %s", err)
+	}
+	var again TimeInterval
+	if err := yaml.Unmarshal(out, &again); err != nil {
+		t.Fatalf("Error unmarshaling marshaled time interval: %s", err)
+	}
+	if again.Location.String() != "Europe/Berlin" {
+		t.Errorf("Expected location Europe/Berlin after marshaling, got %s", again.Location)
+	}
+
+	expectLoadError(t, configWithTimeIntervals(`
+- name: offhours
+  location: Mars/Olympus_Mons
+`), `invalid location "Mars/Olympus_Mons"`)
+}
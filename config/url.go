@@ -0,0 +1,177 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// URL is an absolute HTTP or HTTPS URL.
+type URL struct {
+	*url.URL
+}
+
+// parseURL parses s into a URL. The returned error does not contain s, so
+// that it can be used for secret URLs.
+func parseURL(s string) (*URL, error) {
+	u, err := url.Parse(s)
+	if err != nil || !u.IsAbs() || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("must be an absolute http or https URL")
+	}
+	host, err := canonicalHost(u.Hostname(), strings.HasPrefix(u.Host, "["))
+	if err != nil {
+		return nil, fmt.Errorf("must be an absolute http or https URL with a valid IPv6 address")
+	}
+	if strings.HasPrefix(u.Host, "[") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+	return &URL{u}, nil
+}
+
+// splitHostPort splits s of the form host:port, where IPv6 addresses must
+// be enclosed in brackets like [2001:db8::1]:587. The host is returned in
+// its canonical form and the port must be numeric.
+func splitHostPort(s string) (host, port string, err error) {
+	host, port, err = net.SplitHostPort(s)
+	if err != nil {
+		if ip := net.ParseIP(s); ip != nil && strings.Contains(s, ":") {
+			return "", "", fmt.Errorf("missing port, IPv6 addresses must be enclosed in brackets like [%s]:25", s)
+		}
+		if ae, ok := err.(*net.AddrError); ok {
+			return "", "", fmt.Errorf("%s", ae.Err)
+		}
+		return "", "", err
+	}
+	if host == "" {
+		return "", "", fmt.Errorf("missing host")
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return "", "", fmt.Errorf("port must be numeric")
+	}
+	host, err = canonicalHost(host, strings.HasPrefix(s, "["))
+	if err != nil {
+		return "", "", err
+	}
+	return host, port, nil
+}
+
+// canonicalHost returns the canonical form of a host name or, if it was
+// enclosed in brackets, of an IPv6 address with an optional zone. Host
+// names are lower cased as they are case insensitive.
+func canonicalHost(host string, bracketed bool) (string, error) {
+	if !bracketed {
+		return strings.ToLower(host), nil
+	}
+	addr, zone := host, ""
+	if i := strings.LastIndex(host, "%"); i >= 0 {
+		addr, zone = host[:i], host[i:]
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil || !strings.Contains(addr, ":") || zone == "%" {
+		return "", fmt.Errorf("invalid IPv6 address %q", host)
+	}
+	canonical := ip.String()
+	if ip.To4() != nil {
+		// IPv4-mapped addresses would be written as IPv4 addresses.
+		canonical = strings.ToLower(addr)
+	}
+	return canonical + zone, nil
+}
+
+// mustParseURL parses s into a URL and panics if it is invalid.
+func mustParseURL(s string) *URL {
+	u, err := parseURL(s)
+	if err != nil {
+		panic(fmt.Sprintf("invalid URL %q: %s", s, err))
+	}
+	return u
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (u *URL) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	pu, err := parseURL(s)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q, %s", s, err)
+	}
+	*u = *pu
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (u *URL) MarshalYAML() (interface{}, error) {
+	if u == nil || u.URL == nil {
+		return nil, nil
+	}
+	return u.String(), nil
+}
+
+// withTrailingSlash returns the URL with a slash appended to its path
+// unless it already ends in one. API base URLs need it as the paths of
+// the API endpoints are appended to them.
+func (u *URL) withTrailingSlash() *URL {
+	if strings.HasSuffix(u.Path, "/") {
+		return u
+	}
+	cu := *u.URL
+	cu.Path += "/"
+	if cu.RawPath != "" {
+		cu.RawPath += "/"
+	}
+	return &URL{&cu}
+}
+
+// SecretURL is a URL that contains a secret, such as the token of a Slack
+// webhook. Like Secret, it is hidden when marshaled and it is not part of
+// errors.
+type SecretURL URL
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (u *SecretURL) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	pu, err := parseURL(s)
+	if err != nil {
+		return fmt.Errorf("invalid secret URL, %s", err)
+	}
+	*u = SecretURL(*pu)
+	return nil
+}
+
+// String hides the URL, which is available via the URL field.
+func (u SecretURL) String() string {
+	return "<hidden>"
+}
+
+// MarshalYAML implements the yaml.Marshaler interface. Like secrets, the
+// URL is always hidden.
+func (u *SecretURL) MarshalYAML() (interface{}, error) {
+	if u == nil || u.URL == nil {
+		return nil, nil
+	}
+	return "<hidden>", nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestURL(t *testing.T) {
+	cases := []struct {
+		in  string
+		out string
+		err string
+	}{
+		{in: `https://hooks.example.com/services/a%2Fb?x=1`},
+		{in: `http://localhost:8080/`},
+		{in: `http://[2001:db8::1]:8080/`},
+		{in: `http://[fe80::1%25eth0]/`},
+		{in: `http://[2001:DB8:0::1]/api`, out: `http://[2001:db8::1]/api`},
+		{in: `https://Hooks.Example.COM/services/Token`, out: `https://hooks.example.com/services/Token`},
+		{in: `"http://[2001:db8::zz]:8080/"`, err: `invalid URL "http://[2001:db8::zz]:8080/", must be an absolute http or https URL`},
+		{in: `"http://[10.0.0.1]/"`, err: `invalid URL "http://[10.0.0.1]/", must be an absolute http or https URL`},
+		{in: `api.slack.com/services/token`, err: `invalid URL "api.slack.com/services/token", must be an absolute http or https URL`},
+		{in: `/services/token`, err: `invalid URL "/services/token", must be an absolute http or https URL`},
+		{in: `ftp://example.com/`, err: `invalid URL "ftp://example.com/", must be an absolute http or https URL`},
+		{in: `"http://"`, err: `invalid URL "http://", must be an absolute http or https URL`},
+		{in: `"http://a b.com/"`, err: `invalid URL "http://a b.com/", must be an absolute http or https URL`},
+	}
+	for _, c := range cases {
+		var u URL
+		err := yaml.Unmarshal([]byte(c.in), &u)
+		if c.err != "" {
+			if err == nil || err.Error() != c.err {
+				t.Errorf("Expected error %q for %s, got %v", c.err, c.in, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %s", c.in, err)
+			continue
+		}
+		out, err := yaml.Marshal(&u)
+		if err != nil {
+			t.Fatalf("Error marshaling %s: %s", c.in, err)
+		}
+		if c.out == "" {
+			c.out = c.in
+		}
+		if string(out) != c.out+"\n" {
+			t.Errorf("Expected %s to marshal to %s, got %s", c.in, c.out, out)
+		}
+	}
+}
+
+func TestSecretURL(t *testing.T) {
+	var u SecretURL
+	err := yaml.Unmarshal([]byte(`hooks.slack.com/services/token`), &u)
+	if err == nil {
+		t.Fatalf("Expected error for URL without scheme")
+	}
+	if strings.Contains(err.Error(), "token") {
+		t.Errorf("Error reveals secret URL: %s", err)
+	}
+
+	if err := yaml.Unmarshal([]byte(`https://hooks.slack.com/services/token`), &u); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if s := u.String(); strings.Contains(s, "token") {
+		t.Errorf("String reveals secret URL: %s", s)
+	}
+	out, err := yaml.Marshal(&u)
+	if err != nil {
+		t.Fatalf("Error marshaling: %s", err)
+	}
+	if string(out) != "<hidden>\n" {
+		t.Errorf("Expected hidden URL, got %s", out)
+	}
+	out, err = marshalYAML(&u, true)
+	if err != nil {
+		t.Fatalf("Error marshaling with secrets: %s", err)
+	}
+	if string(out) != "https://hooks.slack.com/services/token\n" {
+		t.Errorf("Expected URL with secrets, got %s", out)
+	}
+}
+
+func TestURLFields(t *testing.T) {
+	cfg, err := Load(`
+global:
+  hipchat_url: https://hipchat.example.com/base
+  opsgenie_api_host: https://opsgenie.example.com
+route:
+  receiver: default
+receivers:
+- name: default
+  hipchat_configs:
+  - room_id: 1
+    auth_token: token
+  opsgenie_configs:
+  - api_key: key
+    api_host: https://opsgenie.example.org/api
+`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	rcv := cfg.Receivers[0]
+	if got := rcv.HipchatConfigs[0].APIURL.String(); got != "https://hipchat.example.com/base/" {
+		t.Errorf("Expected normalized global Hipchat URL, got %q", got)
+	}
+	if got := rcv.OpsGenieConfigs[0].APIHost.String(); got != "https://opsgenie.example.org/api/" {
+		t.Errorf("Expected normalized OpsGenie API host, got %q", got)
+	}
+	// The global URLs are normalized in the receivers only.
+	if got := cfg.Global.HipchatURL.String(); got != "https://hipchat.example.com/base" {
+		t.Errorf("Expected global Hipchat URL as configured, got %q", got)
+	}
+	// Unmarshaling global URLs leaves the defaults untouched.
+	if got := DefaultGlobalConfig.OpsGenieAPIHost.String(); got != "https://api.opsgenie.com/" {
+		t.Errorf("Expected default OpsGenie API host to be unchanged, got %q", got)
+	}
+
+	for _, in := range []string{
+		`{url: example.com/hook}`,
+		`{url: "mailto:team@example.com"}`,
+	} {
+		_, err := Load(`
+route:
+  receiver: default
+receivers:
+- name: default
+  webhook_configs:
+  - ` + in)
+		if err == nil || !strings.HasPrefix(err.Error(), "receivers[0].webhook_configs[0].url: invalid URL") {
+			t.Errorf("Expected invalid URL error for %s, got %v", in, err)
+		}
+	}
+}
@@ -165,7 +165,7 @@ func (d *Dispatcher) run(it provider.AlertIterator) {
 				continue
 			}
 
-			for _, r := range d.route.Match(alert.Labels) {
+			for _, r := range d.route.Match(alert.Labels, alert.Annotations) {
 				d.processAlert(alert, r)
 			}
 
@@ -211,7 +211,7 @@ func (d *Dispatcher) processAlert(alert *types.Alert, route *Route) {
 	group := model.LabelSet{}
 
 	for ln, lv := range alert.Labels {
-		if _, ok := route.RouteOpts.GroupBy[ln]; ok {
+		if _, ok := route.RouteOpts.GroupBy[ln]; ok || route.RouteOpts.GroupByAll {
 			group[ln] = lv
 		}
 	}
@@ -276,11 +276,16 @@ func newAggrGroup(ctx context.Context, labels model.LabelSet, opts *RouteOpts) *
 
 	// Set an initial one-time wait before flushing
 	// the first batch of notifications.
-	ag.next = time.NewTimer(ag.opts.GroupWait)
+	ag.next = time.NewTimer(ag.firstWait())
 
 	return ag
 }
 
+// firstWait returns how long the group waits before its first flush.
+func (ag *aggrGroup) firstWait() time.Duration {
+	return ag.opts.GroupWait + ag.opts.InitialDelay
+}
+
 func (ag *aggrGroup) String() string {
 	return fmt.Sprint(ag.fingerprint())
 }
@@ -326,15 +331,22 @@ func (ag *aggrGroup) run(nf notifyFunc) {
 			ctx = notify.WithGroupLabels(ctx, ag.labels)
 			ctx = notify.WithReceiver(ctx, ag.opts.Receiver)
 			ctx = notify.WithRepeatInterval(ctx, ag.opts.RepeatInterval)
+			ctx = notify.WithGroupLimit(ctx, ag.opts.GroupLimit)
 
 			// Wait the configured interval before calling flush again.
 			ag.mtx.Lock()
 			ag.next.Reset(ag.opts.GroupInterval)
 			ag.mtx.Unlock()
 
-			ag.flush(func(alerts ...*types.Alert) bool {
-				return nf(ctx, alerts...)
-			})
+			// Alerts are kept while the route is muted, so that they are
+			// notified about at the first flush after the mute ends.
+			if inTimeIntervals(ag.opts.MuteTimeIntervals, now) {
+				ag.log.Debugln("not flushing muted group")
+			} else {
+				ag.flush(func(alerts ...*types.Alert) bool {
+					return nf(ctx, alerts...)
+				})
+			}
 
 			cancel()
 
@@ -365,7 +377,7 @@ func (ag *aggrGroup) insert(alert *types.Alert) {
 
 	// Immediately trigger a flush if the wait duration for this
 	// alert is already over.
-	if !ag.hasSent && alert.StartsAt.Add(ag.opts.GroupWait).Before(time.Now()) {
+	if !ag.hasSent && alert.StartsAt.Add(ag.firstWait()).Before(time.Now()) {
 		ag.next.Reset(0)
 	}
 }
@@ -387,10 +399,28 @@ func (ag *aggrGroup) flush(notify func(...*types.Alert) bool) {
 	var (
 		alerts      = make(map[model.Fingerprint]*types.Alert, len(ag.alerts))
 		alertsSlice = make([]*types.Alert, 0, len(ag.alerts))
+		firing      = 0
 	)
 	for fp, alert := range ag.alerts {
 		alerts[fp] = alert
 		alertsSlice = append(alertsSlice, alert)
+		if !alert.Resolved() {
+			firing++
+		}
+	}
+
+	// Groups are only notified about once they reach their minimum size.
+	// Until then, resolved alerts are dropped as no one was notified about
+	// them. Once notified, a group is notified about until it is resolved.
+	if !ag.hasSent && firing < ag.opts.MinGroupSize {
+		for fp, a := range alerts {
+			if a.Resolved() {
+				delete(ag.alerts, fp)
+			}
+		}
+		ag.mtx.Unlock()
+		ag.log.Debugln("not flushing group below the minimum size", alertsSlice)
+		return
 	}
 
 	ag.mtx.Unlock()
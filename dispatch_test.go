@@ -214,3 +214,101 @@ func TestAggrGroup(t *testing.T) {
 
 	ag.stop()
 }
+
+func TestAggrGroupMinGroupSize(t *testing.T) {
+	opts := &RouteOpts{
+		Receiver:       "n1",
+		GroupBy:        map[model.LabelName]struct{}{},
+		GroupWait:      time.Hour,
+		GroupInterval:  time.Hour,
+		RepeatInterval: time.Hour,
+		MinGroupSize:   2,
+	}
+	ag := newAggrGroup(context.Background(), model.LabelSet{"a": "v1"}, opts)
+	defer ag.cancel()
+
+	alert := func(c model.LabelValue, endsAt time.Time) *types.Alert {
+		return &types.Alert{
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"a": "v1", "c": c},
+				StartsAt: time.Now().Add(-time.Minute),
+				EndsAt:   endsAt,
+			},
+			UpdatedAt: time.Now(),
+		}
+	}
+
+	var notified []*types.Alert
+	ntfy := func(alerts ...*types.Alert) bool {
+		notified = alerts
+		return true
+	}
+
+	// A single firing alert is below the minimum, a resolved one is
+	// dropped without notification.
+	ag.insert(alert("v3", time.Now().Add(time.Hour)))
+	ag.insert(alert("v4", time.Now().Add(-time.Second)))
+	ag.flush(ntfy)
+	if notified != nil {
+		t.Fatalf("Expected no notification below the minimum group size, got %v", notified)
+	}
+	if n := len(ag.alertSlice()); n != 1 {
+		t.Fatalf("Expected the resolved alert to be dropped, %d alerts left", n)
+	}
+
+	ag.insert(alert("v5", time.Now().Add(time.Hour)))
+	ag.flush(ntfy)
+	if len(notified) != 2 {
+		t.Fatalf("Expected notification about 2 alerts, got %v", notified)
+	}
+
+	// Once notified, the group is notified about below the minimum, too,
+	// so that resolved alerts are sent.
+	notified = nil
+	ag.insert(alert("v5", time.Now().Add(-time.Second)))
+	ag.flush(ntfy)
+	if len(notified) != 2 {
+		t.Fatalf("Expected notification about 2 alerts, got %v", notified)
+	}
+}
+
+func TestAggrGroupInitialDelay(t *testing.T) {
+	opts := &RouteOpts{
+		Receiver:       "n1",
+		GroupBy:        map[model.LabelName]struct{}{},
+		GroupWait:      100 * time.Millisecond,
+		GroupInterval:  time.Hour,
+		RepeatInterval: time.Hour,
+		InitialDelay:   200 * time.Millisecond,
+	}
+	ag := newAggrGroup(context.Background(), model.LabelSet{"a": "v1"}, opts)
+
+	notified := make(chan time.Time, 1)
+	ntfy := func(ctx context.Context, alerts ...*types.Alert) bool {
+		notified <- time.Now()
+		return true
+	}
+
+	// An alert that started longer ago than group_wait but not longer
+	// than group_wait plus initial_delay must not trigger a flush.
+	start := time.Now()
+	ag.insert(&types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"a": "v1"},
+			StartsAt: start.Add(-150 * time.Millisecond),
+		},
+		UpdatedAt: start,
+	})
+
+	go ag.run(ntfy)
+	defer ag.stop()
+
+	select {
+	case at := <-notified:
+		if s := at.Sub(start); s < opts.GroupWait+opts.InitialDelay {
+			t.Fatalf("Received notification after %s, expected not before %s", s, opts.GroupWait+opts.InitialDelay)
+		}
+	case <-time.After(2 * (opts.GroupWait + opts.InitialDelay)):
+		t.Fatalf("Expected notification, received none")
+	}
+}
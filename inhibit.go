@@ -15,6 +15,7 @@ package main
 
 import (
 	"sync"
+	"time"
 
 	"github.com/prometheus/common/log"
 	"github.com/prometheus/common/model"
@@ -54,6 +55,7 @@ func (ih *Inhibitor) Mutes(lset model.LabelSet) bool {
 	// TODO(fabxc): improve erroring for iterators so it does not
 	// go silenced here.
 
+	now := time.Now()
 	for alert := range alerts.Next() {
 		if err := alerts.Err(); err != nil {
 			log.Errorf("Error iterating alerts: %s", err)
@@ -63,6 +65,9 @@ func (ih *Inhibitor) Mutes(lset model.LabelSet) bool {
 			continue
 		}
 		for _, rule := range ih.rules {
+			if rule.expired(alert, now) {
+				continue
+			}
 			if rule.Mutes(alert.Labels, lset) {
 				ih.marker.SetInhibited(lset.Fingerprint(), true)
 				return true
@@ -93,6 +98,9 @@ type InhibitRule struct {
 	// A set of label names whose label values need to be identical in source and
 	// target alerts in order for the inhibition to take effect.
 	Equal map[model.LabelName]struct{}
+	// The time after its last update for which a source alert inhibits
+	// target alerts. 0 means until it is resolved.
+	TTL time.Duration
 }
 
 // NewInhibitRule returns a new InihibtRule based on a configuration definition.
@@ -108,6 +116,12 @@ func NewInhibitRule(cr *config.InhibitRule) *InhibitRule {
 	for ln, lv := range cr.SourceMatchRE {
 		sourcem = append(sourcem, types.NewRegexMatcher(model.LabelName(ln), lv.Regexp))
 	}
+	for ln, lv := range cr.SourceMatchNot {
+		sourcem = append(sourcem, types.NewNegativeMatcher(model.LabelName(ln), lv))
+	}
+	for ln, lv := range cr.SourceMatchNotRE {
+		sourcem = append(sourcem, types.NewNegativeRegexMatcher(model.LabelName(ln), lv.Regexp))
+	}
 
 	for ln, lv := range cr.TargetMatch {
 		targetm = append(targetm, types.NewMatcher(model.LabelName(ln), lv))
@@ -115,19 +129,37 @@ func NewInhibitRule(cr *config.InhibitRule) *InhibitRule {
 	for ln, lv := range cr.TargetMatchRE {
 		targetm = append(targetm, types.NewRegexMatcher(model.LabelName(ln), lv.Regexp))
 	}
+	for ln, lv := range cr.TargetMatchNot {
+		targetm = append(targetm, types.NewNegativeMatcher(model.LabelName(ln), lv))
+	}
+	for ln, lv := range cr.TargetMatchNotRE {
+		targetm = append(targetm, types.NewNegativeRegexMatcher(model.LabelName(ln), lv.Regexp))
+	}
 
 	equal := map[model.LabelName]struct{}{}
 	for _, ln := range cr.Equal {
 		equal[ln] = struct{}{}
 	}
 
+	var ttl time.Duration
+	if cr.TTL != nil {
+		ttl = time.Duration(*cr.TTL)
+	}
+
 	return &InhibitRule{
 		SourceMatchers: sourcem,
 		TargetMatchers: targetm,
 		Equal:          equal,
+		TTL:            ttl,
 	}
 }
 
+// expired returns true if the source alert was last updated longer than
+// the TTL of the rule before now.
+func (r *InhibitRule) expired(source *types.Alert, now time.Time) bool {
+	return r.TTL > 0 && !source.UpdatedAt.IsZero() && now.Sub(source.UpdatedAt) > r.TTL
+}
+
 // Mutes returns true iff the Inhibition rule applies for the given
 // source and target label set.
 func (r *InhibitRule) Mutes(source, target model.LabelSet) bool {
@@ -43,8 +43,10 @@ import (
 var (
 	showVersion = flag.Bool("version", false, "Print version information.")
 
-	configFile = flag.String("config.file", "alertmanager.yml", "Alertmanager configuration file name.")
-	dataDir    = flag.String("storage.path", "data/", "Base path for data storage.")
+	configFile      = flag.String("config.file", "alertmanager.yml", "Alertmanager configuration file name.")
+	configExpandEnv = flag.Bool("config.expand-env", false, "Expand $VAR and ${VAR} references in the configuration file with environment variables.")
+	configAllowExec = flag.Bool("config.allow-exec", false, "Allow running the commands configured to provide secrets.")
+	dataDir         = flag.String("storage.path", "data/", "Base path for data storage.")
 
 	externalURL   = flag.String("web.external-url", "", "The URL under which Alertmanager is externally reachable (for example, if Alertmanager is served via a reverse proxy). Used for generating relative and absolute links back to Alertmanager itself. If the URL has a path portion, it will be used to prefix all HTTP endpoints served by Alertmanager. If omitted, relevant URL components will be derived automatically.")
 	listenAddress = flag.String("web.listen-address", ":9093", "Address to listen on for the web interface and API.")
@@ -83,22 +85,20 @@ func main() {
 		log.Fatal(err)
 	}
 
-	var (
-		inhibitor *Inhibitor
-		tmpl      *template.Template
-		disp      *Dispatcher
-	)
+	var disp *Dispatcher
 	defer disp.Stop()
 
 	api := NewAPI(alerts, silences, func() AlertOverview {
 		return disp.Groups()
 	})
 
-	build := func(rcvs []*config.Receiver) notify.Notifier {
-		var (
-			router  = notify.Router{}
-			fanouts = notify.Build(rcvs, tmpl)
-		)
+	build := func(rcvs []*config.Receiver, tmpl *template.Template, inhibitor *Inhibitor) (notify.Notifier, error) {
+		fanouts, err := notify.Build(rcvs, tmpl)
+		if err != nil {
+			return nil, err
+		}
+		router := notify.Router{}
+
 		for name, fo := range fanouts {
 			for i, n := range fo {
 				n = notify.Retry(n)
@@ -118,7 +118,7 @@ func main() {
 		n = notify.Inhibit(inhibitor, n, marker)
 		n = notify.Log(n, log.With("step", "inhibit"))
 
-		return n
+		return n, nil
 	}
 
 	reload := func() (err error) {
@@ -129,14 +129,15 @@ func main() {
 			}
 		}()
 
-		conf, err := config.LoadFile(*configFile)
+		conf, err := config.LoadFileWithOptions(*configFile, config.LoadOptions{
+			ExpandEnv: *configExpandEnv,
+			AllowExec: *configAllowExec,
+		})
 		if err != nil {
 			return err
 		}
 
-		api.Update(conf.String(), time.Duration(conf.Global.ResolveTimeout))
-
-		tmpl, err = template.FromGlobs(conf.Templates...)
+		tmpl, err := template.FromGlobs(conf.Templates...)
 		if err != nil {
 			return err
 		}
@@ -144,10 +145,19 @@ func main() {
 			return err
 		}
 
+		inhibitor := NewInhibitor(alerts, conf.InhibitRules, marker)
+		notifier, err := build(conf.Receivers, tmpl, inhibitor)
+		if err != nil {
+			return err
+		}
+
+		// The new configuration is only applied once all of its parts
+		// were built, so that a failed reload keeps the previous one.
+		api.Update(conf.String(), time.Duration(conf.Global.ResolveTimeout))
+
 		disp.Stop()
 
-		inhibitor = NewInhibitor(alerts, conf.InhibitRules, marker)
-		disp = NewDispatcher(alerts, NewRoute(conf.Route, nil), build(conf.Receivers), marker)
+		disp = NewDispatcher(alerts, NewRouteTree(conf), notifier, marker)
 
 		go disp.Run()
 
@@ -163,7 +173,11 @@ func main() {
 	RegisterWeb(router)
 	api.Register(router.WithPrefix("/api"))
 
-	go http.ListenAndServe(*listenAddress, router)
+	l, err := net.Listen("tcp", *listenAddress)
+	if err != nil {
+		log.Fatalf("Error listening on %s: %s", *listenAddress, err)
+	}
+	go http.Serve(l, router)
 
 	var (
 		hup  = make(chan os.Signal)
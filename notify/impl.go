@@ -16,15 +16,20 @@ package notify
 import (
 	"bytes"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"mime"
-	"net"
+	"mime/multipart"
+	"mime/quotedprintable"
 	"net/http"
 	"net/mail"
 	"net/smtp"
+	"net/textproto"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -33,6 +38,7 @@ import (
 	"github.com/prometheus/common/model"
 	"golang.org/x/net/context"
 	"golang.org/x/net/context/ctxhttp"
+	"gopkg.in/yaml.v2"
 
 	"github.com/prometheus/alertmanager/config"
 	"github.com/prometheus/alertmanager/template"
@@ -74,7 +80,7 @@ type integration interface {
 }
 
 // Build creates a fanout notifier for each receiver.
-func Build(confs []*config.Receiver, tmpl *template.Template) map[string]Fanout {
+func Build(confs []*config.Receiver, tmpl *template.Template) (map[string]Fanout, error) {
 	res := map[string]Fanout{}
 
 	filter := func(n integration, c notifierConfig) Notifier {
@@ -110,8 +116,25 @@ func Build(confs []*config.Receiver, tmpl *template.Template) map[string]Fanout
 			add = func(i int, on integration, n Notifier) { fo[fmt.Sprintf("%s/%d", on.name(), i)] = n }
 		)
 
+		// Notifications must not be dropped silently because a receiver
+		// only has integrations without a notifier.
+		if keys := unsupportedIntegrations(nc); len(keys) > 0 {
+			return nil, fmt.Errorf("receiver %q: %s not supported by the notifier yet", nc.Name, strings.Join(keys, ", "))
+		}
+
+		tmpl := tmpl
+		if len(nc.Templates) > 0 {
+			var err error
+			if tmpl, err = tmpl.WithGlobs(nc.Templates...); err != nil {
+				return nil, fmt.Errorf("receiver %q: %s", nc.Name, err)
+			}
+		}
+
 		for i, c := range nc.WebhookConfigs {
-			n := NewWebhook(c)
+			n, err := NewWebhook(c, tmpl)
+			if err != nil {
+				return nil, fmt.Errorf("receiver %q: %s", nc.Name, err)
+			}
 			add(i, n, filter(n, c))
 		}
 		for i, c := range nc.EmailConfigs {
@@ -119,25 +142,66 @@ func Build(confs []*config.Receiver, tmpl *template.Template) map[string]Fanout
 			add(i, n, filter(n, c))
 		}
 		for i, c := range nc.PagerdutyConfigs {
-			n := NewPagerDuty(c, tmpl)
+			n, err := NewPagerDuty(c, tmpl)
+			if err != nil {
+				return nil, fmt.Errorf("receiver %q: %s", nc.Name, err)
+			}
 			add(i, n, filter(n, c))
 		}
 		for i, c := range nc.OpsGenieConfigs {
-			n := NewOpsGenie(c, tmpl)
+			n, err := NewOpsGenie(c, tmpl)
+			if err != nil {
+				return nil, fmt.Errorf("receiver %q: %s", nc.Name, err)
+			}
 			add(i, n, filter(n, c))
 		}
 		for i, c := range nc.SlackConfigs {
-			n := NewSlack(c, tmpl)
+			n, err := NewSlack(c, tmpl)
+			if err != nil {
+				return nil, fmt.Errorf("receiver %q: %s", nc.Name, err)
+			}
 			add(i, n, filter(n, c))
 		}
 		for i, c := range nc.HipchatConfigs {
-			n := NewHipchat(c, tmpl)
+			n, err := NewHipchat(c, tmpl)
+			if err != nil {
+				return nil, fmt.Errorf("receiver %q: %s", nc.Name, err)
+			}
 			add(i, n, filter(n, c))
 		}
 
 		res[nc.Name] = fo
 	}
-	return res
+	return res, nil
+}
+
+// unsupportedIntegrations returns the configuration keys of the
+// integrations of the receiver that can be configured but are not
+// implemented by a notifier yet.
+func unsupportedIntegrations(nc *config.Receiver) []string {
+	var keys []string
+	if len(nc.VictorOpsConfigs) > 0 {
+		keys = append(keys, "victorops_configs")
+	}
+	if len(nc.WechatConfigs) > 0 {
+		keys = append(keys, "wechat_configs")
+	}
+	if len(nc.TelegramConfigs) > 0 {
+		keys = append(keys, "telegram_configs")
+	}
+	if len(nc.MSTeamsConfigs) > 0 {
+		keys = append(keys, "msteams_configs")
+	}
+	if len(nc.SNSConfigs) > 0 {
+		keys = append(keys, "sns_configs")
+	}
+	if len(nc.DiscordConfigs) > 0 {
+		keys = append(keys, "discord_configs")
+	}
+	if len(nc.KafkaConfigs) > 0 {
+		keys = append(keys, "kafka_configs")
+	}
+	return keys
 }
 
 const contentTypeJSON = "application/json"
@@ -146,11 +210,19 @@ const contentTypeJSON = "application/json"
 type Webhook struct {
 	// The URL to which notifications are sent.
 	URL string
+
+	conf   *config.WebhookConfig
+	tmpl   *template.Template
+	client *http.Client
 }
 
 // NewWebhook returns a new Webhook.
-func NewWebhook(conf *config.WebhookConfig) *Webhook {
-	return &Webhook{URL: conf.URL}
+func NewWebhook(conf *config.WebhookConfig, t *template.Template) (*Webhook, error) {
+	client, err := newHTTPClient(conf.HTTPConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &Webhook{URL: conf.URL.String(), conf: conf, tmpl: t, client: client}, nil
 }
 
 func (*Webhook) name() string { return "webhook" }
@@ -163,6 +235,10 @@ type WebhookMessage struct {
 	Status model.AlertStatus `json:"status"`
 	// A batch of alerts.
 	Alerts model.Alerts `json:"alert"`
+	// The number of alerts of the group that are not part of the batch.
+	TruncatedAlerts int `json:"truncatedAlerts"`
+	// The message rendered from the inline template, if one is configured.
+	Text string `json:"text,omitempty"`
 }
 
 // Notify implements the Notifier interface.
@@ -177,10 +253,54 @@ func (w *Webhook) Notify(ctx context.Context, alerts ...*types.Alert) error {
 		}
 	}
 
+	version := w.conf.Version
+	if version == "" {
+		version = config.DefaultWebhookVersion
+	}
+	// The status reflects all alerts, even if some are not sent.
+	status := as.Status()
+	limit := alertLimit(ctx, w.conf.MaxAlerts)
+	var truncated int
+	if limit > 0 && len(as) > limit {
+		truncated = len(as) - limit
+		as = as[:limit]
+	}
+
 	msg := &WebhookMessage{
-		Version: "2",
-		Status:  as.Status(),
-		Alerts:  as,
+		Version:         version,
+		Status:          status,
+		Alerts:          as,
+		TruncatedAlerts: truncated,
+	}
+	var (
+		headers   map[string]string
+		templated = w.conf.TextTemplate != nil
+	)
+	if hc := w.conf.HTTPConfig; hc != nil && len(hc.Headers) > 0 {
+		headers = make(map[string]string, len(hc.Headers))
+		for h, v := range hc.Headers {
+			headers[h] = v
+			templated = templated || strings.Contains(v, "{{")
+		}
+	}
+	if templated {
+		var (
+			err        error
+			data       = templateData(ctx, w.tmpl, w.conf.MaxAlerts, alerts...)
+			tmplText   = tmplText(w.tmpl, data, &err)
+			tmplInline = tmplInline(w.tmpl, data, &err)
+		)
+		msg.Text = tmplInline(w.conf.TextTemplate, "")
+		for h, v := range headers {
+			if it := w.conf.HTTPConfig.HeaderTemplate(h); it != nil {
+				headers[h] = tmplInline(it, "")
+			} else if strings.Contains(v, "{{") {
+				headers[h] = tmplText(v)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("templating error: %s", err)
+		}
 	}
 
 	var buf bytes.Buffer
@@ -188,7 +308,15 @@ func (w *Webhook) Notify(ctx context.Context, alerts ...*types.Alert) error {
 		return err
 	}
 
-	resp, err := ctxhttp.Post(ctx, http.DefaultClient, w.URL, contentTypeJSON, &buf)
+	req, err := http.NewRequest("POST", w.URL, &buf)
+	if err != nil {
+		return err
+	}
+	for h, v := range headers {
+		req.Header.Set(h, v)
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+	resp, err := ctxhttp.Do(ctx, w.client, req)
 	if err != nil {
 		return err
 	}
@@ -223,56 +351,129 @@ func NewEmail(c *config.EmailConfig, t *template.Template) *Email {
 
 func (*Email) name() string { return "email" }
 
+// authValue returns the configured value or, if it is empty, the value of
+// the given environment variable.
+func authValue(v, env string) string {
+	if v != "" {
+		return v
+	}
+	return os.Getenv(env)
+}
+
 // auth resolves a string of authentication mechanisms.
 func (n *Email) auth(mechs string) (smtp.Auth, *tls.Config, error) {
-	username := os.Getenv("SMTP_AUTH_USERNAME")
+	username := authValue(n.conf.AuthUsername, "SMTP_AUTH_USERNAME")
 
 	for _, mech := range strings.Split(mechs, " ") {
 		switch mech {
 		case "CRAM-MD5":
-			secret := os.Getenv("SMTP_AUTH_SECRET")
+			secret := authValue(string(n.conf.AuthSecret), "SMTP_AUTH_SECRET")
 			if secret == "" {
 				continue
 			}
 			return smtp.CRAMMD5Auth(username, secret), nil, nil
 
 		case "PLAIN":
-			password := os.Getenv("SMTP_AUTH_PASSWORD")
+			password := authValue(string(n.conf.AuthPassword), "SMTP_AUTH_PASSWORD")
 			if password == "" {
 				continue
 			}
-			identity := os.Getenv("SMTP_AUTH_IDENTITY")
+			identity := authValue(n.conf.AuthIdentity, "SMTP_AUTH_IDENTITY")
 
 			// We need to know the hostname for both auth and TLS.
-			host, _, err := net.SplitHostPort(n.conf.Smarthost)
-			if err != nil {
-				return nil, nil, fmt.Errorf("invalid address: %s", err)
-			}
 			var (
+				host = n.conf.Smarthost.Host
 				auth = smtp.PlainAuth(identity, username, password, host)
 				cfg  = &tls.Config{ServerName: host}
 			)
 			return auth, cfg, nil
+
+		case "LOGIN":
+			password := authValue(string(n.conf.AuthPassword), "SMTP_AUTH_PASSWORD")
+			if password == "" {
+				continue
+			}
+			// Like PLAIN, LOGIN sends the password as is and needs TLS.
+			host := n.conf.Smarthost.Host
+			return &loginAuth{username: username, password: password, host: host}, &tls.Config{ServerName: host}, nil
 		}
 	}
 	return nil, nil, nil
 }
 
+// loginAuth implements the LOGIN authentication mechanism which is not
+// supported by net/smtp.
+type loginAuth struct {
+	username, password, host string
+}
+
+// Start implements the smtp.Auth interface. Like smtp.PlainAuth, it
+// refuses to send credentials over unencrypted connections other than to
+// localhost.
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, fmt.Errorf("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, fmt.Errorf("wrong host name")
+	}
+	return "LOGIN", []byte{}, nil
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
+
+// Next implements the smtp.Auth interface.
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(string(fromServer)) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected server challenge %q", fromServer)
+}
+
 // Notify implements the Notifier interface.
 func (n *Email) Notify(ctx context.Context, as ...*types.Alert) error {
 	// Connect to the SMTP smarthost.
-	c, err := smtp.Dial(n.conf.Smarthost)
+	c, err := smtp.Dial(n.conf.Smarthost.String())
 	if err != nil {
 		return err
 	}
 	defer c.Quit()
 
+	hello := n.conf.Hello
+	if hello == "" {
+		if hello, err = os.Hostname(); err != nil {
+			return fmt.Errorf("unable to determine hostname for HELO: %s", err)
+		}
+	}
+	if err := c.Hello(hello); err != nil {
+		return fmt.Errorf("hello failed: %s", err)
+	}
+
+	tlsDone := false
+	if n.conf.RequireTLS != nil && *n.conf.RequireTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("require_tls is set but %q does not advertise the STARTTLS extension", n.conf.Smarthost)
+		}
+		if err := c.StartTLS(&tls.Config{ServerName: n.conf.Smarthost.Host}); err != nil {
+			return fmt.Errorf("starttls failed: %s", err)
+		}
+		tlsDone = true
+	}
+
 	if ok, mech := c.Extension("AUTH"); ok {
 		auth, tlsConf, err := n.auth(mech)
 		if err != nil {
 			return err
 		}
-		if tlsConf != nil {
+		if tlsConf != nil && !tlsDone {
 			if err := c.StartTLS(tlsConf); err != nil {
 				return fmt.Errorf("starttls failed: %s", err)
 			}
@@ -285,11 +486,16 @@ func (n *Email) Notify(ctx context.Context, as ...*types.Alert) error {
 	}
 
 	var (
-		data = n.tmpl.Data(receiver(ctx), groupLabels(ctx), as...)
+		data = templateData(ctx, n.tmpl, n.conf.MaxAlerts, as...)
 		tmpl = tmplText(n.tmpl, data, &err)
 		from = tmpl(n.conf.From)
-		to   = tmpl(n.conf.To)
+		to   string
 	)
+	if it := n.conf.ToTemplate(); it != nil {
+		to = tmplInline(n.tmpl, data, &err)(it, "")
+	} else {
+		to = tmpl(n.conf.To)
+	}
 	if err != nil {
 		return err
 	}
@@ -329,32 +535,77 @@ func (n *Email) Notify(ctx context.Context, as ...*types.Alert) error {
 		fmt.Fprintf(wc, "%s: %s\r\n", header, mime.QEncoding.Encode("utf-8", value))
 	}
 
-	fmt.Fprintf(wc, "Content-Type: text/html; charset=UTF-8\r\n")
+	var (
+		buf = &bytes.Buffer{}
+		mw  = multipart.NewWriter(buf)
+	)
 	fmt.Fprintf(wc, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(wc, "Content-Type: multipart/alternative; boundary=%s\r\n", mw.Boundary())
+	fmt.Fprintf(wc, "MIME-Version: 1.0\r\n")
 
 	// TODO: Add some useful headers here, such as URL of the alertmanager
 	// and active/resolved.
 	fmt.Fprintf(wc, "\r\n")
 
-	// TODO(fabxc): do a multipart write that considers the plain template.
-	body, err := n.tmpl.ExecuteHTMLString(n.conf.HTML, data)
-	if err != nil {
-		return fmt.Errorf("executing email html template: %s", err)
+	// Parts are ordered by increasing preference, so the plain text
+	// body comes first.
+	if n.conf.Text != "" {
+		body, err := n.tmpl.ExecuteTextString(n.conf.Text, data)
+		if err != nil {
+			return fmt.Errorf("executing email text template: %s", err)
+		}
+		if err := writeQuotedPart(mw, "text/plain; charset=UTF-8", body); err != nil {
+			return err
+		}
+	}
+	if n.conf.HTML != "" {
+		body, err := n.tmpl.ExecuteHTMLString(n.conf.HTML, data)
+		if err != nil {
+			return fmt.Errorf("executing email html template: %s", err)
+		}
+		if err := writeQuotedPart(mw, "text/html; charset=UTF-8", body); err != nil {
+			return err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return err
 	}
-	_, err = io.WriteString(wc, body)
 
+	_, err = wc.Write(buf.Bytes())
 	return err
 }
 
+// writeQuotedPart writes body as a quoted-printable encoded part of the
+// given content type.
+func writeQuotedPart(mw *multipart.Writer, contentType, body string) error {
+	w, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Transfer-Encoding": {"quoted-printable"},
+		"Content-Type":              {contentType},
+	})
+	if err != nil {
+		return err
+	}
+	qw := quotedprintable.NewWriter(w)
+	if _, err := qw.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
 // PagerDuty implements a Notifier for PagerDuty notifications.
 type PagerDuty struct {
-	conf *config.PagerdutyConfig
-	tmpl *template.Template
+	conf   *config.PagerdutyConfig
+	tmpl   *template.Template
+	client *http.Client
 }
 
 // NewPagerDuty returns a new PagerDuty notifier.
-func NewPagerDuty(c *config.PagerdutyConfig, t *template.Template) *PagerDuty {
-	return &PagerDuty{conf: c, tmpl: t}
+func NewPagerDuty(c *config.PagerdutyConfig, t *template.Template) (*PagerDuty, error) {
+	client, err := newHTTPClient(c.HTTPConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &PagerDuty{conf: c, tmpl: t, client: client}, nil
 }
 
 func (*PagerDuty) name() string { return "pagerduty" }
@@ -374,9 +625,42 @@ type pagerDutyMessage struct {
 	Details     map[string]string `json:"details,omitempty"`
 }
 
+type pagerDutyMessageV2 struct {
+	RoutingKey  string            `json:"routing_key"`
+	DedupKey    string            `json:"dedup_key"`
+	EventAction string            `json:"event_action"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	Client      string            `json:"client,omitempty"`
+	ClientURL   string            `json:"client_url,omitempty"`
+	Links       []pagerDutyLink   `json:"links,omitempty"`
+	Images      []pagerDutyImage  `json:"images,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Class         string            `json:"class,omitempty"`
+	Component     string            `json:"component,omitempty"`
+	Group         string            `json:"group,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text,omitempty"`
+}
+
+type pagerDutyImage struct {
+	Src  string `json:"src"`
+	Alt  string `json:"alt,omitempty"`
+	Href string `json:"href,omitempty"`
+}
+
 // Notify implements the Notifier interface.
 //
 // http://developer.pagerduty.com/documentation/integration/events/trigger
+// https://v2.developer.pagerduty.com/docs/send-an-event-events-api-v2
 func (n *PagerDuty) Notify(ctx context.Context, as ...*types.Alert) error {
 	key, ok := GroupKey(ctx)
 	if !ok {
@@ -385,10 +669,11 @@ func (n *PagerDuty) Notify(ctx context.Context, as ...*types.Alert) error {
 
 	var err error
 	var (
-		alerts    = types.Alerts(as...)
-		data      = n.tmpl.Data(receiver(ctx), groupLabels(ctx), as...)
-		tmpl      = tmplText(n.tmpl, data, &err)
-		eventType = pagerDutyEventTrigger
+		alerts     = types.Alerts(as...)
+		data       = templateData(ctx, n.tmpl, n.conf.MaxAlerts, as...)
+		tmpl       = tmplText(n.tmpl, data, &err)
+		tmplInline = tmplInline(n.tmpl, data, &err)
+		eventType  = pagerDutyEventTrigger
 	)
 	if alerts.Status() == model.AlertResolved {
 		eventType = pagerDutyEventResolve
@@ -400,17 +685,24 @@ func (n *PagerDuty) Notify(ctx context.Context, as ...*types.Alert) error {
 	for k, v := range n.conf.Details {
 		details[k] = tmpl(v)
 	}
+	description := tmplInline(n.conf.TextTemplate, tmpl(n.conf.Description))
 
-	msg := &pagerDutyMessage{
-		ServiceKey:  tmpl(string(n.conf.ServiceKey)),
-		EventType:   eventType,
-		IncidentKey: key,
-		Description: tmpl(n.conf.Description),
-		Details:     details,
-	}
-	if eventType == pagerDutyEventTrigger {
-		msg.Client = tmpl(n.conf.Client)
-		msg.ClientURL = tmpl(n.conf.ClientURL)
+	var msg interface{}
+	if n.conf.RoutingKey != "" {
+		msg = n.messageV2(key, eventType, description, details, tmpl)
+	} else {
+		msgV1 := &pagerDutyMessage{
+			ServiceKey:  tmpl(string(n.conf.ServiceKey)),
+			EventType:   eventType,
+			IncidentKey: key,
+			Description: description,
+			Details:     details,
+		}
+		if eventType == pagerDutyEventTrigger {
+			msgV1.Client = tmpl(n.conf.Client)
+			msgV1.ClientURL = tmpl(n.conf.ClientURL)
+		}
+		msg = msgV1
 	}
 	if err != nil {
 		return err
@@ -421,7 +713,7 @@ func (n *PagerDuty) Notify(ctx context.Context, as ...*types.Alert) error {
 		return err
 	}
 
-	resp, err := ctxhttp.Post(ctx, http.DefaultClient, n.conf.URL, contentTypeJSON, &buf)
+	resp, err := ctxhttp.Post(ctx, n.client, n.conf.URL.String(), contentTypeJSON, &buf)
 	if err != nil {
 		return err
 	}
@@ -435,16 +727,22 @@ func (n *PagerDuty) Notify(ctx context.Context, as ...*types.Alert) error {
 
 // Slack implements a Notifier for Slack notifications.
 type Slack struct {
-	conf *config.SlackConfig
-	tmpl *template.Template
+	conf   *config.SlackConfig
+	tmpl   *template.Template
+	client *http.Client
 }
 
 // NewSlack returns a new Slack notification handler.
-func NewSlack(conf *config.SlackConfig, tmpl *template.Template) *Slack {
-	return &Slack{
-		conf: conf,
-		tmpl: tmpl,
+func NewSlack(conf *config.SlackConfig, tmpl *template.Template) (*Slack, error) {
+	client, err := newHTTPClient(conf.HTTPConfig)
+	if err != nil {
+		return nil, err
 	}
+	return &Slack{
+		conf:   conf,
+		tmpl:   tmpl,
+		client: client,
+	}, nil
 }
 
 func (*Slack) name() string { return "slack" }
@@ -453,6 +751,7 @@ func (*Slack) name() string { return "slack" }
 type slackReq struct {
 	Channel     string            `json:"channel,omitempty"`
 	Username    string            `json:"username,omitempty"`
+	Blocks      []interface{}     `json:"blocks,omitempty"`
 	Attachments []slackAttachment `json:"attachments"`
 }
 
@@ -464,8 +763,10 @@ type slackAttachment struct {
 	Text      string `json:"text"`
 	Fallback  string `json:"fallback"`
 
-	Color    string   `json:"color,omitempty"`
-	MrkdwnIn []string `json:"mrkdwn_in,omitempty"`
+	Color    string                 `json:"color,omitempty"`
+	MrkdwnIn []string               `json:"mrkdwn_in,omitempty"`
+	Fields   []slackAttachmentField `json:"fields,omitempty"`
+	Actions  []slackAction          `json:"actions,omitempty"`
 }
 
 // slackAttachmentField is displayed in a table inside the message attachment.
@@ -475,29 +776,60 @@ type slackAttachmentField struct {
 	Short bool   `json:"short,omitempty"`
 }
 
+// slackAction is an interactive element of the message attachment.
+type slackAction struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	URL   string `json:"url,omitempty"`
+	Style string `json:"style,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
 // Notify implements the Notifier interface.
 func (n *Slack) Notify(ctx context.Context, as ...*types.Alert) error {
 	var err error
 	var (
-		data     = n.tmpl.Data(receiver(ctx), groupLabels(ctx), as...)
-		tmplText = tmplText(n.tmpl, data, &err)
-		tmplHTML = tmplHTML(n.tmpl, data, &err)
+		data       = templateData(ctx, n.tmpl, n.conf.MaxAlerts, as...)
+		tmplText   = tmplText(n.tmpl, data, &err)
+		tmplHTML   = tmplHTML(n.tmpl, data, &err)
+		tmplInline = tmplInline(n.tmpl, data, &err)
 	)
 
 	attachment := &slackAttachment{
-		Title:     tmplText(n.conf.Title),
+		Title:     tmplInline(n.conf.TitleTemplate, tmplText(n.conf.Title)),
 		TitleLink: tmplText(n.conf.TitleLink),
 		Pretext:   tmplText(n.conf.Pretext),
-		Text:      tmplHTML(n.conf.Text),
+		Text:      tmplInline(n.conf.TextTemplate, tmplHTML(n.conf.Text)),
 		Fallback:  tmplText(n.conf.Fallback),
-		Color:     tmplText(n.conf.Color),
+		Color:     tmplText(string(n.conf.Color)),
 		MrkdwnIn:  []string{"fallback", "pretext"},
 	}
+	for _, f := range n.conf.Fields {
+		attachment.Fields = append(attachment.Fields, slackAttachmentField{
+			Title: tmplText(f.Title),
+			Value: tmplText(f.Value),
+			Short: f.Short,
+		})
+	}
+	for _, a := range n.conf.Actions {
+		attachment.Actions = append(attachment.Actions, slackAction{
+			Type:  tmplText(a.Type),
+			Text:  tmplText(a.Text),
+			URL:   tmplText(a.URL),
+			Style: tmplText(a.Style),
+			Name:  tmplText(a.Name),
+			Value: tmplText(a.Value),
+		})
+	}
 	req := &slackReq{
 		Channel:     tmplText(n.conf.Channel),
 		Username:    tmplText(n.conf.Username),
 		Attachments: []slackAttachment{*attachment},
 	}
+	for _, b := range n.conf.Blocks {
+		req.Blocks = append(req.Blocks, slackBlockValue(b, tmplText))
+	}
 	if err != nil {
 		return err
 	}
@@ -507,7 +839,7 @@ func (n *Slack) Notify(ctx context.Context, as ...*types.Alert) error {
 		return err
 	}
 
-	resp, err := ctxhttp.Post(ctx, http.DefaultClient, string(n.conf.APIURL), contentTypeJSON, &buf)
+	resp, err := ctxhttp.Post(ctx, n.client, n.conf.APIURL.URL.String(), contentTypeJSON, &buf)
 	if err != nil {
 		return err
 	}
@@ -521,18 +853,75 @@ func (n *Slack) Notify(ctx context.Context, as ...*types.Alert) error {
 	return nil
 }
 
+// slackBlockValue returns the value v of a Slack block with all strings
+// in it expanded by tmpl. Mappings are returned as jsonMapSlice to keep
+// the order of their keys.
+func slackBlockValue(v interface{}, tmpl func(string) string) interface{} {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		m := make(jsonMapSlice, 0, len(v))
+		for _, item := range v {
+			m = append(m, yaml.MapItem{Key: item.Key, Value: slackBlockValue(item.Value, tmpl)})
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, 0, len(v))
+		for _, e := range v {
+			l = append(l, slackBlockValue(e, tmpl))
+		}
+		return l
+	case string:
+		return tmpl(v)
+	}
+	return v
+}
+
+// jsonMapSlice is a YAML mapping that is encoded as a JSON object with
+// its keys in order.
+type jsonMapSlice yaml.MapSlice
+
+// MarshalJSON implements the json.Marshaler interface.
+func (m jsonMapSlice) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, item := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(fmt.Sprint(item.Key))
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(item.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
 // Hipchat implements a Notifier for Hipchat notifications.
 type Hipchat struct {
-	conf *config.HipchatConfig
-	tmpl *template.Template
+	conf   *config.HipchatConfig
+	tmpl   *template.Template
+	client *http.Client
 }
 
 // NewHipchat returns a new Hipchat notification handler.
-func NewHipchat(conf *config.HipchatConfig, tmpl *template.Template) *Hipchat {
-	return &Hipchat{
-		conf: conf,
-		tmpl: tmpl,
+func NewHipchat(conf *config.HipchatConfig, tmpl *template.Template) (*Hipchat, error) {
+	client, err := newHTTPClient(conf.HTTPConfig)
+	if err != nil {
+		return nil, err
 	}
+	return &Hipchat{
+		conf:   conf,
+		tmpl:   tmpl,
+		client: client,
+	}, nil
 }
 
 func (*Hipchat) name() string { return "hipchat" }
@@ -550,10 +939,10 @@ func (n *Hipchat) Notify(ctx context.Context, as ...*types.Alert) error {
 	var err error
 	var msg string
 	var (
-		data     = n.tmpl.Data(receiver(ctx), groupLabels(ctx), as...)
+		data     = templateData(ctx, n.tmpl, n.conf.MaxAlerts, as...)
 		tmplText = tmplText(n.tmpl, data, &err)
 		tmplHTML = tmplHTML(n.tmpl, data, &err)
-		url      = fmt.Sprintf("%sv2/room/%s/notification?auth_token=%s", n.conf.APIURL, n.conf.RoomID, n.conf.AuthToken)
+		url      = fmt.Sprintf("%sv2/room/%s/notification?auth_token=%s", n.conf.APIURL, n.conf.RoomID, string(n.conf.AuthToken))
 	)
 
 	if n.conf.MessageFormat == "html" {
@@ -578,7 +967,7 @@ func (n *Hipchat) Notify(ctx context.Context, as ...*types.Alert) error {
 		return err
 	}
 
-	resp, err := ctxhttp.Post(ctx, http.DefaultClient, url, contentTypeJSON, &buf)
+	resp, err := ctxhttp.Post(ctx, n.client, url, contentTypeJSON, &buf)
 	if err != nil {
 		return err
 	}
@@ -592,28 +981,79 @@ func (n *Hipchat) Notify(ctx context.Context, as ...*types.Alert) error {
 	return nil
 }
 
+// messageV2 returns the Events API v2 message for the given event.
+func (n *PagerDuty) messageV2(key model.Fingerprint, eventType, description string, details map[string]string, tmpl func(string) string) *pagerDutyMessageV2 {
+	msg := &pagerDutyMessageV2{
+		RoutingKey:  tmpl(string(n.conf.RoutingKey)),
+		DedupKey:    key.String(),
+		EventAction: eventType,
+	}
+	if eventType != pagerDutyEventTrigger {
+		return msg
+	}
+
+	msg.Client = tmpl(n.conf.Client)
+	msg.ClientURL = tmpl(n.conf.ClientURL)
+	msg.Payload = &pagerDutyPayload{
+		Summary:       description,
+		Source:        tmpl(n.conf.Client),
+		Severity:      tmpl(n.conf.Severity),
+		Class:         tmpl(n.conf.Class),
+		Component:     tmpl(n.conf.Component),
+		Group:         tmpl(n.conf.Group),
+		CustomDetails: details,
+	}
+	for _, l := range n.conf.Links {
+		msg.Links = append(msg.Links, pagerDutyLink{
+			Href: tmpl(l.Href),
+			Text: tmpl(l.Text),
+		})
+	}
+	for _, i := range n.conf.Images {
+		msg.Images = append(msg.Images, pagerDutyImage{
+			Src:  tmpl(i.Src),
+			Alt:  tmpl(i.Alt),
+			Href: tmpl(i.Href),
+		})
+	}
+	return msg
+}
+
 // OpsGenie implements a Notifier for OpsGenie notifications.
 type OpsGenie struct {
-	conf *config.OpsGenieConfig
-	tmpl *template.Template
+	conf   *config.OpsGenieConfig
+	tmpl   *template.Template
+	client *http.Client
 }
 
 // NewOpsGenieDuty returns a new OpsGenie notifier.
-func NewOpsGenie(c *config.OpsGenieConfig, t *template.Template) *OpsGenie {
-	return &OpsGenie{conf: c, tmpl: t}
+func NewOpsGenie(c *config.OpsGenieConfig, t *template.Template) (*OpsGenie, error) {
+	client, err := newHTTPClient(c.HTTPConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &OpsGenie{conf: c, tmpl: t, client: client}, nil
 }
 
 func (*OpsGenie) name() string { return "opsgenie" }
 
 type opsGenieMessage struct {
-	APIKey string            `json:"apiKey"`
-	Alias  model.Fingerprint `json:"alias"`
+	APIKey string `json:"apiKey"`
+	Alias  string `json:"alias"`
 }
 
 type opsGenieCreateMessage struct {
 	*opsGenieMessage `json:,inline`
 	Message          string            `json:"message"`
 	Details          map[string]string `json:"details"`
+	Source           string            `json:"source,omitempty"`
+	Tags             []string          `json:"tags,omitempty"`
+	Note             string            `json:"note,omitempty"`
+	Priority         string            `json:"priority,omitempty"`
+	Teams            []string          `json:"teams,omitempty"`
+	Recipients       []string          `json:"recipients,omitempty"`
+	Entity           string            `json:"entity,omitempty"`
+	Actions          []string          `json:"actions,omitempty"`
 }
 
 type opsGenieCloseMessage struct {
@@ -626,7 +1066,7 @@ func (n *OpsGenie) Notify(ctx context.Context, as ...*types.Alert) error {
 	if !ok {
 		return fmt.Errorf("group key missing")
 	}
-	data := n.tmpl.Data(receiver(ctx), groupLabels(ctx), as...)
+	data := templateData(ctx, n.tmpl, n.conf.MaxAlerts, as...)
 
 	log.With("incident", key).Debugln("notifying OpsGenie")
 
@@ -638,26 +1078,67 @@ func (n *OpsGenie) Notify(ctx context.Context, as ...*types.Alert) error {
 		details[k] = tmpl(v)
 	}
 
+	// The decimal group key is the alias of alerts without a configured
+	// one, so that they still match alerts created by earlier versions.
+	alias := strconv.FormatUint(uint64(key), 10)
+	if n.conf.Alias != "" {
+		if a := strings.TrimSpace(tmpl(n.conf.Alias)); a != "" {
+			alias = a
+		}
+	}
+
 	var (
 		msg    interface{}
 		apiURL string
 
 		apiMsg = opsGenieMessage{
 			APIKey: string(n.conf.APIKey),
-			Alias:  key,
+			Alias:  alias,
 		}
 		alerts = types.Alerts(as...)
 	)
 	switch alerts.Status() {
 	case model.AlertResolved:
-		apiURL = n.conf.APIHost + "v1/json/alert/close"
+		apiURL = n.conf.APIHost.String() + "v1/json/alert/close"
 		msg = &opsGenieCloseMessage{&apiMsg}
 	default:
-		apiURL = n.conf.APIHost + "v1/json/alert"
+		var tags, actions []string
+		for _, t := range n.conf.Tags {
+			if t = strings.TrimSpace(tmpl(t)); t != "" {
+				tags = append(tags, t)
+			}
+		}
+		for _, a := range n.conf.Actions {
+			if a = strings.TrimSpace(tmpl(a)); a != "" {
+				actions = append(actions, a)
+			}
+		}
+		// Teams are notified by their names, all other responders are
+		// addressed as recipients.
+		var teams, recipients []string
+		for _, r := range n.conf.Responders {
+			// Exactly one of the identifiers is set.
+			name := tmpl(r.ID + r.Name + r.Username)
+			if r.Type == "team" {
+				teams = append(teams, name)
+			} else {
+				recipients = append(recipients, name)
+			}
+		}
+
+		apiURL = n.conf.APIHost.String() + "v1/json/alert"
 		msg = &opsGenieCreateMessage{
 			opsGenieMessage: &apiMsg,
 			Message:         tmpl(n.conf.Description),
 			Details:         details,
+			Source:          tmpl(n.conf.Source),
+			Tags:            tags,
+			Note:            tmpl(n.conf.Note),
+			Priority:        tmpl(n.conf.Priority),
+			Teams:           teams,
+			Recipients:      recipients,
+			Entity:          tmpl(n.conf.Entity),
+			Actions:         actions,
 		}
 	}
 	if err != nil {
@@ -669,7 +1150,7 @@ func (n *OpsGenie) Notify(ctx context.Context, as ...*types.Alert) error {
 		return err
 	}
 
-	resp, err := ctxhttp.Post(ctx, http.DefaultClient, apiURL, contentTypeJSON, &buf)
+	resp, err := ctxhttp.Post(ctx, n.client, apiURL, contentTypeJSON, &buf)
 	if err != nil {
 		return err
 	}
@@ -681,6 +1162,30 @@ func (n *OpsGenie) Notify(ctx context.Context, as ...*types.Alert) error {
 	return nil
 }
 
+// alertLimit returns the maximum number of alerts included in a
+// notification, which is the lower one of the group limit in the context
+// and maxAlerts of the notifier. 0 means unlimited.
+func alertLimit(ctx context.Context, maxAlerts int) int {
+	limit := maxAlerts
+	if gl, ok := GroupLimit(ctx); ok && gl > 0 && (limit == 0 || gl < limit) {
+		limit = gl
+	}
+	return limit
+}
+
+// templateData returns the template data for a notification about the
+// alerts. Only as many alerts as the group limit in the context and
+// maxAlerts allow are included, the status and common labels are those of
+// all alerts.
+func templateData(ctx context.Context, tmpl *template.Template, maxAlerts int, as ...*types.Alert) *template.Data {
+	data := tmpl.Data(receiver(ctx), groupLabels(ctx), as...)
+	if limit := alertLimit(ctx, maxAlerts); limit > 0 && len(data.Alerts) > limit {
+		data.TruncatedAlerts = len(data.Alerts) - limit
+		data.Alerts = data.Alerts[:limit]
+	}
+	return data
+}
+
 func tmplText(tmpl *template.Template, data *template.Data, err *error) func(string) string {
 	return func(name string) (s string) {
 		if *err != nil {
@@ -691,6 +1196,21 @@ func tmplText(tmpl *template.Template, data *template.Data, err *error) func(str
 	}
 }
 
+// tmplInline returns a function that executes an inline template if it
+// is set and returns the given fallback otherwise.
+func tmplInline(tmpl *template.Template, data *template.Data, err *error) func(*config.InlineTemplate, string) string {
+	return func(it *config.InlineTemplate, fallback string) (s string) {
+		if it == nil {
+			return fallback
+		}
+		if *err != nil {
+			return
+		}
+		s, *err = tmpl.ExecuteTextTemplate(it.Template(), data)
+		return s
+	}
+}
+
 func tmplHTML(tmpl *template.Template, data *template.Data, err *error) func(string) string {
 	return func(name string) (s string) {
 		if *err != nil {
@@ -700,3 +1220,92 @@ func tmplHTML(tmpl *template.Template, data *template.Data, err *error) func(str
 		return s
 	}
 }
+
+// newHTTPClient returns an HTTP client configured by c. If c is nil,
+// the default client is returned.
+func newHTTPClient(c *config.HTTPClientConfig) (*http.Client, error) {
+	if c == nil {
+		return http.DefaultClient, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.TLSConfig.InsecureSkipVerify}
+	if c.TLSConfig.CAFile != "" {
+		b, err := ioutil.ReadFile(c.TLSConfig.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA file: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("unable to use specified CA file %s", c.TLSConfig.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.TLSConfig.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLSConfig.CertFile, c.TLSConfig.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to use specified client cert (%s) and key (%s): %s", c.TLSConfig.CertFile, c.TLSConfig.KeyFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if c.ProxyURL != "" {
+		u, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %s", err)
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	var rt http.RoundTripper = &http.Transport{
+		Proxy:           proxy,
+		TLSClientConfig: tlsConfig,
+	}
+	if c.BasicAuth != nil {
+		rt = &basicAuthRoundTripper{
+			username: c.BasicAuth.Username,
+			password: string(c.BasicAuth.Password),
+			rt:       rt,
+		}
+	} else if c.BearerToken != "" {
+		rt = &bearerAuthRoundTripper{
+			token: string(c.BearerToken),
+			rt:    rt,
+		}
+	}
+	return &http.Client{Transport: rt}, nil
+}
+
+type basicAuthRoundTripper struct {
+	username, password string
+	rt                 http.RoundTripper
+}
+
+func (rt *basicAuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = cloneRequest(req)
+	req.SetBasicAuth(rt.username, rt.password)
+	return rt.rt.RoundTrip(req)
+}
+
+type bearerAuthRoundTripper struct {
+	token string
+	rt    http.RoundTripper
+}
+
+func (rt *bearerAuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = cloneRequest(req)
+	req.Header.Set("Authorization", "Bearer "+rt.token)
+	return rt.rt.RoundTrip(req)
+}
+
+// cloneRequest returns a shallow copy of the request with a deep copy of
+// its headers as a RoundTripper must not modify the request it was given.
+func cloneRequest(r *http.Request) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.Header = make(http.Header, len(r.Header))
+	for k, v := range r.Header {
+		r2.Header[k] = append([]string(nil), v...)
+	}
+	return r2
+}
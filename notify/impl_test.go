@@ -0,0 +1,627 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"golang.org/x/net/context"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+func TestBuildUnsupportedIntegrations(t *testing.T) {
+	tmpl, err := template.FromGlobs()
+	if err != nil {
+		t.Fatalf("Error loading templates: %s", err)
+	}
+	cases := []struct {
+		rcv *config.Receiver
+		key string
+	}{
+		{
+			rcv: &config.Receiver{Name: "victorops", VictorOpsConfigs: []*config.VictorOpsConfig{{}}},
+			key: "victorops_configs",
+		},
+		{
+			rcv: &config.Receiver{Name: "wechat", WechatConfigs: []*config.WechatConfig{{}}},
+			key: "wechat_configs",
+		},
+		{
+			rcv: &config.Receiver{Name: "telegram", TelegramConfigs: []*config.TelegramConfig{{}}},
+			key: "telegram_configs",
+		},
+		{
+			rcv: &config.Receiver{Name: "msteams", MSTeamsConfigs: []*config.MSTeamsConfig{{}}},
+			key: "msteams_configs",
+		},
+		{
+			rcv: &config.Receiver{Name: "sns", SNSConfigs: []*config.SNSConfig{{}}},
+			key: "sns_configs",
+		},
+		{
+			rcv: &config.Receiver{Name: "discord", DiscordConfigs: []*config.DiscordConfig{{}}},
+			key: "discord_configs",
+		},
+		{
+			rcv: &config.Receiver{Name: "kafka", KafkaConfigs: []*config.KafkaConfig{{}}},
+			key: "kafka_configs",
+		},
+	}
+	for _, c := range cases {
+		// Supported integrations do not make the receiver acceptable.
+		c.rcv.WebhookConfigs = []*config.WebhookConfig{{URL: mustParseURL(t, "http://example.com/")}}
+		_, err := Build([]*config.Receiver{c.rcv}, tmpl)
+		if want := fmt.Sprintf("receiver %q: %s not supported by the notifier yet", c.rcv.Name, c.key); err == nil || err.Error() != want {
+			t.Errorf("Expected error %q, got %v", want, err)
+		}
+	}
+
+	if _, err := Build([]*config.Receiver{{Name: "blackhole", Blackhole: true}}, tmpl); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+}
+
+func TestWebhookHTTPConfig(t *testing.T) {
+	cases := []struct {
+		httpConfig *config.HTTPClientConfig
+		authHeader string
+	}{
+		{
+			httpConfig: nil,
+			authHeader: "",
+		},
+		{
+			httpConfig: &config.HTTPClientConfig{
+				BearerToken: "secret",
+			},
+			authHeader: "Bearer secret",
+		},
+		{
+			httpConfig: &config.HTTPClientConfig{
+				BasicAuth: &config.BasicAuth{
+					Username: "user",
+					Password: "pass",
+				},
+			},
+			authHeader: "Basic dXNlcjpwYXNz",
+		},
+	}
+
+	for _, c := range cases {
+		var authHeader string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader = r.Header.Get("Authorization")
+		}))
+
+		wh, err := NewWebhook(&config.WebhookConfig{
+			URL:        mustParseURL(t, srv.URL),
+			HTTPConfig: c.httpConfig,
+		}, nil)
+		if err != nil {
+			t.Fatalf("Error creating webhook: %s", err)
+		}
+
+		alert := &types.Alert{
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": "test"},
+				StartsAt: time.Now(),
+			},
+		}
+		if err := wh.Notify(context.Background(), alert); err != nil {
+			t.Fatalf("Error notifying webhook: %s", err)
+		}
+		srv.Close()
+
+		if authHeader != c.authHeader {
+			t.Errorf("Expected Authorization header %q, got %q", c.authHeader, authHeader)
+		}
+	}
+}
+
+func TestWebhookHeaders(t *testing.T) {
+	cfg, err := config.Load(`
+route:
+  receiver: default
+receivers:
+- name: default
+  webhook_configs:
+  - url: http://example.com/
+    http_config:
+      headers:
+        x-tenant-id: '{{ .CommonLabels.tenant }}'
+        X-Source: alertmanager
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	tmpl, err := template.FromGlobs()
+	if err != nil {
+		t.Fatalf("Error loading templates: %s", err)
+	}
+	tmpl.ExternalURL, _ = url.Parse("http://am.example.com")
+
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+	}))
+	defer srv.Close()
+
+	conf := cfg.Receivers[0].WebhookConfigs[0]
+	conf.URL = mustParseURL(t, srv.URL)
+	wh, err := NewWebhook(conf, tmpl)
+	if err != nil {
+		t.Fatalf("Error creating webhook: %s", err)
+	}
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "test", "tenant": "team-a"},
+			StartsAt: time.Now(),
+		},
+	}
+	if err := wh.Notify(WithReceiver(context.Background(), "default"), alert); err != nil {
+		t.Fatalf("Error notifying webhook: %s", err)
+	}
+	if v := header.Get("X-Tenant-Id"); v != "team-a" {
+		t.Errorf("Expected templated header value %q, got %q", "team-a", v)
+	}
+	if v := header.Get("X-Source"); v != "alertmanager" {
+		t.Errorf("Expected header value %q, got %q", "alertmanager", v)
+	}
+	if v := header.Get("Content-Type"); v != contentTypeJSON {
+		t.Errorf("Expected content type %q, got %q", contentTypeJSON, v)
+	}
+}
+
+func TestWebhookMaxAlerts(t *testing.T) {
+	var msg WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("Error decoding request: %s", err)
+		}
+	}))
+	defer srv.Close()
+
+	wh, err := NewWebhook(&config.WebhookConfig{
+		NotifierConfig: config.NotifierConfig{MaxAlerts: 2},
+		URL:            mustParseURL(t, srv.URL),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Error creating webhook: %s", err)
+	}
+
+	var alerts []*types.Alert
+	for _, name := range []string{"a", "b", "c"} {
+		alerts = append(alerts, &types.Alert{
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": model.LabelValue(name)},
+				StartsAt: time.Now(),
+			},
+		})
+	}
+	if err := wh.Notify(context.Background(), alerts...); err != nil {
+		t.Fatalf("Error notifying webhook: %s", err)
+	}
+
+	if msg.Version != config.DefaultWebhookVersion {
+		t.Errorf("Expected version %q, got %q", config.DefaultWebhookVersion, msg.Version)
+	}
+	if len(msg.Alerts) != 2 {
+		t.Fatalf("Expected 2 alerts, got %d", len(msg.Alerts))
+	}
+	if msg.Alerts[1].Labels["alertname"] != "b" {
+		t.Errorf("Expected the first alerts to be sent, got %v", msg.Alerts)
+	}
+	if msg.TruncatedAlerts != 1 {
+		t.Errorf("Expected 1 truncated alert, got %d", msg.TruncatedAlerts)
+	}
+
+	// A lower group limit takes precedence.
+	msg = WebhookMessage{}
+	if err := wh.Notify(WithGroupLimit(context.Background(), 1), alerts...); err != nil {
+		t.Fatalf("Error notifying webhook: %s", err)
+	}
+	if len(msg.Alerts) != 1 || msg.TruncatedAlerts != 2 {
+		t.Errorf("Expected 1 alert and 2 truncated ones, got %d and %d", len(msg.Alerts), msg.TruncatedAlerts)
+	}
+}
+
+func TestTemplateDataGroupLimit(t *testing.T) {
+	tmpl, err := template.FromGlobs()
+	if err != nil {
+		t.Fatalf("Error loading templates: %s", err)
+	}
+	tmpl.ExternalURL, _ = url.Parse("http://am.example.com")
+
+	var alerts []*types.Alert
+	for _, name := range []string{"a", "b", "c"} {
+		alerts = append(alerts, &types.Alert{
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": model.LabelValue(name), "team": "ops"},
+				StartsAt: time.Now().Add(-time.Hour),
+			},
+		})
+	}
+	// Only the last alert is firing.
+	alerts[0].EndsAt = time.Now().Add(-time.Minute)
+	alerts[1].EndsAt = time.Now().Add(-time.Minute)
+
+	ctx := WithReceiver(context.Background(), "default")
+	ctx = WithGroupLabels(ctx, model.LabelSet{"team": "ops"})
+
+	cases := []struct {
+		limit, maxAlerts, alerts, truncated int
+	}{
+		{limit: 0, alerts: 3},
+		{limit: 3, alerts: 3},
+		{limit: 2, alerts: 2, truncated: 1},
+		{maxAlerts: 1, alerts: 1, truncated: 2},
+		{limit: 1, maxAlerts: 2, alerts: 1, truncated: 2},
+		{limit: 2, maxAlerts: 1, alerts: 1, truncated: 2},
+	}
+	for _, c := range cases {
+		data := templateData(WithGroupLimit(ctx, c.limit), tmpl, c.maxAlerts, alerts...)
+		if len(data.Alerts) != c.alerts || data.TruncatedAlerts != c.truncated {
+			t.Errorf("Limit %d: expected %d alerts and %d truncated ones, got %d and %d", c.limit, c.alerts, c.truncated, len(data.Alerts), data.TruncatedAlerts)
+		}
+		if data.Status != string(model.AlertFiring) {
+			t.Errorf("Limit %d: expected status of all alerts, got %q", c.limit, data.Status)
+		}
+		if data.CommonLabels["team"] != "ops" || data.CommonLabels["alertname"] != "" {
+			t.Errorf("Limit %d: expected common labels of all alerts, got %v", c.limit, data.CommonLabels)
+		}
+
+		// The default templates count all firing alerts and mention the
+		// omitted ones.
+		subject, err := tmpl.ExecuteTextString(config.DefaultEmailSubject, data)
+		if err != nil {
+			t.Fatalf("Limit %d: error executing subject template: %s", c.limit, err)
+		}
+		if !strings.HasPrefix(subject, "[FIRING:1] ") {
+			t.Errorf("Limit %d: expected all firing alerts to be counted, got subject %q", c.limit, subject)
+		}
+		desc, err := tmpl.ExecuteTextString(config.DefaultPagerdutyConfig.Description, data)
+		if err != nil {
+			t.Fatalf("Limit %d: error executing description template: %s", c.limit, err)
+		}
+		omitted := fmt.Sprintf("%d more alerts omitted", c.truncated)
+		if strings.Contains(desc, omitted) != (c.truncated > 0) {
+			t.Errorf("Limit %d: unexpected description %q", c.limit, desc)
+		}
+		html, err := tmpl.ExecuteHTMLString(config.DefaultEmailConfig.HTML, data)
+		if err != nil {
+			t.Fatalf("Limit %d: error executing HTML template: %s", c.limit, err)
+		}
+		if strings.Contains(html, omitted) != (c.truncated > 0) {
+			t.Errorf("Limit %d: expected omitted alerts to be mentioned iff there are any", c.limit)
+		}
+	}
+}
+
+func TestNewWebhookInvalidCAFile(t *testing.T) {
+	_, err := NewWebhook(&config.WebhookConfig{
+		URL: mustParseURL(t, "https://example.com/"),
+		HTTPConfig: &config.HTTPClientConfig{
+			TLSConfig: config.TLSConfig{CAFile: "/nonexistent/ca.pem"},
+		},
+	}, nil)
+	if err == nil {
+		t.Fatal("Expected error for missing CA file")
+	}
+}
+
+func TestOpsGenieCreateMessage(t *testing.T) {
+	var msg map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("Error decoding request: %s", err)
+		}
+	}))
+	defer srv.Close()
+
+	tmpl, err := template.FromGlobs()
+	if err != nil {
+		t.Fatalf("Error loading templates: %s", err)
+	}
+	tmpl.ExternalURL, _ = url.Parse("http://am.example.com")
+
+	og, err := NewOpsGenie(&config.OpsGenieConfig{
+		APIKey:   "key",
+		APIHost:  mustParseURL(t, srv.URL+"/"),
+		Priority: "P{{ .CommonLabels.priority }}",
+		Tags:     config.CommaSeparatedList{"{{ .CommonLabels.team }}", "static"},
+		Note:     "note",
+		Responders: []config.OpsGenieConfigResponder{
+			{Type: "team", Name: "{{ .CommonLabels.team }}"},
+			{Type: "user", Username: "alice@example.com"},
+		},
+		Entity:  "{{ .CommonLabels.instance }}",
+		Alias:   "{{ .CommonLabels.alertname }}-{{ .CommonLabels.team }}",
+		Actions: config.CommaSeparatedList{"restart", "{{ .CommonLabels.missing }}"},
+		Details: map[string]string{"team": "{{ .CommonLabels.team }}"},
+	}, tmpl)
+	if err != nil {
+		t.Fatalf("Error creating OpsGenie notifier: %s", err)
+	}
+
+	ctx := WithGroupKey(context.Background(), 1)
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "test", "team": "ops", "priority": "2", "instance": "db-1"},
+			StartsAt: time.Now(),
+		},
+	}
+	if err := og.Notify(ctx, alert); err != nil {
+		t.Fatalf("Error notifying OpsGenie: %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"priority":   "P2",
+		"tags":       []interface{}{"ops", "static"},
+		"note":       "note",
+		"teams":      []interface{}{"ops"},
+		"recipients": []interface{}{"alice@example.com"},
+		"entity":     "db-1",
+		"alias":      "test-ops",
+		"actions":    []interface{}{"restart"},
+		"details":    map[string]interface{}{"team": "ops"},
+	}
+	for k, v := range expected {
+		if !reflect.DeepEqual(msg[k], v) {
+			t.Errorf("Expected %s %v, got %v", k, v, msg[k])
+		}
+	}
+
+	// Without a configured alias, alerts are deduplicated by group key.
+	og.conf.Alias = ""
+	if err := og.Notify(ctx, alert); err != nil {
+		t.Fatalf("Error notifying OpsGenie: %s", err)
+	}
+	if msg["alias"] != "1" {
+		t.Errorf("Expected the group key as alias, got %v", msg["alias"])
+	}
+}
+
+func TestPagerDutyMessageV2(t *testing.T) {
+	var msg map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("Error decoding request: %s", err)
+		}
+	}))
+	defer srv.Close()
+
+	tmpl, err := template.FromGlobs()
+	if err != nil {
+		t.Fatalf("Error loading templates: %s", err)
+	}
+	tmpl.ExternalURL, _ = url.Parse("http://am.example.com")
+
+	pd, err := NewPagerDuty(&config.PagerdutyConfig{
+		RoutingKey:  "v2-key",
+		URL:         mustParseURL(t, srv.URL),
+		Description: "{{ .CommonLabels.alertname }} fired",
+		Severity:    "critical",
+		Component:   "{{ .CommonLabels.job }}",
+		Links:       []config.PagerdutyLink{{Href: "https://runbooks.example.com/", Text: "Runbook"}},
+	}, tmpl)
+	if err != nil {
+		t.Fatalf("Error creating PagerDuty notifier: %s", err)
+	}
+
+	ctx := WithGroupKey(context.Background(), 1)
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "test", "job": "db"},
+			StartsAt: time.Now(),
+		},
+	}
+	if err := pd.Notify(ctx, alert); err != nil {
+		t.Fatalf("Error notifying PagerDuty: %s", err)
+	}
+
+	if msg["routing_key"] != "v2-key" || msg["event_action"] != "trigger" || msg["dedup_key"] == "" {
+		t.Errorf("Unexpected event %v", msg)
+	}
+	payload, _ := msg["payload"].(map[string]interface{})
+	if payload["summary"] != "test fired" || payload["severity"] != "critical" || payload["component"] != "db" {
+		t.Errorf("Unexpected payload %v", payload)
+	}
+	if links, _ := msg["links"].([]interface{}); len(links) != 1 {
+		t.Errorf("Expected 1 link, got %v", msg["links"])
+	}
+}
+
+func mustParseURL(t *testing.T, s string) *config.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatalf("Error parsing URL %q: %s", s, err)
+	}
+	return &config.URL{URL: u}
+}
+
+func TestInlineTemplates(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Error decoding request: %s", err)
+		}
+	}))
+	defer srv.Close()
+
+	tmpl, err := template.FromGlobs()
+	if err != nil {
+		t.Fatalf("Error loading templates: %s", err)
+	}
+	tmpl.ExternalURL, _ = url.Parse("http://am.example.com")
+
+	cfg, err := config.Load(`
+route:
+  receiver: default
+receivers:
+- name: default
+  slack_configs:
+  - api_url: ` + srv.URL + `
+    channel: '#ops'
+    title: ignored
+    title_template: '{{ .CommonLabels.alertname | toUpper }}'
+    text_template: '{{ define "count" }}{{ len .Alerts }}{{ end }}{{ template "count" . }} alerts'
+  webhook_configs:
+  - url: ` + srv.URL + `
+    text_template: '{{ template "__subject" . }}'
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	rcv := cfg.Receivers[0]
+
+	ctx := WithGroupKey(context.Background(), 1)
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "test"},
+			StartsAt: time.Now(),
+		},
+	}
+
+	sl, err := NewSlack(rcv.SlackConfigs[0], tmpl)
+	if err != nil {
+		t.Fatalf("Error creating Slack notifier: %s", err)
+	}
+	if err := sl.Notify(ctx, alert); err != nil {
+		t.Fatalf("Error notifying Slack: %s", err)
+	}
+	attachment := body["attachments"].([]interface{})[0].(map[string]interface{})
+	if attachment["title"] != "TEST" || attachment["text"] != "1 alerts" {
+		t.Errorf("Unexpected Slack attachment %v", attachment)
+	}
+
+	wh, err := NewWebhook(rcv.WebhookConfigs[0], tmpl)
+	if err != nil {
+		t.Fatalf("Error creating webhook: %s", err)
+	}
+	if err := wh.Notify(ctx, alert); err != nil {
+		t.Fatalf("Error notifying webhook: %s", err)
+	}
+	if body["text"] != "[FIRING:1]  (test)" {
+		t.Errorf("Unexpected webhook text %q", body["text"])
+	}
+}
+
+func TestSlackBlocks(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			t.Errorf("Error reading request: %s", err)
+		}
+	}))
+	defer srv.Close()
+
+	tmpl, err := template.FromGlobs()
+	if err != nil {
+		t.Fatalf("Error loading templates: %s", err)
+	}
+	tmpl.ExternalURL, _ = url.Parse("http://am.example.com")
+
+	cfg, err := config.Load(`
+route:
+  receiver: default
+receivers:
+- name: default
+  slack_configs:
+  - api_url: ` + srv.URL + `
+    channel: '#ops'
+    blocks:
+    - type: section
+      text: {type: mrkdwn, text: '*{{ .CommonLabels.alertname }}* is firing'}
+    - type: actions
+      elements:
+      - {type: button, url: '{{ .ExternalURL }}', text: {type: plain_text, text: Open}}
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+
+	sl, err := NewSlack(cfg.Receivers[0].SlackConfigs[0], tmpl)
+	if err != nil {
+		t.Fatalf("Error creating Slack notifier: %s", err)
+	}
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "test"},
+			StartsAt: time.Now(),
+		},
+	}
+	if err := sl.Notify(WithGroupKey(context.Background(), 1), alert); err != nil {
+		t.Fatalf("Error notifying Slack: %s", err)
+	}
+
+	// The keys of the blocks are sent in the configured order.
+	want := `"blocks":[{"type":"section","text":{"type":"mrkdwn","text":"*test* is firing"}},` +
+		`{"type":"actions","elements":[{"type":"button","url":"http://am.example.com","text":{"type":"plain_text","text":"Open"}}]}]`
+	if !strings.Contains(string(body), want) {
+		t.Errorf("Expected request to contain %s, got %s", want, body)
+	}
+	if strings.Contains(string(body), `"text":"{{`) {
+		t.Errorf("Expected the default text to be replaced by the blocks, got %s", body)
+	}
+}
+
+func TestLoginAuth(t *testing.T) {
+	auth := &loginAuth{username: "user", password: "pass", host: "smtp.example.com"}
+
+	cases := []struct {
+		server *smtp.ServerInfo
+		err    string
+	}{
+		{server: &smtp.ServerInfo{Name: "smtp.example.com", TLS: true}},
+		{
+			server: &smtp.ServerInfo{Name: "smtp.example.com"},
+			err:    "unencrypted connection",
+		},
+		{
+			server: &smtp.ServerInfo{Name: "other.example.com", TLS: true},
+			err:    "wrong host name",
+		},
+	}
+	for _, c := range cases {
+		_, _, err := auth.Start(c.server)
+		if c.err == "" && err != nil {
+			t.Errorf("%+v: unexpected error: %s", c.server, err)
+		}
+		if c.err != "" && (err == nil || err.Error() != c.err) {
+			t.Errorf("%+v: expected error %q, got %v", c.server, c.err, err)
+		}
+	}
+
+	// Unencrypted connections to localhost are allowed.
+	local := &loginAuth{username: "user", password: "pass", host: "localhost"}
+	if _, _, err := local.Start(&smtp.ServerInfo{Name: "localhost"}); err != nil {
+		t.Errorf("Unexpected error for unencrypted localhost connection: %s", err)
+	}
+}
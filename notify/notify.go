@@ -41,6 +41,7 @@ const (
 	keyGroupLabels
 	keyGroupKey
 	keyNow
+	keyGroupLimit
 )
 
 // WithReceiver populates a context with a receiver.
@@ -63,6 +64,12 @@ func WithGroupLabels(ctx context.Context, lset model.LabelSet) context.Context {
 	return context.WithValue(ctx, keyGroupLabels, lset)
 }
 
+// WithGroupLimit populates a context with the maximum number of alerts
+// included in a notification.
+func WithGroupLimit(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, keyGroupLimit, n)
+}
+
 // WithNow populates a context with a now timestamp.
 func WithNow(ctx context.Context, t time.Time) context.Context {
 	return context.WithValue(ctx, keyNow, t)
@@ -112,6 +119,14 @@ func GroupLabels(ctx context.Context) (model.LabelSet, bool) {
 	return v, ok
 }
 
+// GroupLimit extracts the maximum number of alerts included in a
+// notification from the context. Iff none exists, the second argument is
+// false.
+func GroupLimit(ctx context.Context) (int, bool) {
+	v, ok := ctx.Value(keyGroupLimit).(int)
+	return v, ok
+}
+
 // Now extracts a now timestamp from the context. Iff none exists, the
 // second argument is false.
 func Now(ctx context.Context) (time.Time, bool) {
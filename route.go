@@ -27,9 +27,9 @@ import (
 // DefaultRouteOpts are the defaulting routing options which apply
 // to the root route of a routing tree.
 var DefaultRouteOpts = RouteOpts{
-	GroupWait:      30 * time.Second,
-	GroupInterval:  5 * time.Minute,
-	RepeatInterval: 4 * time.Hour,
+	GroupWait:      time.Duration(config.DefaultGroupWait),
+	GroupInterval:  time.Duration(config.DefaultGroupInterval),
+	RepeatInterval: time.Duration(config.DefaultRepeatInterval),
 	GroupBy: map[model.LabelName]struct{}{
 		model.AlertNameLabel: struct{}{},
 	},
@@ -45,6 +45,15 @@ type Route struct {
 	// Equality or regex matchers an alert has to fulfill to match
 	// this route.
 	Matchers types.Matchers
+	// cidrs holds the networks an alert's labels have to hold an IP
+	// address of to match this route.
+	cidrs map[model.LabelName][]config.CIDR
+	// annotationMatchers are matchers an alert's annotations have to
+	// fulfill to match this route.
+	annotationMatchers types.Matchers
+	// activeIntervals are the time intervals outside of which the route
+	// does not match. It matches at all times if there are none.
+	activeIntervals []*config.TimeInterval
 
 	// If true, an alert matches further routes on the same level.
 	Continue bool
@@ -64,11 +73,14 @@ func NewRoute(cr *config.Route, parent *Route) *Route {
 	if cr.Receiver != "" {
 		opts.Receiver = cr.Receiver
 	}
-	if cr.GroupBy != nil {
+	// Mute time intervals only apply to the route they are set on.
+	opts.MuteTimeIntervals = nil
+	if cr.GroupBy != nil || cr.GroupByAll {
 		opts.GroupBy = map[model.LabelName]struct{}{}
 		for _, ln := range cr.GroupBy {
 			opts.GroupBy[ln] = struct{}{}
 		}
+		opts.GroupByAll = cr.GroupByAll
 	}
 	if cr.GroupWait != nil {
 		opts.GroupWait = time.Duration(*cr.GroupWait)
@@ -79,6 +91,15 @@ func NewRoute(cr *config.Route, parent *Route) *Route {
 	if cr.RepeatInterval != nil {
 		opts.RepeatInterval = time.Duration(*cr.RepeatInterval)
 	}
+	if cr.InitialDelay != nil {
+		opts.InitialDelay = time.Duration(*cr.InitialDelay)
+	}
+	if cr.GroupLimit != nil {
+		opts.GroupLimit = *cr.GroupLimit
+	}
+	if cr.MinGroupSize != nil {
+		opts.MinGroupSize = *cr.MinGroupSize
+	}
 
 	// Build matchers.
 	var matchers types.Matchers
@@ -89,12 +110,55 @@ func NewRoute(cr *config.Route, parent *Route) *Route {
 	for ln, lv := range cr.MatchRE {
 		matchers = append(matchers, types.NewRegexMatcher(model.LabelName(ln), lv.Regexp))
 	}
+	for ln, lv := range cr.MatchNot {
+		matchers = append(matchers, types.NewNegativeMatcher(model.LabelName(ln), lv))
+	}
+	for ln, lv := range cr.MatchNotRE {
+		matchers = append(matchers, types.NewNegativeRegexMatcher(model.LabelName(ln), lv.Regexp))
+	}
+	for ln, vs := range cr.MatchIn {
+		matchers = append(matchers, types.NewRegexMatcher(model.LabelName(ln), config.NewValuesRegexp(vs).Regexp))
+	}
+	for ln, vs := range cr.MatchNotIn {
+		matchers = append(matchers, types.NewNegativeRegexMatcher(model.LabelName(ln), config.NewValuesRegexp(vs).Regexp))
+	}
+	for _, m := range cr.Matchers {
+		ln := model.LabelName(m.Name)
+		switch m.Type {
+		case config.MatchEqual:
+			matchers = append(matchers, types.NewMatcher(ln, m.Value))
+		case config.MatchNotEqual:
+			matchers = append(matchers, types.NewNegativeMatcher(ln, m.Value))
+		case config.MatchRegexp:
+			matchers = append(matchers, types.NewRegexMatcher(ln, m.Regexp.Regexp))
+		case config.MatchNotRegexp:
+			matchers = append(matchers, types.NewNegativeRegexMatcher(ln, m.Regexp.Regexp))
+		}
+	}
+
+	var annotationMatchers types.Matchers
+	for an, av := range cr.AnnotationMatch {
+		annotationMatchers = append(annotationMatchers, types.NewMatcher(model.LabelName(an), av))
+	}
+	for an, av := range cr.AnnotationMatchRE {
+		annotationMatchers = append(annotationMatchers, types.NewRegexMatcher(model.LabelName(an), av.Regexp))
+	}
+
+	var cidrs map[model.LabelName][]config.CIDR
+	for ln, nets := range cr.MatchCIDR {
+		if cidrs == nil {
+			cidrs = map[model.LabelName][]config.CIDR{}
+		}
+		cidrs[model.LabelName(ln)] = nets
+	}
 
 	route := &Route{
-		parent:    parent,
-		RouteOpts: opts,
-		Matchers:  matchers,
-		Continue:  cr.Continue,
+		parent:             parent,
+		RouteOpts:          opts,
+		Matchers:           matchers,
+		cidrs:              cidrs,
+		annotationMatchers: annotationMatchers,
+		Continue:           cr.Continue,
 	}
 
 	route.Routes = NewRoutes(cr.Routes, route)
@@ -102,6 +166,47 @@ func NewRoute(cr *config.Route, parent *Route) *Route {
 	return route
 }
 
+// NewRouteTree returns the routing tree of the configuration. Unlike
+// routes returned by NewRoute, its routes take the active and mute time
+// intervals they refer to into account.
+func NewRouteTree(c *config.Config) *Route {
+	intervals := make(map[string]*config.TimeInterval, len(c.TimeIntervals))
+	for _, ti := range c.TimeIntervals {
+		intervals[ti.Name] = ti
+	}
+	r := NewRoute(c.Route, nil)
+	r.resolveTimeIntervals(c.Route, intervals)
+	return r
+}
+
+// resolveTimeIntervals sets the time intervals of the route and its
+// children, which were created from cr, to the ones cr refers to.
+func (r *Route) resolveTimeIntervals(cr *config.Route, intervals map[string]*config.TimeInterval) {
+	for _, name := range cr.ActiveTimeIntervals {
+		if ti, ok := intervals[name]; ok {
+			r.activeIntervals = append(r.activeIntervals, ti)
+		}
+	}
+	for _, name := range cr.MuteTimeIntervals {
+		if ti, ok := intervals[name]; ok {
+			r.RouteOpts.MuteTimeIntervals = append(r.RouteOpts.MuteTimeIntervals, ti)
+		}
+	}
+	for i, child := range r.Routes {
+		child.resolveTimeIntervals(cr.Routes[i], intervals)
+	}
+}
+
+// inTimeIntervals returns true if t falls into any of the time intervals.
+func inTimeIntervals(intervals []*config.TimeInterval, t time.Time) bool {
+	for _, ti := range intervals {
+		if ti.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
 // NewRoutes returns a slice of routes.
 func NewRoutes(croutes []*config.Route, parent *Route) []*Route {
 	res := []*Route{}
@@ -112,16 +217,34 @@ func NewRoutes(croutes []*config.Route, parent *Route) []*Route {
 }
 
 // Match does a depth-first left-to-right search through the route tree
-// and returns the matching routing nodes.
-func (r *Route) Match(lset model.LabelSet) []*Route {
+// and returns the matching routing nodes for an alert with the given
+// labels and annotations.
+func (r *Route) Match(lset, annotations model.LabelSet) []*Route {
+	return r.MatchAt(lset, annotations, time.Now())
+}
+
+// MatchAt is like Match, but routes with active time intervals only match
+// if t falls into one of them.
+func (r *Route) MatchAt(lset, annotations model.LabelSet, t time.Time) []*Route {
 	if !r.Matchers.Match(lset) {
 		return nil
 	}
+	if len(r.annotationMatchers) > 0 && !r.annotationMatchers.Match(annotations) {
+		return nil
+	}
+	if len(r.activeIntervals) > 0 && !inTimeIntervals(r.activeIntervals, t) {
+		return nil
+	}
+	for ln, nets := range r.cidrs {
+		if !config.InNetworks(string(lset[ln]), nets) {
+			return nil
+		}
+	}
 
 	var all []*Route
 
 	for _, cr := range r.Routes {
-		matches := cr.Match(lset)
+		matches := cr.MatchAt(lset, annotations, t)
 
 		all = append(all, matches...)
 
@@ -163,6 +286,29 @@ func (r *Route) Fingerprint() model.Fingerprint {
 	for ln := range r.RouteOpts.GroupBy {
 		lset[ln] = ""
 	}
+	if r.RouteOpts.GroupByAll {
+		lset["..."] = ""
+	}
+	// The networks of CIDR matchers are not part of the matchers, but
+	// distinguish routes as well.
+	for pr, depth := r, 0; pr != nil; pr, depth = pr.parent, depth+1 {
+		for ln, nets := range pr.cidrs {
+			for _, n := range nets {
+				lset[model.LabelName(fmt.Sprintf("%s-cidr-%s", ln, n))] = ""
+			}
+		}
+		// So do the annotation matchers.
+		for _, m := range pr.annotationMatchers {
+			lset[model.LabelName(fmt.Sprintf("%d-annotation-%s", depth, m))] = ""
+		}
+		// So do the time intervals routes are active in.
+		for _, ti := range pr.activeIntervals {
+			lset[model.LabelName(fmt.Sprintf("%d-active-%s", depth, ti.Name))] = ""
+		}
+	}
+	for _, ti := range r.RouteOpts.MuteTimeIntervals {
+		lset[model.LabelName("mute-"+ti.Name)] = ""
+	}
 
 	return r.SquashMatchers().Fingerprint() ^ lset.Fingerprint()
 }
@@ -176,11 +322,28 @@ type RouteOpts struct {
 	// What labels to group alerts by for notifications.
 	GroupBy map[model.LabelName]struct{}
 
+	// Use all alert labels to group.
+	GroupByAll bool
+
 	// How long to wait to group matching alerts before sending
 	// a notificaiton
 	GroupWait      time.Duration
 	GroupInterval  time.Duration
 	RepeatInterval time.Duration
+
+	// How long to additionally hold back the first notification of a group.
+	InitialDelay time.Duration
+
+	// The maximum number of alerts included in a notification, 0 means
+	// unlimited.
+	GroupLimit int
+
+	// The minimum number of firing alerts of a group before it is
+	// notified about, 0 means no minimum.
+	MinGroupSize int
+
+	// The time intervals during which no notifications are sent.
+	MuteTimeIntervals []*config.TimeInterval
 }
 
 func (ro *RouteOpts) String() string {
@@ -188,6 +351,9 @@ func (ro *RouteOpts) String() string {
 	for ln := range ro.GroupBy {
 		labels = append(labels, ln)
 	}
+	if ro.GroupByAll {
+		labels = append(labels, "...")
+	}
 	return fmt.Sprintf("<RouteOpts send_to:%q group_by:%q timers:%q|%q>", ro.Receiver, labels, ro.GroupWait, ro.GroupInterval)
 }
 
@@ -196,14 +362,22 @@ func (ro *RouteOpts) MarshalJSON() ([]byte, error) {
 	v := struct {
 		Receiver       string           `json:"receiver"`
 		GroupBy        model.LabelNames `json:"groupBy"`
+		GroupByAll     bool             `json:"groupByAll"`
 		GroupWait      time.Duration    `json:"groupWait"`
 		GroupInterval  time.Duration    `json:"groupInterval"`
 		RepeatInterval time.Duration    `json:"repeatInterval"`
+		InitialDelay   time.Duration    `json:"initialDelay"`
+		GroupLimit     int              `json:"groupLimit"`
+		MinGroupSize   int              `json:"minGroupSize"`
 	}{
 		Receiver:       ro.Receiver,
+		GroupByAll:     ro.GroupByAll,
 		GroupWait:      ro.GroupWait,
 		GroupInterval:  ro.GroupInterval,
 		RepeatInterval: ro.RepeatInterval,
+		InitialDelay:   ro.InitialDelay,
+		GroupLimit:     ro.GroupLimit,
+		MinGroupSize:   ro.MinGroupSize,
 	}
 	for ln := range ro.GroupBy {
 		v.GroupBy = append(v.GroupBy, ln)
@@ -171,7 +171,7 @@ routes:
 
 	for _, test := range tests {
 		var matches []*RouteOpts
-		for _, r := range tree.Match(test.input) {
+		for _, r := range tree.Match(test.input, nil) {
 			matches = append(matches, &r.RouteOpts)
 		}
 
@@ -180,3 +180,453 @@ routes:
 		}
 	}
 }
+
+func TestRouteMatchNot(t *testing.T) {
+	in := `
+receiver: 'notify-def'
+
+routes:
+- match:
+    owner: 'team-A'
+  match_not:
+    env: 'testing'
+  match_not_re:
+    severity: 'info|debug'
+
+  receiver: 'notify-A'
+`
+
+	var ctree config.Route
+	if err := yaml.Unmarshal([]byte(in), &ctree); err != nil {
+		t.Fatal(err)
+	}
+	tree := NewRoute(&ctree, nil)
+
+	tests := []struct {
+		input    model.LabelSet
+		receiver string
+	}{
+		{
+			input:    model.LabelSet{"owner": "team-A"},
+			receiver: "notify-A",
+		},
+		{
+			input:    model.LabelSet{"owner": "team-A", "env": "production", "severity": "critical"},
+			receiver: "notify-A",
+		},
+		{
+			input:    model.LabelSet{"owner": "team-A", "env": "testing"},
+			receiver: "notify-def",
+		},
+		{
+			input:    model.LabelSet{"owner": "team-A", "severity": "debug"},
+			receiver: "notify-def",
+		},
+		{
+			input:    model.LabelSet{"owner": "team-B"},
+			receiver: "notify-def",
+		},
+	}
+
+	for _, test := range tests {
+		matches := tree.Match(test.input, nil)
+		if len(matches) != 1 {
+			t.Errorf("Expected a single match for %v, got %d", test.input, len(matches))
+			continue
+		}
+		if matches[0].RouteOpts.Receiver != test.receiver {
+			t.Errorf("Expected receiver %q for %v, got %q", test.receiver, test.input, matches[0].RouteOpts.Receiver)
+		}
+	}
+}
+
+func TestRouteMatchIn(t *testing.T) {
+	in := `
+receiver: 'notify-def'
+
+routes:
+- match_in:
+    severity: ['critical', 'page', 'sev.1']
+  match_not_in:
+    env: ['testing', 'staging']
+
+  receiver: 'notify-pager'
+`
+
+	var ctree config.Route
+	if err := yaml.Unmarshal([]byte(in), &ctree); err != nil {
+		t.Fatal(err)
+	}
+	tree := NewRoute(&ctree, nil)
+
+	tests := []struct {
+		input    model.LabelSet
+		receiver string
+	}{
+		{
+			input:    model.LabelSet{"severity": "critical"},
+			receiver: "notify-pager",
+		},
+		{
+			input:    model.LabelSet{"severity": "sev.1", "env": "production"},
+			receiver: "notify-pager",
+		},
+		{
+			// Values are matched literally.
+			input:    model.LabelSet{"severity": "sevX1"},
+			receiver: "notify-def",
+		},
+		{
+			input:    model.LabelSet{"severity": "page", "env": "staging"},
+			receiver: "notify-def",
+		},
+		{
+			input:    model.LabelSet{"severity": "warning"},
+			receiver: "notify-def",
+		},
+	}
+
+	for _, test := range tests {
+		matches := tree.Match(test.input, nil)
+		if len(matches) != 1 {
+			t.Errorf("Expected a single match for %v, got %d", test.input, len(matches))
+			continue
+		}
+		if matches[0].RouteOpts.Receiver != test.receiver {
+			t.Errorf("Expected receiver %q for %v, got %q", test.receiver, test.input, matches[0].RouteOpts.Receiver)
+		}
+		if got := ctree.MatchLabels(test.input); got[len(got)-1].Receiver != test.receiver {
+			t.Errorf("Expected config route for receiver %q for %v, got %q", test.receiver, test.input, got[len(got)-1].Receiver)
+		}
+	}
+}
+
+func TestRouteMatchUnanchored(t *testing.T) {
+	in := `
+receiver: 'notify-def'
+
+routes:
+- match_re:
+    service:
+      pattern: 'foo'
+      anchored: false
+  receiver: 'notify-foo'
+`
+
+	var ctree config.Route
+	if err := yaml.Unmarshal([]byte(in), &ctree); err != nil {
+		t.Fatal(err)
+	}
+	tree := NewRoute(&ctree, nil)
+
+	for input, receiver := range map[string]string{"myfoobar": "notify-foo", "fo": "notify-def"} {
+		matches := tree.Match(model.LabelSet{"service": model.LabelValue(input)}, nil)
+		if len(matches) != 1 || matches[0].RouteOpts.Receiver != receiver {
+			t.Errorf("Expected a single match with receiver %q for service %q, got %v", receiver, input, matches)
+		}
+	}
+}
+
+func TestRouteMatchCIDR(t *testing.T) {
+	in := `
+receiver: 'notify-def'
+
+routes:
+- match_cidr:
+    instance_ip: ['10.0.0.0/8']
+  receiver: 'notify-private'
+- match_cidr:
+    instance_ip: ['fd00::/8']
+  receiver: 'notify-private-v6'
+`
+
+	var ctree config.Route
+	if err := yaml.Unmarshal([]byte(in), &ctree); err != nil {
+		t.Fatal(err)
+	}
+	tree := NewRoute(&ctree, nil)
+
+	for ip, receiver := range map[string]string{
+		"10.0.0.1":    "notify-private",
+		"fd12::1":     "notify-private-v6",
+		"11.0.0.1":    "notify-def",
+		"not-an-ip":   "notify-def",
+		"fe80::1%eth": "notify-def",
+	} {
+		matches := tree.Match(model.LabelSet{"instance_ip": model.LabelValue(ip)}, nil)
+		if len(matches) != 1 || matches[0].RouteOpts.Receiver != receiver {
+			t.Errorf("Expected a single match with receiver %q for %q, got %v", receiver, ip, matches)
+		}
+	}
+
+	// Routes differing only in their networks are distinguished.
+	if tree.Routes[0].Fingerprint() == tree.Routes[1].Fingerprint() {
+		t.Errorf("Expected routes with different networks to have different fingerprints")
+	}
+}
+
+func TestRouteMatchAnnotations(t *testing.T) {
+	in := `
+receiver: 'notify-def'
+
+routes:
+- annotation_match:
+    owning team: database
+  receiver: 'notify-db'
+- match:
+    env: production
+  annotation_match_re:
+    runbook: 'https://runbooks\.example\.com/.*'
+  receiver: 'notify-prod'
+- match:
+    env: production
+  receiver: 'notify-prod-fallback'
+`
+
+	var ctree config.Route
+	if err := yaml.Unmarshal([]byte(in), &ctree); err != nil {
+		t.Fatal(err)
+	}
+	tree := NewRoute(&ctree, nil)
+
+	cases := []struct {
+		labels, annotations model.LabelSet
+		receiver            string
+	}{
+		{
+			annotations: model.LabelSet{"owning team": "database"},
+			receiver:    "notify-db",
+		},
+		{
+			// Labels do not match annotation matchers.
+			labels:   model.LabelSet{"owning team": "database"},
+			receiver: "notify-def",
+		},
+		{
+			labels:      model.LabelSet{"env": "production"},
+			annotations: model.LabelSet{"runbook": "https://runbooks.example.com/db"},
+			receiver:    "notify-prod",
+		},
+		{
+			labels:      model.LabelSet{"env": "production"},
+			annotations: model.LabelSet{"runbook": "https://wiki.example.com/db"},
+			receiver:    "notify-prod-fallback",
+		},
+		{
+			labels:   model.LabelSet{"env": "production"},
+			receiver: "notify-prod-fallback",
+		},
+	}
+	for _, c := range cases {
+		matches := tree.Match(c.labels, c.annotations)
+		if len(matches) != 1 || matches[0].RouteOpts.Receiver != c.receiver {
+			t.Errorf("Expected a single match with receiver %q for labels %v and annotations %v, got %v", c.receiver, c.labels, c.annotations, matches)
+		}
+	}
+
+	// Routes differing only in their annotation matchers are
+	// distinguished.
+	if tree.Routes[1].Fingerprint() == tree.Routes[2].Fingerprint() {
+		t.Errorf("Expected routes with different annotation matchers to have different fingerprints")
+	}
+}
+
+func TestRouteGroupByAll(t *testing.T) {
+	in := `
+receiver: 'notify-def'
+group_by: ['alertname']
+
+routes:
+- match:
+    owner: 'team-A'
+  receiver: 'notify-A'
+  group_by: ['...']
+
+  routes:
+  - match:
+      env: 'testing'
+    receiver: 'notify-testing'
+`
+
+	var ctree config.Route
+	if err := yaml.Unmarshal([]byte(in), &ctree); err != nil {
+		t.Fatal(err)
+	}
+	tree := NewRoute(&ctree, nil)
+
+	tests := []struct {
+		input model.LabelSet
+		all   bool
+	}{
+		{
+			input: model.LabelSet{"owner": "team-B"},
+			all:   false,
+		},
+		{
+			input: model.LabelSet{"owner": "team-A"},
+			all:   true,
+		},
+		{
+			// The wildcard is inherited by child routes.
+			input: model.LabelSet{"owner": "team-A", "env": "testing"},
+			all:   true,
+		},
+	}
+
+	for _, test := range tests {
+		matches := tree.Match(test.input, nil)
+		if len(matches) != 1 {
+			t.Errorf("Expected a single match for %v, got %d", test.input, len(matches))
+			continue
+		}
+		if matches[0].RouteOpts.GroupByAll != test.all {
+			t.Errorf("Expected grouping by all labels to be %t for %v", test.all, test.input)
+		}
+	}
+}
+
+func TestRouteGroupLimit(t *testing.T) {
+	in := `
+receiver: 'notify-def'
+group_limit: 50
+
+routes:
+- match:
+    owner: 'team-A'
+  receiver: 'notify-A'
+  group_limit: 10
+
+- match:
+    owner: 'team-B'
+  receiver: 'notify-B'
+`
+
+	var ctree config.Route
+	if err := yaml.Unmarshal([]byte(in), &ctree); err != nil {
+		t.Fatal(err)
+	}
+	tree := NewRoute(&ctree, nil)
+
+	for owner, limit := range map[model.LabelValue]int{"team-A": 10, "team-B": 50} {
+		matches := tree.Match(model.LabelSet{"owner": owner}, nil)
+		if len(matches) != 1 {
+			t.Errorf("Expected a single match for %s, got %d", owner, len(matches))
+			continue
+		}
+		if got := matches[0].RouteOpts.GroupLimit; got != limit {
+			t.Errorf("Expected group limit %d for %s, got %d", limit, owner, got)
+		}
+	}
+}
+
+func TestRouteInitialDelay(t *testing.T) {
+	in := `
+receiver: 'notify-def'
+initial_delay: 5m
+
+routes:
+- match:
+    owner: 'team-A'
+  receiver: 'notify-A'
+  initial_delay: 1m
+
+- match:
+    owner: 'team-B'
+  receiver: 'notify-B'
+`
+
+	var ctree config.Route
+	if err := yaml.Unmarshal([]byte(in), &ctree); err != nil {
+		t.Fatal(err)
+	}
+	tree := NewRoute(&ctree, nil)
+
+	for owner, delay := range map[model.LabelValue]time.Duration{"team-A": time.Minute, "team-B": 5 * time.Minute} {
+		matches := tree.Match(model.LabelSet{"owner": owner}, nil)
+		if len(matches) != 1 {
+			t.Errorf("Expected a single match for %s, got %d", owner, len(matches))
+			continue
+		}
+		if got := matches[0].RouteOpts.InitialDelay; got != delay {
+			t.Errorf("Expected initial delay %s for %s, got %s", delay, owner, got)
+		}
+	}
+}
+
+func TestRouteTreeTimeIntervals(t *testing.T) {
+	cfg, err := config.Load(`
+route:
+  receiver: default
+  routes:
+  - receiver: weekend-oncall
+    active_time_intervals: [weekends]
+    mute_time_intervals: [nights]
+  - receiver: oncall
+
+receivers:
+- name: default
+  blackhole: true
+- name: weekend-oncall
+  blackhole: true
+- name: oncall
+  blackhole: true
+
+time_intervals:
+- name: weekends
+  time_intervals:
+  - weekdays: [saturday, sunday]
+- name: nights
+  time_intervals:
+  - times:
+    - start_time: "00:00"
+      end_time: "06:00"
+`)
+	if err != nil {
+		t.Fatalf("Error loading config: %s", err)
+	}
+	tree := NewRouteTree(cfg)
+
+	cases := []struct {
+		at       time.Time
+		receiver string
+		muted    bool
+	}{
+		{
+			// Wednesday noon, outside of the active interval.
+			at:       time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC),
+			receiver: "oncall",
+		},
+		{
+			// Saturday noon.
+			at:       time.Date(2017, 3, 4, 12, 0, 0, 0, time.UTC),
+			receiver: "weekend-oncall",
+		},
+		{
+			// Saturday night, the matching route is muted.
+			at:       time.Date(2017, 3, 4, 3, 0, 0, 0, time.UTC),
+			receiver: "weekend-oncall",
+			muted:    true,
+		},
+		{
+			// Wednesday night, mute intervals are not inherited.
+			at:       time.Date(2017, 3, 1, 3, 0, 0, 0, time.UTC),
+			receiver: "oncall",
+		},
+	}
+
+	for _, c := range cases {
+		matches := tree.MatchAt(model.LabelSet{"alertname": "test"}, nil, c.at)
+		if len(matches) != 1 {
+			t.Fatalf("At %s: expected 1 match, got %d", c.at, len(matches))
+		}
+		opts := matches[0].RouteOpts
+		if muted := inTimeIntervals(opts.MuteTimeIntervals, c.at); opts.Receiver != c.receiver || muted != c.muted {
+			t.Errorf("At %s: expected receiver %q muted %t, got %q muted %t", c.at, c.receiver, c.muted, opts.Receiver, muted)
+		}
+	}
+
+	// Routes built without the config ignore time intervals.
+	if matches := NewRoute(cfg.Route, nil).MatchAt(model.LabelSet{"alertname": "test"}, nil, cases[0].at); matches[0].RouteOpts.Receiver != "weekend-oncall" {
+		t.Errorf("Expected time intervals to be ignored by NewRoute, got receiver %q", matches[0].RouteOpts.Receiver)
+	}
+}
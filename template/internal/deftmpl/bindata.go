@@ -68,7 +68,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _templateDefaultTmpl = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xec\x1a\x6d\x73\xd3\x3c\xf2\x7b\x7e\x85\x30\x73\x03\x9d\xc1\x71\xda\x42\x87\xa6\x49\x6f\xb8\xd2\x1e\x37\x53\x38\xa6\xb4\xdc\xdd\x30\x0c\xa3\xd8\x4a\x22\x2a\x5b\x46\x52\x92\x16\x8e\xff\x7e\x2b\xc9\xb1\xad\xd8\x79\x69\x87\x4b\xf3\x3c\xa4\x1d\xa8\xb5\xde\x5d\xed\xae\xf6\x45\xd2\xfa\xc7\x0f\x14\x91\x3e\x4d\x08\xf2\xbe\x7c\xc1\x8c\x08\x15\xe3\x04\x0f\x88\xf0\xd0\xcf\x9f\xaf\xf4\xf8\xad\x1d\x03\x22\x49\x22\x00\x36\xe6\x92\x5c\x5d\x9c\x6b\x2a\x78\xdf\x3c\xbd\x51\x44\x24\x98\x01\x08\x20\xc1\xe3\xc0\xe0\xc9\xbf\x0a\x12\x12\x3a\x26\xa2\xab\x91\x2e\xb2\x81\xa5\xc9\xb8\xbb\xec\xe5\xa8\xf7\x95\x84\x4a\xb3\xfd\xa4\x49\x3e\x28\xac\x46\x12\xfd\x17\x29\x7e\x95\xa6\x53\x52\xda\x47\xe4\x5b\xfe\xd2\xeb\x53\x41\x93\x81\xa6\x69\x6b\x1a\xa3\x85\x6c\x9e\x19\x28\x90\x32\x92\x94\x67\xfc\x8c\x34\xd2\xdf\x05\x1f\xa5\xe7\xb8\x47\x98\x6c\x7e\xe0\x42\x91\xe8\x3d\xa6\x42\x36\x3f\x62\x36\x22\x7a\xc2\xaf\x9c\x26\xc8\x43\x9a\x2b\xb2\x53\x0e\x14\x7a\xaa\x79\x35\x4f\x78\x1c\xf3\xc4\x12\xef\x64\xb0\x12\xbf\x1d\x20\x79\x0a\x24\x13\xaa\x86\x2e\x32\x58\x20\xe6\x63\xe2\xce\xfe\x0e\xc7\x30\xa1\x35\x63\xdd\xec\xb9\xe0\x3b\xf9\xd3\x9c\xb5\x89\x88\x0c\x05\x4d\x15\xe5\x89\xb7\xc0\xc6\x8a\xdc\x28\xbb\x8e\x5f\x18\x95\x2a\x43\x15\x38\x19\x80\x64\x30\xb0\x72\xb5\x1b\x05\xb0\x6a\x27\x6d\x15\xdf\x18\x52\x8b\xaf\x47\x5d\x94\x2b\x90\x09\x66\x27\x7f\x95\x24\x1c\xd6\x09\x64\x72\x58\x96\xc0\xf7\xe0\x5b\x9e\xa0\xa4\x66\x59\x4f\xc9\x70\x78\xdd\x84\x11\x1e\x31\xd5\x54\x54\x31\x92\x69\xaa\x48\x9c\x32\xac\x5c\x7f\x6b\xce\x33\xab\xcb\x67\x24\xb5\x9b\xc7\x75\xac\xdc\x60\x5a\x91\x5f\x1f\x33\xd6\x03\x40\x85\x5f\xad\xf8\x9a\x29\x38\xc7\x32\x44\x46\x93\xeb\x95\x25\x48\x05\xd1\x0e\xe1\xad\x86\x5d\xe2\xbf\xd0\x00\x26\x35\xac\x28\x41\x75\x7a\x67\x21\x87\x34\x0d\x87\x58\x15\x26\x13\x3c\xbe\xbf\xf9\x67\xb9\x41\xec\x49\x20\x59\xdd\x35\x1c\xd9\x52\x3d\x5b\x34\x52\xb7\x39\xbf\x6a\x0c\xde\xcd\xdd\xaa\x1c\x43\x46\x49\xa2\xee\xaf\xf1\x3c\x8e\x45\xf6\xbe\xdf\x22\x56\xf9\xd2\x44\x2a\x9c\x84\x44\xd6\xf0\xad\x24\x9d\x05\x56\xe5\xa9\x1c\x90\x84\x92\x5f\x66\xd4\x0a\x43\xc9\x47\x22\x5c\x16\xc4\x35\xea\x3b\x62\x92\x18\x53\x56\xb0\x2c\x2a\xd7\x9d\xe5\x73\x39\x0d\x55\xcc\x34\x9b\x46\xe7\xd1\xeb\x7f\x9e\x5c\xfe\xe7\xfd\x29\xd2\x20\xf4\xfe\xea\x6f\xe7\xff\x38\x41\x9e\x1f\x04\xff\xda\x3f\x09\x82\xd7\x97\xaf\xd1\xbf\xdf\x5c\xbe\x3d\x47\xbb\xcd\x16\xba\x84\xc4\x2a\xa9\x36\x11\x66\x41\x70\xfa\x0e\x8a\xc7\x50\xa9\xb4\x1d\x04\x93\xc9\xa4\x39\xd9\x6f\x72\x31\x08\x2e\x2f\x82\x1b\xcd\x6b\x57\x13\x67\x8f\xbe\x2a\x51\x36\x23\x15\x79\xc7\x8d\x8e\x99\xf0\x26\x66\x89\xec\xd6\xb0\xd9\x3d\x3c\x3c\xb4\xd4\xde\x6a\x48\x52\xdd\x32\xd2\xf5\xfa\x3c\x51\x7e\x1f\xc7\x94\xdd\xb6\xd1\x93\x37\x84\x8d\x89\xa2\x21\x46\xef\xc8\x88\x3c\x79\x86\x72\xc0\x33\xf4\x4a\x50\xcc\x9e\x21\x09\x92\xf9\x90\x72\x69\xff\x08\xf5\xf8\x8d\x2f\xe9\x77\xa8\xe9\x6d\x78\x16\x11\x11\x3e\x80\x8e\x90\x61\x0a\x2f\x48\x1b\xed\x3e\x4f\x01\x10\x63\x31\xa0\x49\x1b\xb5\x8e\x8c\x26\x04\x47\xf0\x27\x26\x0a\x23\x9d\xb9\xbb\xde\x98\x92\x49\x0a\x25\xc7\x43\x21\x90\x42\x24\x74\xbd\x09\x8d\xd4\xb0\x1b\x91\x31\x0d\x89\x6f\x06\x1e\x0a\xa6\x54\x5a\x35\x9f\x7c\x1b\xd1\x71\xd7\x3b\xb1\x14\xfe\xe5\x6d\x4a\x4a\xf4\xda\xbb\x03\xad\xea\x11\x82\xec\x22\x24\x51\xdd\xab\xcb\x33\xff\xa5\xe5\x62\x92\xe6\xf1\x22\xaf\xe8\x04\x16\xa7\xd1\xe8\x04\x56\xe0\x46\xa7\xc7\xa3\x5b\x44\x81\x44\x86\x3c\x05\xb1\x3d\x33\x50\xb7\xfa\x39\xb3\xb6\x0c\x87\xe0\x3a\xc6\xda\xa7\xda\x85\xde\x4e\x93\xd9\x5a\xed\xed\x4f\x48\xef\x9a\xc2\x44\xe6\x45\xcc\xb9\x1a\x1a\x22\x9c\x28\x60\x4a\xb1\x24\x51\x81\xa4\x2d\x65\xa8\x7d\x1c\x7d\x1d\x49\xd5\x46\x09\x4f\xc8\x11\x32\x46\x07\x8e\xad\xd6\x5f\xd0\x23\x1a\xeb\xf5\x01\xfa\x23\x34\x24\x74\x30\x54\xf6\xc5\x11\x82\xca\x43\xfc\x1c\xd4\x3c\x20\x31\xc8\x09\xb5\x64\x00\xfb\xa9\x24\xf2\x43\xce\xb8\x68\xa3\xc7\xfd\x03\xfd\x5b\xf6\x04\x94\xe2\x28\x32\x52\x81\x57\xa0\xde\xc0\x60\x76\xbd\x0c\xd3\xd3\xf6\x56\xb8\xc7\xc8\x7a\x2d\x57\x52\x7a\x45\x3d\x6a\x65\x47\xa8\xa3\xc4\x03\xc6\x18\x42\x5a\x82\x68\xbd\x12\xc0\x39\x42\x33\x61\x3e\xb8\xd8\x00\x24\x51\x3c\x75\x0d\x35\x36\x2f\x20\x36\x79\xea\x1d\x43\x80\x45\x85\xa0\x36\xdc\xbd\x83\x56\x6b\xcd\xa1\x52\x2b\x74\x44\x25\x64\x05\x98\xb6\xc7\x78\x78\xed\x78\x7f\x8c\x6f\xfc\xcc\x49\x40\xd8\xf4\xc6\x79\x19\x32\x82\x85\x9e\x10\x8e\x19\x65\xf8\xbc\x50\xca\x8d\x83\xf0\x48\xf1\x99\x90\x70\xac\x65\x0c\x05\xa6\x8a\xe8\x78\xdd\x6e\xe5\xea\x3b\x6b\x9c\xc5\x4a\x4c\xe5\xd6\x8b\x6c\x82\x39\x5b\x67\x6d\x09\x48\xd6\x84\xb1\x0c\xbb\xeb\xb5\xec\x58\xa6\x38\x9c\x8e\xd7\xaa\x68\xf6\x52\xe0\x88\x8e\x64\x1b\xed\x1b\x58\x4d\x02\xe8\xf7\x9d\x2c\x66\xc9\x80\x09\xb8\x82\xe4\x8c\x46\xe8\x31\x39\xd4\xbf\x6e\x62\xe8\xf7\x4b\xb6\xd8\x84\xec\x50\x48\xb2\xbe\x2c\x71\x30\x37\xe0\x1c\xeb\x1a\x92\x49\x56\x52\x5e\xb4\xc0\xc8\xa6\x44\x65\xf8\x21\x94\x77\x22\xea\xd6\xcb\xfc\x6b\x99\x45\xa9\xae\xdb\xe9\xc1\x8b\xbd\xbd\x93\xfa\x02\xb4\xa7\xfd\xda\x43\x59\xbc\xd9\x09\xca\xab\x67\x69\xeb\x23\x72\xfa\x53\x5c\x73\xe4\xf7\x1b\xc8\x6c\x5d\x67\x6e\x2a\x2c\xce\x0e\xda\x05\x04\x99\x6f\x3d\x41\x67\x81\x8a\xa3\xf8\x9c\xab\x10\xbd\x03\x45\xa8\x3a\x6f\x76\x30\xef\x3a\xc7\xf2\x0a\x5a\xb6\xc9\x75\x16\x3f\xcf\xc1\xf9\x58\x6c\xdd\x74\x95\x62\x56\x38\xcf\xae\x75\x9e\x45\xbe\xb1\xf1\xb9\x6f\xae\xd9\x37\xcb\x09\x36\xdd\x15\x20\xf7\x4c\x73\xc9\x22\x77\xc8\xd4\x80\x63\x8c\x20\xfd\xae\xb7\xca\x79\x77\xcd\xfe\x30\x4d\x9a\x67\x67\x67\x59\xf2\x8d\x48\xc8\x85\xb9\x1c\x9c\x1e\x0f\x9c\x8d\xff\x9e\xde\xf6\x3b\x79\xbb\xc7\x59\x54\x9f\xb8\xc3\x91\x90\x9a\x7b\xca\xa9\x05\xe4\x1b\x0a\x9a\x18\xa6\xd9\xbe\x62\x26\xc1\xbf\xd0\x82\x19\x7e\xe6\x74\x0c\x09\x33\x06\x9e\x38\xa5\x0a\xf8\x7f\x27\xb5\x49\x7f\xff\xf9\x4b\x12\xe1\x9a\x7a\x5d\xc1\xc8\xc0\xc6\xca\x6d\x5b\xc8\x73\x60\xbe\x7b\x83\xf2\x62\x97\xf7\xf8\x23\x1c\x58\x41\x5c\xb4\xf4\xfe\xa7\x13\xe0\x5a\x1f\x9e\x49\xbc\xf5\xe9\xd7\xfe\xd4\x16\x90\xec\x2e\x7d\x07\x3c\xae\xa6\x28\x6c\x43\xf6\xff\x13\xb2\x52\x09\x9e\x0c\x1e\xce\xb4\x9f\xe6\x37\x53\x3e\x23\x0b\xe8\x04\x56\xc8\x5f\xe0\x75\x35\x1b\x86\xec\xcd\xb4\x63\xe0\x48\xb2\xf5\xc3\xdf\xc6\x0f\xed\xd6\x34\x77\xb5\x4e\xef\xe1\x96\x59\x5f\xe7\xd5\xd9\x68\x49\xab\x6c\x7e\x3f\xeb\x81\x95\x99\x1f\x77\x99\x56\x4e\x2d\x28\x5a\x76\xb6\x12\x3c\xb8\x67\x94\x24\xda\x14\xf7\x58\x6a\xd1\xa5\xfd\xcf\x3f\xa4\xb3\xdc\x2f\xd9\xaf\xb8\xfb\xb8\x20\xb0\x45\x1a\x93\x68\xce\xfe\x63\xbb\x69\xd9\xa0\x62\xb1\x81\xc9\xb9\x33\xdc\x40\x99\x36\xce\x4e\x77\x89\xe0\x45\x1b\xb6\x6d\x60\xfd\x39\x77\x61\xe5\xd3\xc0\x34\x21\x97\xce\x03\x53\xd0\x03\x9c\x08\x72\x69\xb6\xde\xf8\xdb\x78\xe3\xf6\x4c\xb0\x3d\x13\x6c\xcf\x04\x9b\xed\x2c\xbf\x22\xdd\x03\xb6\xee\x66\x1c\xdf\xa1\x91\x94\x93\x14\x90\xb5\xf7\xb1\x9d\x0f\x3b\x4a\x7d\xfa\xe2\xb2\xfb\xf0\xf0\x70\x51\x7b\xd0\xed\x8b\x55\x1b\x3a\x9b\x51\xd3\x36\xa9\xba\xae\xb3\xb2\xee\x2d\x6d\x2d\x9b\xe5\xad\xeb\x47\x2c\x29\xbd\x33\x5d\x61\xf7\x1b\x96\x52\x0f\x67\xe6\x83\xfb\xf5\x39\xc4\x5e\xb9\x63\x53\x68\x59\xee\xd8\x8c\x12\xa0\xd4\xbd\x15\x77\xbd\x3e\x80\x4e\xa8\x77\xbb\x5a\x17\xa3\x9a\x3b\x2a\xdd\xe2\xd9\xcc\xd0\x09\x20\xcc\x8f\xed\xff\x0d\x37\x4d\x6c\xda\xae\x6b\xce\xc7\x49\x56\xc5\x22\x7f\x75\x02\xfd\x0d\xa0\x86\xe8\x4f\x0b\x8f\x1b\xc5\x37\xef\x8d\xff\x05\x00\x00\xff\xff\xcc\xc4\x43\x99\xc7\x31\x00\x00")
+var _templateDefaultTmpl = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xec\x5b\x7b\x6f\xdb\x38\x12\xff\x5f\x9f\x62\x56\xc5\x62\x1b\xa0\x96\x94\x74\x1b\x6c\xfc\x3a\xf4\xd2\xe4\xf6\x80\xb4\x57\xa4\xc9\xde\x1d\x16\x8b\x82\x96\x46\x36\x1b\x8a\xd4\x92\x94\xed\xd4\xf5\x77\x3f\x50\x2f\x4b\xb6\xec\x78\x73\xb7\x8e\xaf\x75\x8c\x16\xe6\x88\x33\x9c\xc7\x6f\x86\x2f\x79\x36\x83\x00\x43\xca\x11\xec\x8f\x1f\x09\x43\xa9\x23\xc2\xc9\x10\xa5\x0d\xf3\xf9\x6b\xd3\x7e\x9b\xb5\x67\x33\x40\x1e\xc0\x7c\x6e\xad\x65\xb9\xbd\xbe\x32\x5c\xb3\x19\x38\x17\x53\x8d\x92\x13\x76\x7b\x7d\x05\xf3\xb9\xfb\xcc\x4d\x45\xab\xbf\x48\xf4\x91\x8e\x51\xf6\x4c\xa7\xeb\xbc\x91\xf1\xe4\xd2\xeb\xe2\x55\x32\xf8\x84\xbe\x36\x62\x7f\x35\x2c\x1f\x34\xd1\x89\x82\x2f\xa0\xc5\x6d\x1c\x17\xac\x34\x04\xfc\xbd\x7c\x68\x87\x54\x52\x3e\x34\x3c\x6d\xc3\xf3\x2e\x89\x2e\x53\x4a\x75\x9c\xdf\xc0\x3c\xfa\x9b\x14\x49\x7c\x45\x06\xc8\x94\xf3\x41\x48\x8d\xc1\x7b\x42\xa5\x72\x7e\x21\x2c\x41\x33\xcc\x27\x41\x39\xd8\x60\x64\x19\x06\x1a\xc2\x50\xc3\x73\x86\x1c\x9c\x73\x11\x45\x82\x67\xcc\x47\x39\xad\x22\xef\x08\xe6\xf3\xe7\xb3\x19\x4c\xa8\x1e\xd5\x3b\x3b\xd7\x18\x89\x31\xd6\x47\x7f\x47\x22\x54\xb9\xf3\x9a\x46\x2f\x15\x3f\x2a\xbf\xad\x89\x48\x80\xca\x97\x34\xd6\x54\x70\x7b\x7d\x2f\x2d\x13\xee\x13\x8d\x41\xde\x27\xb3\xcc\xb9\x29\xc8\x69\xe8\x15\x78\xb9\x4a\xcb\xf4\xf9\x1c\x22\x21\x11\xb2\xb0\x82\x88\xa8\xd6\x18\x94\x63\x2d\x06\x5d\x1a\x15\xa7\x3a\x83\xcc\x47\x46\x95\xce\xc7\x96\x84\x0f\x11\x1c\x98\xcf\x33\x07\xb5\xad\x05\x71\x35\x38\x26\x14\x2d\x13\x8c\xd4\x67\xa6\xd5\x83\xd2\x6b\xb9\x9d\x59\x8c\x5f\x73\x2e\x34\x31\x8e\xa8\x89\xac\x90\x1f\x21\xb7\x3a\x40\xf9\xc5\xaa\xd9\xa9\x18\xf1\xef\x9c\x00\x43\x92\x30\xed\x68\xaa\x19\xe6\x96\x6a\x8c\x62\x46\x74\x1d\xda\x4e\x4d\xd2\x5a\x39\x89\x32\x19\x15\x35\x89\xaa\xe7\xed\x96\xf2\x42\xc2\xd8\x80\xf8\x77\x2b\xf2\x1a\xd5\x37\x42\xe1\x0b\x3c\xd4\x91\x51\x7e\xb7\xb5\x06\xb1\x44\x8d\x53\x6d\x6f\xd7\xbb\x22\x7f\xa3\x03\xd2\x2a\xb4\xa5\x06\x95\xe1\xab\xe2\x2a\xc9\xe1\xac\x0f\xf3\x88\xc6\xfe\x88\xe8\x52\x58\x28\x45\xf4\x80\x6e\x1b\x14\x5b\x96\x16\xa1\x52\x64\xb8\x0d\x70\x36\xa4\x2e\x3c\xdf\x6c\xd9\xd1\x43\x50\x8e\x0d\xa4\x82\x44\xdf\x97\x7a\xad\x96\x97\x1d\xea\xb6\x51\x33\x9f\x51\xe4\xfa\xf1\x11\x58\x27\x71\x31\xad\x3d\x0e\x72\xab\x72\x29\x57\x9a\x70\x1f\x55\x83\xdc\x95\x12\xe9\xac\x8f\x8e\x88\xd5\x10\x39\xc5\xbd\x0b\xce\x8a\x62\x4a\x24\xd2\xc7\x3f\xee\xc6\x9a\xb9\x18\x11\xca\x16\x22\x0b\x6b\x1e\xb4\x73\x55\xbf\xba\xa4\x91\x8e\x98\x11\x63\x75\xbf\x7b\xf3\x8f\xf3\x9b\x7f\xbf\xbf\x00\x43\x82\xf7\xb7\x7f\xbd\xfa\xfb\x39\xd8\x2d\xd7\xfd\xe7\xcb\x73\xd7\x7d\x73\xf3\x06\xfe\xf5\xf3\xcd\xdb\x2b\x38\x76\x3c\xb8\x91\x84\x2b\x6a\x5c\x4d\x98\xeb\x5e\xbc\xb3\xc1\x1e\x69\x1d\xb7\x5d\x77\x32\x99\x38\x93\x97\x8e\x90\x43\xf7\xe6\xda\x9d\x1a\x59\xc7\x86\x39\xff\xda\xd2\x15\x4e\x27\xd0\x81\xdd\xb7\xba\xe6\x09\x4c\x23\xc6\x55\xaf\x41\xcc\xf1\xd9\xd9\x59\xc6\x6d\x6f\xd7\x49\xe9\x7b\x86\x3d\x3b\x14\x5c\xb7\x42\x12\x51\x76\xdf\x86\x1f\x7e\x46\x36\x46\x4d\x7d\x02\xef\x30\xc1\x1f\x5e\x40\x49\x78\x01\xaf\x25\x25\xec\x05\x28\xc2\x55\x4b\xa1\xa4\x61\x07\x06\x62\xda\x52\xf4\x33\xe5\xc3\x36\x0c\x84\x0c\x50\xb6\x06\x62\xda\x81\x54\xa8\xa2\x9f\xb1\x0d\xc7\x3f\xc6\xd3\x0e\x44\x44\x0e\x29\x6f\x83\xd7\x49\x2d\x41\x12\xf4\xad\x6e\x84\x9a\x80\x99\xaf\x7a\xf6\x98\xe2\x24\x16\x52\xdb\xe0\x0b\xae\x91\xeb\x9e\x3d\xa1\x81\x1e\xf5\x02\x1c\x53\x1f\x5b\x69\xc3\x06\xb7\xe0\x32\xa6\xb5\xf0\xf7\x84\x8e\x7b\xf6\x79\xc6\xd1\xba\xb9\x8f\xb1\xc2\x6f\xb2\xc4\x35\xa6\x76\xc0\x1f\x11\xa9\x50\xf7\x6e\x6f\x2e\x5b\x3f\x65\x52\xd2\xa9\xa2\xbf\x09\x15\x5d\x37\xeb\x63\x59\x5d\x37\x53\xd8\xea\x0e\x44\x70\x0f\x54\x63\xa4\x7c\x11\x63\xcf\xb6\xd3\x86\xbe\x8f\xb1\xf4\xb6\xf2\x47\x18\x91\x34\x24\x17\x06\x42\x6f\x8b\x22\xbd\x53\x7f\xb7\x26\x38\xb8\xa3\xba\x95\x3d\x88\x84\xd0\xa3\x34\x48\x84\x6b\x4a\x18\x25\x0a\x83\x45\x27\xe3\xa9\x94\xbb\x45\x82\x4f\x89\xd2\x6d\xe0\x82\x63\x07\x52\xa7\xb7\xe1\xd8\xf3\xbe\x87\xef\x68\x64\xe2\x43\xb8\xee\xc0\x08\xe9\x70\xa4\xb3\x07\x1d\x60\x94\x63\xab\x24\x39\xa7\x18\x75\xc0\x2c\x1a\x86\x52\x24\x3c\x68\xf9\x82\x09\xd9\x86\x67\xe1\xa9\xf9\x54\x91\x00\x31\x09\x82\x54\x2b\xaf\x63\xc3\x60\x98\xf6\xec\xd9\x79\x4f\xbb\x6f\x59\x5d\x4d\x06\x0c\x77\xeb\xb9\x8a\xd1\x5b\xda\xd1\xa8\x3b\x40\x57\xcb\xdd\x6a\x5e\xd1\xa8\x6f\x01\x18\x0d\x82\xdd\x6a\x30\x46\x69\x84\xb0\x16\x61\x74\xc8\xdb\xa0\x45\x5c\x53\x0b\xc6\xe9\x83\x9e\xad\x45\x6c\xf7\xbb\xae\x0e\x16\x8a\xa6\x7e\xef\xd9\xa7\x9e\x67\xef\x81\xd2\x01\x55\x31\x23\xf7\x6d\x18\x30\xe1\xdf\xd5\xd0\x1f\x91\x69\x2b\x07\xc9\xa9\xe7\xc5\xd3\xda\x43\x9f\x21\x91\x66\x40\x3d\xaa\xd1\x2b\xa8\xaa\xd1\x4b\xe7\x00\x49\xb4\x58\x4a\x89\x9a\xb7\x52\x47\x01\x74\x03\x3a\xde\xad\x7f\x96\xed\x5d\x76\xce\x66\x23\x0a\xbd\x4d\x90\xd3\x64\xce\xe3\x6c\x6a\x87\x0d\x3e\x32\x96\xf7\xee\xd9\x5e\xd6\x56\x31\xf1\x8b\xf6\x4e\x0d\xcd\x1f\x4a\x12\xd0\x44\xb5\xe1\x65\x3c\x6d\x2e\x00\x61\x58\x31\xb9\x60\x6b\xc3\x71\x3c\x05\x25\x18\x0d\xe0\x19\x9e\x99\x4f\xbd\xa8\x85\x61\xc5\x17\xfb\x50\x1d\x8a\xbf\x5d\x56\x89\xd3\xb5\x09\x57\xf3\x6e\xca\x32\xc9\xa7\x94\x57\x9e\xd7\x81\x74\x8a\xca\xfb\xfb\xc8\x35\xca\xa6\x78\xa5\xff\x3c\xf0\x1a\xe3\x76\x71\xfa\xea\xe4\xe4\xbc\xea\x88\x05\x50\x4f\xbc\x78\xda\xb1\x21\xcf\xb7\x6c\x80\x6a\xf4\x32\xde\xe6\x8c\x2c\xfe\xcc\x41\x40\xbe\x3c\xfe\x02\xe6\xa8\x67\x3e\xcf\xce\x3e\xca\x65\xf4\x73\x43\xcd\xfb\x1c\xc1\x31\xcc\xe7\xaa\x5c\x7a\x42\x28\x24\x2c\x0e\x20\xaa\xe7\x3e\xf5\x03\x88\xa5\x51\xa1\x7a\x1c\xd1\xab\x1d\x46\xac\x74\xcb\x17\xb9\x05\xc5\x7c\x16\x35\xb8\x6c\xcb\x5a\xfb\x9b\x84\xe9\x36\x93\xd9\x02\x3c\xc7\x19\x78\x36\x61\x63\xef\x6b\xdf\x5a\xb7\xef\x17\x08\xf6\x1d\x0a\x1e\x78\x70\xf2\x30\x1c\x72\x33\x08\x8c\x24\x86\x3d\x7b\x9b\xfd\xee\x8e\xf1\x50\x14\xcd\xcb\xcb\xcb\xbc\xf8\x06\xe8\x0b\x99\x9e\x94\x16\xdb\x83\xda\xc2\xff\x04\xa3\xa5\xba\x3d\x10\x2c\x68\x2e\xdc\x7e\x22\x95\x29\xc9\xb1\xa0\x19\xa1\x5c\x50\x50\x9e\x0a\xcd\xd7\x15\x4b\x05\xfe\x95\xc9\xca\x54\x5e\xba\x3b\x0e\x85\x8c\xda\xe0\x93\x98\x6a\xc2\xe8\x67\x6c\x2c\xfa\x2f\x7f\xfc\x09\x03\x52\x0b\x56\x2e\x75\xb9\x47\x4e\x4e\xbd\xdc\xce\x26\xf2\x92\x58\xae\xde\xe2\x69\x1e\xde\xfe\x2f\x14\x27\x40\x39\x6c\x88\x5d\xb1\x8d\x24\x8d\x18\x5e\x2a\xbc\xcd\xe5\xb7\x2c\xdd\xab\x13\x88\x93\x5d\x58\x1c\xa5\x07\x65\xab\x82\x0e\x29\xfb\xa7\xa4\xac\xd2\x52\xf0\xe1\xd3\xb9\xf6\xd7\xd9\x6c\x09\x00\xe5\x62\xe3\x37\xc8\x08\x5d\x37\x53\xf2\x7f\x80\xba\x86\x05\x43\xfe\xa4\xb8\x27\xa9\x69\x72\xc0\xe1\x37\x83\xc3\xec\xce\xad\x84\x5a\x77\xf0\x74\x61\x36\xc7\x79\x85\x5f\x9a\x51\xda\xb8\x8e\x5e\x7f\x8b\xf7\xc4\xc6\xac\xcf\xbb\xa6\xb9\x60\x71\x51\x99\xcd\x04\x4f\x8e\x8c\x8a\x46\xfb\x02\x8f\x07\x3d\x5a\x54\xb3\x85\xea\x5f\x03\x58\x1e\x57\xec\xad\x87\x10\x97\x95\xfc\x6b\x54\x82\x8d\x31\x58\xb3\xfe\x38\x2c\x5a\xf6\x68\xd1\xf2\xb4\x10\x6d\xd6\x69\xb4\x87\x3a\xed\x9d\x9f\xfe\x48\x06\x6f\x5a\xb0\x1d\x56\x61\x5f\xe7\x2a\xac\xba\x1b\x28\x0a\x72\x65\x3f\x50\x90\xca\x79\xf8\xbf\x84\xd8\x7a\x80\x55\xe6\xd0\x25\x6d\x0e\x7b\x82\xc3\x9e\xe0\xb0\x27\x38\xec\x09\x0e\x7b\x82\xff\xe3\x3d\x41\x41\x59\x01\x5c\xe3\x8b\x61\xab\xe2\x0f\x05\xff\xab\x2c\xf8\x9b\xdf\xff\x2b\x33\xfd\x4f\x82\x61\xd7\x4d\x2f\xd5\xfa\xd6\x26\xc1\x75\x91\x25\xcb\x82\xb2\xf3\xd7\x29\xca\xbb\x04\xef\xfb\xda\xeb\x22\x8b\x3b\x97\xb3\xb3\xb3\x66\x74\xe4\x57\x0f\xd6\xe6\x7b\xc5\x27\x83\x83\xb5\xaf\x39\xbf\xcb\x7c\x3f\x59\x9b\xef\xb5\xf0\x36\x5d\x8b\x35\x85\xbc\x52\x10\x96\x5e\x4e\xa8\xd5\x87\xea\x55\xe2\xd2\x0f\x6b\x76\x07\x88\x93\x78\xda\x64\x65\xf5\xe2\x30\xe1\x01\x4a\x73\xc5\x57\x8f\xd7\x07\xe4\x1a\x06\xf7\xdb\x5d\xa6\xad\xd6\x8e\xe5\xba\xb1\x52\x19\xba\x6e\x40\xc7\xfd\xec\x7f\xab\x5e\x26\xf6\x6d\x2e\x58\x0e\x6c\xae\x68\x66\xe2\xa2\x7e\x75\x5d\xf3\x2a\xaa\xa1\x98\x37\x5c\xfb\xd6\xe2\x07\x27\xd6\x7f\x06\x00\x73\x73\x9b\x73\xaf\x35\x00\x00")
 
 func templateDefaultTmplBytes() ([]byte, error) {
 	return bindataRead(
@@ -83,7 +83,7 @@ func templateDefaultTmpl() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "template/default.tmpl", size: 12743, mode: os.FileMode(420), modTime: time.Unix(1452020083, 0)}
+	info := bindataFileInfo{name: "template/default.tmpl", size: 13743, mode: os.FileMode(420), modTime: time.Unix(1452020083, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -60,23 +60,49 @@ func FromGlobs(paths ...string) (*Template, error) {
 		return nil, err
 	}
 
+	if err := t.parseGlobs(paths); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// WithGlobs returns a copy of t with the templates of all path globs
+// provided added to it. Templates of the same name replace those of t.
+func (t *Template) WithGlobs(paths ...string) (*Template, error) {
+	text, err := t.text.Clone()
+	if err != nil {
+		return nil, err
+	}
+	html, err := t.html.Clone()
+	if err != nil {
+		return nil, err
+	}
+	nt := &Template{text: text, html: html, ExternalURL: t.ExternalURL}
+	if err := nt.parseGlobs(paths); err != nil {
+		return nil, err
+	}
+	return nt, nil
+}
+
+// parseGlobs calls ParseGlob on all path globs provided.
+func (t *Template) parseGlobs(paths []string) error {
 	for _, tp := range paths {
 		// ParseGlob in the template packages errors if not at least one file is
 		// matched. We want to allow empty matches that may be populated later on.
 		p, err := filepath.Glob(tp)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if len(p) > 0 {
 			if t.text, err = t.text.ParseGlob(tp); err != nil {
-				return nil, err
+				return err
 			}
 			if t.html, err = t.html.ParseGlob(tp); err != nil {
-				return nil, err
+				return err
 			}
 		}
 	}
-	return t, nil
+	return nil
 }
 
 // ExecuteTextString needs a meaningful doc comment (TODO(fabxc)).
@@ -94,6 +120,29 @@ func (t *Template) ExecuteTextString(text string, data interface{}) (string, err
 	return buf.String(), err
 }
 
+// ExecuteTextTemplate executes the parsed text template tt with the
+// templates and functions of t available to it.
+func (t *Template) ExecuteTextTemplate(tt *tmpltext.Template, data interface{}) (string, error) {
+	tmpl, err := t.text.Clone()
+	if err != nil {
+		return "", err
+	}
+	for _, at := range tt.Templates() {
+		if at.Name() == tt.Name() {
+			continue
+		}
+		if _, err := tmpl.AddParseTree(at.Name(), at.Tree); err != nil {
+			return "", err
+		}
+	}
+	if tmpl, err = tmpl.AddParseTree("", tt.Tree); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	return buf.String(), err
+}
+
 // ExecuteHTMLString needs a meaningful doc comment (TODO(fabxc)).
 func (t *Template) ExecuteHTMLString(html string, data interface{}) (string, error) {
 	tmpl, err := t.html.Clone()
@@ -208,6 +257,12 @@ type Data struct {
 	Receiver string
 	Status   string
 	Alerts   Alerts
+	// TruncatedAlerts is the number of alerts of the group that are not
+	// part of Alerts.
+	TruncatedAlerts int
+	// NumFiring is the number of firing alerts of the group, including
+	// the truncated ones.
+	NumFiring int
 
 	GroupLabels       KV
 	CommonLabels      KV
@@ -277,6 +332,9 @@ func (t *Template) Data(recv string, groupLabels model.LabelSet, alerts ...*type
 			alert.Annotations[string(k)] = string(v)
 		}
 		data.Alerts = append(data.Alerts, alert)
+		if alert.Status == string(model.AlertFiring) {
+			data.NumFiring++
+		}
 	}
 
 	for k, v := range groupLabels {
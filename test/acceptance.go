@@ -15,14 +15,15 @@ package test
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"sync"
-	"syscall"
 	"testing"
 	"time"
 
@@ -44,10 +45,41 @@ type AcceptanceTest struct {
 	actions map[float64][]func()
 }
 
+const (
+	// DefaultReadyTimeout is the time Alertmanager instances are given to
+	// become ready if AcceptanceOpts do not specify it.
+	DefaultReadyTimeout = 10 * time.Second
+	// DefaultBinaryPath is the path of the Alertmanager binary relative to
+	// the directory of the acceptance tests.
+	DefaultBinaryPath = "../../alertmanager"
+	// BinaryPathEnv is the environment variable that overrides the path of
+	// the Alertmanager binary.
+	BinaryPathEnv = "AM_BINARY"
+)
+
 // AcceptanceOpts defines configuration paramters for an acceptance test.
 type AcceptanceOpts struct {
 	Tolerance time.Duration
-	baseTime  time.Time
+	// ReadyTimeout is the maximum time to wait for a started Alertmanager
+	// to serve its API. DefaultReadyTimeout is used if it is zero.
+	ReadyTimeout time.Duration
+	// BinaryPath is the path of the Alertmanager binary to test. The
+	// BinaryPathEnv environment variable takes precedence over it, and
+	// DefaultBinaryPath is used if neither is set.
+	BinaryPath string
+
+	baseTime time.Time
+}
+
+// binaryPath returns the path of the Alertmanager binary to test.
+func (opts *AcceptanceOpts) binaryPath() string {
+	if p := os.Getenv(BinaryPathEnv); p != "" {
+		return p
+	}
+	if opts.BinaryPath != "" {
+		return opts.BinaryPath
+	}
+	return DefaultBinaryPath
 }
 
 func (opts *AcceptanceOpts) alertString(a *model.Alert) string {
@@ -57,6 +89,14 @@ func (opts *AcceptanceOpts) alertString(a *model.Alert) string {
 	return fmt.Sprintf("%s[%v:%v]", a, opts.relativeTime(a.StartsAt), opts.relativeTime(a.EndsAt))
 }
 
+// timeString returns the relative time of t, or "unset" if it is zero.
+func (opts *AcceptanceOpts) timeString(t time.Time) string {
+	if t.IsZero() {
+		return "unset"
+	}
+	return fmt.Sprint(opts.relativeTime(t))
+}
+
 // expandTime returns the absolute time for the relative time
 // calculated from the test's base time.
 func (opts *AcceptanceOpts) expandTime(rel float64) time.Time {
@@ -108,6 +148,12 @@ func (t *AcceptanceTest) Alertmanager(conf string) *Alertmanager {
 		opts: t.opts,
 	}
 
+	binary := t.opts.binaryPath()
+	if fi, err := os.Stat(binary); err != nil || fi.IsDir() {
+		t.Fatalf("Alertmanager binary %q not found, build it or set its path with %s", binary, BinaryPathEnv)
+	}
+	am.binary = binary
+
 	dir, err := ioutil.TempDir("", "am_test")
 	if err != nil {
 		t.Fatal(err)
@@ -121,17 +167,7 @@ func (t *AcceptanceTest) Alertmanager(conf string) *Alertmanager {
 	am.confFile = cf
 	am.UpdateConfig(conf)
 
-	am.addr = freeAddress()
-
-	t.Logf("AM on %s", am.addr)
-
-	client, err := alertmanager.New(alertmanager.Config{
-		Address: fmt.Sprintf("http://%s", am.addr),
-	})
-	if err != nil {
-		t.Fatal(err)
-	}
-	am.client = client
+	am.setAddress(freeAddress())
 
 	t.ams = append(t.ams, am)
 
@@ -145,13 +181,23 @@ func (t *AcceptanceTest) Collector(name string) *Collector {
 		name:      name,
 		opts:      t.opts,
 		collected: map[float64][]model.Alerts{},
-		expected:  map[Interval][]model.Alerts{},
+		expected:  map[Interval][]*Expectation{},
 	}
 	t.collectors = append(t.collectors, co)
 
 	return co
 }
 
+// FlakyReceiver returns a new webhook receiver whose responses can be
+// scripted. Its notifications are recorded by a new collector with the
+// given name.
+func (t *AcceptanceTest) FlakyReceiver(name string) *FlakyReceiver {
+	fr := &FlakyReceiver{MockWebhook: NewWebhook(t.Collector(name))}
+	fr.MockWebhook.status = fr.status
+
+	return fr
+}
+
 // Run starts all Alertmanagers and runs queries against them. It then checks
 // whether all expected notifications have arrived at the expected receiver.
 func (t *AcceptanceTest) Run() {
@@ -192,7 +238,7 @@ func (t *AcceptanceTest) Run() {
 
 	for _, am := range t.ams {
 		t.Logf("stdout:\n%v", am.cmd.Stdout)
-		t.Logf("stderr:\n%v", am.cmd.Stderr)
+		t.Logf("stderr:\n%v", &am.stderr)
 	}
 }
 
@@ -222,18 +268,62 @@ type Alertmanager struct {
 	t    *AcceptanceTest
 	opts *AcceptanceOpts
 
+	binary   string
 	addr     string
 	client   alertmanager.Client
 	cmd      *exec.Cmd
 	confFile *os.File
 	dir      string
+	stderr   bytes.Buffer
+
+	// exited is closed when the current process has exited.
+	exited chan struct{}
 
 	errc chan<- error
 }
 
-// Start the alertmanager and wait until it is ready to receive.
+// setAddress sets the address the Alertmanager listens on.
+func (am *Alertmanager) setAddress(addr string) {
+	am.addr = addr
+	am.t.Logf("AM on %s", am.addr)
+
+	client, err := alertmanager.New(alertmanager.Config{
+		Address: fmt.Sprintf("http://%s", am.addr),
+	})
+	if err != nil {
+		am.t.Fatal(err)
+	}
+	am.client = client
+}
+
+const maxStartAttempts = 5
+
+// errAddrInUse is returned if the Alertmanager exited on start because
+// its address was already in use.
+var errAddrInUse = errors.New("address already in use")
+
+// Start the alertmanager and wait until it is ready to receive. If its
+// address was taken in the meantime, it is restarted on a new one.
 func (am *Alertmanager) Start() {
-	cmd := exec.Command("../../alertmanager",
+	for i := 1; ; i++ {
+		err := am.start()
+		if err == nil {
+			return
+		}
+		if err != errAddrInUse {
+			am.t.Fatalf("Starting alertmanager on %s failed: %s", am.addr, err)
+		}
+		if i == maxStartAttempts {
+			am.t.Fatalf("Starting alertmanager failed: address in use in all of %d attempts", maxStartAttempts)
+		}
+		am.t.Logf("Address %s of alertmanager already in use, retrying", am.addr)
+		am.setAddress(freeAddress())
+	}
+}
+
+// start starts the Alertmanager process and waits until it is ready.
+func (am *Alertmanager) start() error {
+	cmd := exec.Command(am.binary,
 		"-config.file", am.confFile.Name(),
 		"-log.level", "debug",
 		"-web.listen-address", am.addr,
@@ -241,37 +331,110 @@ func (am *Alertmanager) Start() {
 	)
 
 	if am.cmd == nil {
-		var outb, errb bytes.Buffer
-		cmd.Stdout = &outb
-		cmd.Stderr = &errb
+		cmd.Stdout = &bytes.Buffer{}
 	} else {
 		cmd.Stdout = am.cmd.Stdout
-		cmd.Stderr = am.cmd.Stderr
 	}
+	cmd.Stderr = &am.stderr
 	am.cmd = cmd
 
+	// Only the output of this process is checked for startup errors.
+	offset := am.stderr.Len()
+
 	if err := am.cmd.Start(); err != nil {
-		am.t.Fatalf("Starting alertmanager failed: %s", err)
+		return err
 	}
 
+	var (
+		exited  = make(chan struct{})
+		exitErr error
+	)
+	am.exited = exited
 	go func() {
-		if err := am.cmd.Wait(); err != nil {
-			am.errc <- err
+		exitErr = cmd.Wait()
+		close(exited)
+	}()
+
+	if err := am.waitReady(exited); err != nil {
+		select {
+		case <-exited:
+			// The output is complete once the process has exited.
+			output := am.stderr.String()[offset:]
+			if strings.Contains(output, "address already in use") {
+				return errAddrInUse
+			}
+			return fmt.Errorf("exited during startup (%v), stderr:\n%s", exitErr, output)
+		default:
+			return err
+		}
+	}
+
+	// Errors of the running process are reported by Run.
+	go func() {
+		<-exited
+		if exitErr != nil {
+			am.errc <- exitErr
+		}
+	}()
+	return nil
+}
+
+// waitReady polls the API of the Alertmanager until it responds, the
+// ready timeout is exceeded, or the process exits.
+func (am *Alertmanager) waitReady(exited <-chan struct{}) error {
+	timeout := am.opts.ReadyTimeout
+	if timeout == 0 {
+		timeout = DefaultReadyTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Requests may hang if another process holds the address, stop them
+	// once the Alertmanager exited.
+	go func() {
+		select {
+		case <-exited:
+			cancel()
+		case <-ctx.Done():
 		}
 	}()
 
-	time.Sleep(50 * time.Millisecond)
+	silences := alertmanager.NewSilenceAPI(am.client)
+	for {
+		_, err := silences.List(ctx)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("no response within %s, last error: %s", timeout, err)
+		case <-exited:
+			return fmt.Errorf("process exited")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
 }
 
 // Terminate kills the underlying Alertmanager process and remove intermediate
 // data.
 func (am *Alertmanager) Terminate() {
-	syscall.Kill(am.cmd.Process.Pid, syscall.SIGTERM)
+	// The process may have exited already, which is reported by Run.
+	procSignaler.terminate(am.cmd.Process)
+
+	// Wait for the process to release its address, so that it can be
+	// started again right away.
+	select {
+	case <-am.exited:
+	case <-time.After(5 * time.Second):
+		am.t.Errorf("Alertmanager on %s did not exit after termination", am.addr)
+	}
 }
 
 // Reload sends the reloading signal to the Alertmanager process.
 func (am *Alertmanager) Reload() {
-	syscall.Kill(am.cmd.Process.Pid, syscall.SIGHUP)
+	if err := procSignaler.reload(am.cmd.Process); err != nil {
+		am.t.Errorf("Reloading alertmanager failed: %s", err)
+	}
 }
 
 func (am *Alertmanager) cleanup() {
@@ -15,6 +15,7 @@ package test
 
 import (
 	"fmt"
+	"net/http"
 	"sync"
 	"testing"
 	"time"
@@ -100,6 +101,47 @@ receivers:
 	at.Run()
 }
 
+func TestUpdatedAnnotations(t *testing.T) {
+	t.Parallel()
+
+	conf := `
+route:
+  receiver: "default"
+  group_by: []
+  group_wait:      1s
+  group_interval:  1s
+  repeat_interval: 1s
+
+receivers:
+- name: "default"
+  webhook_configs:
+  - url: 'http://%s'
+`
+
+	at := NewAcceptanceTest(t, &AcceptanceOpts{
+		Tolerance: 150 * time.Millisecond,
+	})
+
+	co := at.Collector("webhook")
+	wh := NewWebhook(co)
+
+	am := at.Alertmanager(fmt.Sprintf(conf, wh.Address()))
+
+	am.Push(At(1), Alert("alertname", "test").Annotate("ann", "v1").Active(1))
+
+	co.Want(Between(2, 2.5), Alert("alertname", "test").Annotate("ann", "v1").Active(1)).
+		Label("alertname", "test").
+		Annotation("ann", "v1")
+
+	// Every notification carries the annotations of the most recent update.
+	am.Push(At(2.6), Alert("alertname", "test").Annotate("ann", "v2").Active(1))
+
+	co.Want(Between(3, 3.5), Alert("alertname", "test").Annotate("ann", "v2").Active(1)).
+		Annotation("ann", "v2")
+
+	at.Run()
+}
+
 func TestRepeat(t *testing.T) {
 	t.Parallel()
 
@@ -202,6 +244,49 @@ receivers:
 	co2.Want(Between(4.5, 5), Alert("alertname", "test1").Active(1))
 }
 
+func TestRetryBackoff(t *testing.T) {
+	t.Parallel()
+
+	// The group interval is long enough for all retries of the first
+	// notification to happen within a single flush.
+	conf := `
+route:
+  receiver: "default"
+  group_by: []
+  group_wait:      1s
+  group_interval:  10s
+  repeat_interval: 10s
+
+receivers:
+- name: "default"
+  webhook_configs:
+  - url: '%s'
+`
+
+	at := NewAcceptanceTest(t, &AcceptanceOpts{
+		Tolerance: 150 * time.Millisecond,
+	})
+
+	fr := at.FlakyReceiver("webhook_flaky")
+	fr.Respond(Between(0, 3), http.StatusServiceUnavailable)
+
+	am := at.Alertmanager(fmt.Sprintf(conf, fr.URL()))
+
+	am.Push(At(1), Alert("alertname", "test1"))
+
+	co := fr.Collector()
+
+	// The first attempt fails immediately and is retried with backoff
+	// until the receiver recovers.
+	co.WantAttempt(Between(2, 2.2), http.StatusServiceUnavailable)
+	co.WantAttempt(Between(2.2, 3), http.StatusServiceUnavailable)
+	co.WantAttempt(Between(3, 5), http.StatusOK)
+
+	co.Want(Between(3, 5), Alert("alertname", "test1").Active(1))
+
+	at.Run()
+}
+
 func TestBatching(t *testing.T) {
 	t.Parallel()
 
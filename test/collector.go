@@ -15,6 +15,9 @@ package test
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -28,28 +31,184 @@ type Collector struct {
 	name string
 	opts *AcceptanceOpts
 
+	// mtx guards collected and attempts, which are written by receivers
+	// while the test is running.
+	mtx       sync.Mutex
 	collected map[float64][]model.Alerts
-	expected  map[Interval][]model.Alerts
+	expected  map[Interval][]*Expectation
+
+	attempts     []Attempt
+	wantAttempts []expectedAttempt
+}
+
+// Attempt is a single delivery attempt of a notification to a receiver.
+type Attempt struct {
+	// At is the relative time the attempt arrived at the receiver.
+	At float64
+	// Status is the HTTP status code the receiver responded with.
+	Status int
+}
+
+func (a Attempt) String() string {
+	return fmt.Sprintf("@ %v: %d", a.At, a.Status)
+}
+
+type expectedAttempt struct {
+	iv     Interval
+	status int
 }
 
 func (c *Collector) String() string {
 	return c.name
 }
 
+// Expectation is a batch of alerts a Collector expects to receive within
+// an interval, together with further requirements on the batch.
+type Expectation struct {
+	alerts  model.Alerts
+	ordered bool
+	fields  []fieldAssertion
+}
+
+// InOrder requires the alerts of the batch to be received in the order
+// they were declared in.
+func (e *Expectation) InOrder() *Expectation {
+	e.ordered = true
+	return e
+}
+
+// Label requires the given label of all received alerts of the batch to
+// have the given value.
+func (e *Expectation) Label(name, value string) *Expectation {
+	e.fields = append(e.fields, fieldAssertion{name: model.LabelName(name), value: model.LabelValue(value)})
+	return e
+}
+
+// Annotation requires the given annotation of all received alerts of the
+// batch to have the given value.
+func (e *Expectation) Annotation(name, value string) *Expectation {
+	e.fields = append(e.fields, fieldAssertion{annotation: true, name: model.LabelName(name), value: model.LabelValue(value)})
+	return e
+}
+
+// diff returns the differences between the expectation and the received
+// batch of alerts. It is empty if the batch fulfills the expectation.
+func (e *Expectation) diff(got model.Alerts, opts *AcceptanceOpts) []string {
+	if len(got) != len(e.alerts) {
+		return []string{fmt.Sprintf("expected %d alerts, got %d", len(e.alerts), len(got))}
+	}
+
+	var diffs []string
+	for i, exp := range e.alerts {
+		if e.ordered {
+			for _, d := range diffAlert(exp, got[i], opts) {
+				diffs = append(diffs, fmt.Sprintf("alert %d: %s", i, d))
+			}
+			continue
+		}
+		var closest *model.Alert
+		for _, a := range got {
+			if equalAlerts(exp, a, opts) {
+				closest = nil
+				break
+			}
+			if reflect.DeepEqual(exp.Labels, a.Labels) {
+				closest = a
+			}
+		}
+		if closest != nil {
+			for _, d := range diffAlert(exp, closest, opts) {
+				diffs = append(diffs, fmt.Sprintf("alert %s: %s", exp.Labels, d))
+			}
+		} else if !containsAlert(got, exp, opts) {
+			diffs = append(diffs, fmt.Sprintf("alert %s: not received", exp.Labels))
+		}
+	}
+
+	for _, a := range got {
+		for _, fa := range e.fields {
+			if v := fa.get(a); v != fa.value {
+				diffs = append(diffs, fmt.Sprintf("alert %s: expected %s, got %q", a.Labels, fa, v))
+			}
+		}
+	}
+	return diffs
+}
+
+// fieldAssertion requires a label or annotation of received alerts to
+// have a value.
+type fieldAssertion struct {
+	annotation bool
+	name       model.LabelName
+	value      model.LabelValue
+}
+
+func (fa fieldAssertion) String() string {
+	kind := "label"
+	if fa.annotation {
+		kind = "annotation"
+	}
+	return fmt.Sprintf("%s %s=%q", kind, fa.name, fa.value)
+}
+
+func (fa fieldAssertion) get(a *model.Alert) model.LabelValue {
+	if fa.annotation {
+		return a.Annotations[fa.name]
+	}
+	return a.Labels[fa.name]
+}
+
+func containsAlert(as model.Alerts, a *model.Alert, opts *AcceptanceOpts) bool {
+	for _, b := range as {
+		if equalAlerts(a, b, opts) {
+			return true
+		}
+	}
+	return false
+}
+
+// diffAlert returns the differences between the expected and the received
+// alert.
+func diffAlert(exp, got *model.Alert, opts *AcceptanceOpts) []string {
+	var diffs []string
+	if !reflect.DeepEqual(exp.Labels, got.Labels) {
+		diffs = append(diffs, fmt.Sprintf("labels: expected %s, got %s", exp.Labels, got.Labels))
+	}
+
+	names := map[model.LabelName]struct{}{}
+	for ln := range exp.Annotations {
+		names[ln] = struct{}{}
+	}
+	for ln := range got.Annotations {
+		names[ln] = struct{}{}
+	}
+	sorted := make(model.LabelNames, 0, len(names))
+	for ln := range names {
+		sorted = append(sorted, ln)
+	}
+	sort.Sort(sorted)
+	for _, ln := range sorted {
+		if e, g := exp.Annotations[ln], got.Annotations[ln]; e != g {
+			diffs = append(diffs, fmt.Sprintf("annotation %s: expected %q, got %q", ln, e, g))
+		}
+	}
+
+	if !equalTime(exp.StartsAt, got.StartsAt, opts) {
+		diffs = append(diffs, fmt.Sprintf("starts at: expected %s, got %s", opts.timeString(exp.StartsAt), opts.timeString(got.StartsAt)))
+	}
+	if !equalTime(exp.EndsAt, got.EndsAt, opts) {
+		diffs = append(diffs, fmt.Sprintf("ends at: expected %s, got %s", opts.timeString(exp.EndsAt), opts.timeString(got.EndsAt)))
+	}
+	return diffs
+}
+
 func batchesEqual(as, bs model.Alerts, opts *AcceptanceOpts) bool {
 	if len(as) != len(bs) {
 		return false
 	}
 
 	for _, a := range as {
-		found := false
-		for _, b := range bs {
-			if equalAlerts(a, b, opts) {
-				found = true
-				break
-			}
-		}
-		if !found {
+		if !containsAlert(bs, a, opts) {
 			return false
 		}
 	}
@@ -65,71 +224,136 @@ func (c *Collector) latest() float64 {
 			latest = iv.end
 		}
 	}
+	for _, ea := range c.wantAttempts {
+		if ea.iv.end > latest {
+			latest = ea.iv.end
+		}
+	}
 	return latest
 }
 
 // Want declares that the Collector expects to receive the given alerts
-// within the given time boundaries.
-func (c *Collector) Want(iv Interval, alerts ...*TestAlert) {
+// within the given time boundaries. The returned expectation can be used
+// to further restrict the notification.
+func (c *Collector) Want(iv Interval, alerts ...*TestAlert) *Expectation {
 	var nas model.Alerts
 	for _, a := range alerts {
 		nas = append(nas, a.nativeAlert(c.opts))
 	}
 
-	c.expected[iv] = append(c.expected[iv], nas)
+	e := &Expectation{alerts: nas}
+	c.expected[iv] = append(c.expected[iv], e)
+
+	return e
+}
+
+// WantAttempt declares that the Collector expects a delivery attempt within
+// the given time boundaries that is responded to with the given status code.
+// Every expected attempt must be fulfilled by a different actual attempt.
+func (c *Collector) WantAttempt(iv Interval, status int) {
+	c.wantAttempts = append(c.wantAttempts, expectedAttempt{iv: iv, status: status})
+}
+
+// Attempts returns all delivery attempts recorded so far in order of
+// arrival.
+func (c *Collector) Attempts() []Attempt {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return append([]Attempt(nil), c.attempts...)
+}
+
+// addAttempt records a delivery attempt at the given relative time.
+func (c *Collector) addAttempt(at float64, status int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.attempts = append(c.attempts, Attempt{At: at, Status: status})
 }
 
 // add the given alerts to the collected alerts.
 func (c *Collector) add(alerts ...*model.Alert) {
 	arrival := c.opts.relativeTime(time.Now())
 
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
 	c.collected[arrival] = append(c.collected[arrival], model.Alerts(alerts))
 }
 
 func (c *Collector) check() string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
 	report := fmt.Sprintf("\ncollector %q:\n\n", c)
 
 	for iv, expected := range c.expected {
 		report += fmt.Sprintf("interval %v\n", iv)
 
 		for _, exp := range expected {
-			var found model.Alerts
-
 			report += fmt.Sprintf("---\n")
 
-			for _, e := range exp {
+			for _, e := range exp.alerts {
 				report += fmt.Sprintf("- %v\n", c.opts.alertString(e))
 			}
+			for _, fa := range exp.fields {
+				report += fmt.Sprintf("  with %s\n", fa)
+			}
+			if exp.ordered {
+				report += fmt.Sprintf("  in order\n")
+			}
 
+			// Find a matching batch or otherwise the one closest to
+			// the expectation to report the differences.
+			var (
+				found        bool
+				closest      []string
+				closestAt    float64
+				hasCandidate bool
+			)
 			for at, got := range c.collected {
 				if !iv.contains(at) {
 					continue
 				}
 				for _, a := range got {
-					if batchesEqual(exp, a, c.opts) {
-						found = a
+					diffs := exp.diff(a, c.opts)
+					if len(diffs) == 0 {
+						found = true
 						break
 					}
+					if !hasCandidate || len(diffs) < len(closest) {
+						closest, closestAt, hasCandidate = diffs, at, true
+					}
 				}
-				if found != nil {
+				if found {
 					break
 				}
 			}
 
-			if found != nil {
+			if found {
 				report += fmt.Sprintf("  [ ✓ ]\n")
-			} else {
-				c.t.Fail()
-				report += fmt.Sprintf("  [ ✗ ]\n")
+				continue
+			}
+			c.t.Fail()
+			report += fmt.Sprintf("  [ ✗ ]\n")
+			if !hasCandidate {
+				report += fmt.Sprintf("  no notification received in interval\n")
+				continue
+			}
+			report += fmt.Sprintf("  closest notification @ %v:\n", closestAt)
+			for _, d := range closest {
+				report += fmt.Sprintf("    %s\n", d)
 			}
 		}
 	}
 
+	report += c.checkAttempts()
+
 	// Detect unexpected notifications.
 	var totalExp, totalAct int
 	for _, exp := range c.expected {
 		for _, e := range exp {
-			totalExp += len(e)
+			totalExp += len(e.alerts)
 		}
 	}
 	for _, act := range c.collected {
@@ -156,7 +380,48 @@ func (c *Collector) check() string {
 				}
 			}
 		}
+
+		if len(c.wantAttempts) > 0 {
+			report += "\nattempts:\n"
+			for _, a := range c.attempts {
+				report += fmt.Sprintf("%v\n", a)
+			}
+		}
+	}
+
+	return report
+}
+
+// checkAttempts matches the expected delivery attempts against the recorded
+// ones in order of their intervals. It must be called with mtx held.
+func (c *Collector) checkAttempts() string {
+	if len(c.wantAttempts) == 0 {
+		return ""
 	}
+	expected := append([]expectedAttempt(nil), c.wantAttempts...)
+	sort.SliceStable(expected, func(i, j int) bool {
+		return expected[i].iv.start < expected[j].iv.start
+	})
+
+	report := "\nattempts\n"
+	used := make([]bool, len(c.attempts))
+
+	for _, ea := range expected {
+		report += fmt.Sprintf("- %v: %d", ea.iv, ea.status)
 
+		found := false
+		for i, a := range c.attempts {
+			if !used[i] && a.Status == ea.status && ea.iv.contains(a.At) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if found {
+			report += fmt.Sprintf(" [ ✓ ]\n")
+		} else {
+			c.t.Fail()
+			report += fmt.Sprintf(" [ ✗ ]\n")
+		}
+	}
 	return report
 }
@@ -19,6 +19,7 @@ import (
 	"net"
 	"net/http"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/prometheus/common/model"
@@ -218,16 +219,28 @@ func equalTime(a, b time.Time, opts *AcceptanceOpts) bool {
 	return diff <= opts.Tolerance
 }
 
+// MockWebhook is a webhook receiver that records the alerts of all
+// notifications it receives in a Collector.
 type MockWebhook struct {
 	opts      *AcceptanceOpts
 	collector *Collector
 	listener  net.Listener
 
+	// Func is called with the relative arrival time of every notification
+	// before it is recorded. If it returns true, the notification is
+	// dropped.
 	Func func(timestamp float64) bool
+
+	// status returns the status code to respond with to a notification
+	// arriving at the given relative time. If it is nil, all notifications
+	// are responded to successfully.
+	status func(timestamp float64) int
 }
 
+// NewWebhook returns a webhook receiver listening on a random port that
+// records notifications in the collector.
 func NewWebhook(c *Collector) *MockWebhook {
-	l, err := net.Listen("tcp4", ":0")
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
 	if err != nil {
 		// TODO(fabxc): if shutdown of mock destinations ever becomes a concern
 		// we want to shut them down after test completion. Then we might want to
@@ -235,6 +248,7 @@ func NewWebhook(c *Collector) *MockWebhook {
 		panic(err)
 	}
 	wh := &MockWebhook{
+		opts:      c.opts,
 		listener:  l,
 		collector: c,
 	}
@@ -244,9 +258,22 @@ func NewWebhook(c *Collector) *MockWebhook {
 }
 
 func (ws *MockWebhook) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ts := ws.opts.relativeTime(time.Now())
+
+	status := http.StatusOK
+	if ws.status != nil {
+		status = ws.status(ts)
+	}
+	ws.collector.addAttempt(ts, status)
+
+	if status/100 != 2 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
 	// Inject Func if it exists.
 	if ws.Func != nil {
-		if ws.Func(ws.opts.relativeTime(time.Now())) {
+		if ws.Func(ts) {
 			return
 		}
 	}
@@ -256,12 +283,65 @@ func (ws *MockWebhook) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	var v notify.WebhookMessage
 	if err := dec.Decode(&v); err != nil {
-		panic(err)
+		ws.collector.t.Errorf("collector %q: error decoding notification: %s", ws.collector, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	ws.collector.add(v.Alerts...)
 }
 
+// Address returns the host and port the webhook is listening on.
 func (ws *MockWebhook) Address() string {
 	return ws.listener.Addr().String()
 }
+
+// URL returns the URL to configure as the url of a webhook_configs entry
+// to send notifications to the webhook.
+func (ws *MockWebhook) URL() string {
+	return fmt.Sprintf("http://%s/", ws.Address())
+}
+
+// Collector returns the collector the webhook records notifications in.
+func (ws *MockWebhook) Collector() *Collector {
+	return ws.collector
+}
+
+// FlakyReceiver is a webhook receiver that responds to notifications with
+// scripted status codes. All delivery attempts are recorded in its
+// collector, but only the alerts of successful ones are collected.
+type FlakyReceiver struct {
+	*MockWebhook
+
+	mtx       sync.Mutex
+	responses []scriptedResponse
+}
+
+type scriptedResponse struct {
+	iv     Interval
+	status int
+}
+
+// Respond makes the receiver respond with the given status code to all
+// notifications arriving within the interval. Notifications outside of all
+// scripted intervals are responded to successfully. If intervals overlap,
+// the one scripted first takes precedence.
+func (fr *FlakyReceiver) Respond(iv Interval, status int) *FlakyReceiver {
+	fr.mtx.Lock()
+	defer fr.mtx.Unlock()
+
+	fr.responses = append(fr.responses, scriptedResponse{iv: iv, status: status})
+	return fr
+}
+
+func (fr *FlakyReceiver) status(ts float64) int {
+	fr.mtx.Lock()
+	defer fr.mtx.Unlock()
+
+	for _, r := range fr.responses {
+		if r.iv.contains(ts) {
+			return r.status
+		}
+	}
+	return http.StatusOK
+}
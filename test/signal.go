@@ -0,0 +1,28 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import "os"
+
+// signaler delivers lifecycle requests to Alertmanager processes. How
+// this is done depends on the platform.
+type signaler interface {
+	// terminate asks the process to shut down.
+	terminate(p *os.Process) error
+	// reload asks the process to reload its configuration.
+	reload(p *os.Process) error
+}
+
+// procSignaler is the signaler for the current platform.
+var procSignaler signaler = platformSignaler{}
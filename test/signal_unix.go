@@ -0,0 +1,33 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package test
+
+import (
+	"os"
+	"syscall"
+)
+
+// platformSignaler delivers requests as POSIX signals.
+type platformSignaler struct{}
+
+func (platformSignaler) terminate(p *os.Process) error {
+	return syscall.Kill(p.Pid, syscall.SIGTERM)
+}
+
+func (platformSignaler) reload(p *os.Process) error {
+	return syscall.Kill(p.Pid, syscall.SIGHUP)
+}
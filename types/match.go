@@ -27,27 +27,34 @@ type Matcher struct {
 	Name  model.LabelName
 	Value string
 
-	isRegex bool
-	regex   *regexp.Regexp
+	isRegex    bool
+	isNegative bool
+	regex      *regexp.Regexp
 }
 
 func (m *Matcher) String() string {
+	var neg string
+	if m.isNegative {
+		neg = "Negative"
+	}
 	if m.isRegex {
-		return fmt.Sprintf("<RegexMatcher %s:%q>", m.Name, m.Value)
+		return fmt.Sprintf("<%sRegexMatcher %s:%q>", neg, m.Name, m.Value)
 	}
-	return fmt.Sprintf("<Matcher %s:%q>", m.Name, m.Value)
+	return fmt.Sprintf("<%sMatcher %s:%q>", neg, m.Name, m.Value)
 }
 
 // MarshalJSON implements json.Marshaler.
 func (m *Matcher) MarshalJSON() ([]byte, error) {
 	v := struct {
-		Name    model.LabelName `json:"name"`
-		Value   string          `json:"value"`
-		IsRegex bool            `json:"isRegex"`
+		Name       model.LabelName `json:"name"`
+		Value      string          `json:"value"`
+		IsRegex    bool            `json:"isRegex"`
+		IsNegative bool            `json:"isNegative,omitempty"`
 	}{
-		Name:    m.Name,
-		Value:   m.Value,
-		IsRegex: m.isRegex,
+		Name:       m.Name,
+		Value:      m.Value,
+		IsRegex:    m.isRegex,
+		IsNegative: m.isNegative,
 	}
 	return json.Marshal(&v)
 }
@@ -57,6 +64,12 @@ func (m *Matcher) IsRegex() bool {
 	return m.isRegex
 }
 
+// IsNegative returns true if the matcher is fulfilled by label values that
+// do not match its value.
+func (m *Matcher) IsNegative() bool {
+	return m.isNegative
+}
+
 // Match checks whether the label of the matcher has the specified
 // matching value.
 func (m *Matcher) Match(lset model.LabelSet) bool {
@@ -65,10 +78,13 @@ func (m *Matcher) Match(lset model.LabelSet) bool {
 	// for the comparison below.
 	v := lset[m.Name]
 
+	var matches bool
 	if m.isRegex {
-		return m.regex.MatchString(string(v))
+		matches = m.regex.MatchString(string(v))
+	} else {
+		matches = string(v) == m.Value
 	}
-	return string(v) == m.Value
+	return matches != m.isNegative
 }
 
 // NewMatcher returns a new matcher that compares against equality of
@@ -96,6 +112,22 @@ func NewRegexMatcher(name model.LabelName, re *regexp.Regexp) *Matcher {
 	}
 }
 
+// NewNegativeMatcher returns a new matcher that is fulfilled if the label
+// value is not equal to the given value.
+func NewNegativeMatcher(name model.LabelName, value string) *Matcher {
+	m := NewMatcher(name, value)
+	m.isNegative = true
+	return m
+}
+
+// NewNegativeRegexMatcher returns a new matcher that is fulfilled if the
+// label value does not match the given regular expression.
+func NewNegativeRegexMatcher(name model.LabelName, re *regexp.Regexp) *Matcher {
+	m := NewRegexMatcher(name, re)
+	m.isNegative = true
+	return m
+}
+
 // Matchers provides the Match and Fingerprint methods for a slice of Matchers.
 type Matchers []*Matcher
 
@@ -114,7 +146,11 @@ func (ms Matchers) Fingerprint() model.Fingerprint {
 	lset := make(model.LabelSet, 3*len(ms))
 
 	for _, m := range ms {
-		lset[model.LabelName(fmt.Sprintf("%s-%s-%v", m.Name, m.Value, m.isRegex))] = ""
+		key := fmt.Sprintf("%s-%s-%v", m.Name, m.Value, m.isRegex)
+		if m.isNegative {
+			key += "-negative"
+		}
+		lset[model.LabelName(key)] = ""
 	}
 
 	return lset.Fingerprint()